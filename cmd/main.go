@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
 	"it-user-service/internal/config"
+	"it-user-service/internal/handlers"
 	"it-user-service/internal/logger"
 	"it-user-service/internal/server"
 )
@@ -16,26 +24,116 @@ func main() {
 		// Las variables de entorno del sistema tendrán precedencia
 	}
 
+	// Subcomando "config print": muestra la configuración efectiva y termina
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		os.Exit(printConfig(os.Args[3:]))
+	}
+
+	// Subcomando "openapi": imprime el documento OpenAPI generado a partir de las rutas
+	if len(os.Args) > 1 && os.Args[1] == "openapi" {
+		os.Exit(printOpenAPI())
+	}
+
 	// Inicializar logger
 	logger.Init()
 	log := logger.GetLogger()
 
+	// Subcomando "migrate": aplica las migraciones del esquema y termina
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrations(os.Args[2:]))
+	}
+
 	// Cargar configuración
-	cfg := config.LoadConfig()
-	
-	log.WithField("environment", cfg.Environment).WithField("port", cfg.Port).Info("Starting User Service")
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger.SetLevel(cfg.LogLevel)
+	logger.SetRedaction(logger.RedactionPolicy{Mode: cfg.LogRedaction, Fields: cfg.LogRedactedFields})
+
+	log.WithField("environment", cfg.Environment).WithField("port", cfg.Server.Port).Info("Starting User Service")
 
 	// Crear servidor
-	srv, err := server.NewServer(cfg)
+	srv, err := server.NewServer(*cfg)
 	if err != nil {
 		log.WithError(err).Fatal("Error creating server")
 		os.Exit(1)
 	}
 
-	// Iniciar servidor
-	log.WithField("port", cfg.Port).Info("Server initialized successfully")
-	if err := srv.Start(); err != nil {
-		log.WithError(err).Fatal("Error starting server")
+	// Iniciar servidor hasta recibir SIGTERM (Cloud Run) o SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	log.WithField("port", cfg.Server.Port).Info("Server initialized successfully")
+	if err := srv.Run(ctx); err != nil {
+		log.WithError(err).Error("Server stopped with errors")
 		os.Exit(1)
 	}
 }
+
+// printConfig imprime la configuración efectiva en YAML. Con --redacted oculta los
+// secretos. Los problemas de validación se reportan por stderr
+func printConfig(args []string) int {
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := fs.Bool("redacted", false, "hide secret values")
+
+	cfg, err := config.Parse(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err := cfg.WriteYAML(os.Stdout, *redacted); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// runMigrations aplica las migraciones con la misma configuración que el servidor
+func runMigrations(args []string) int {
+	log := logger.GetLogger()
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	logger.SetLevel(cfg.LogLevel)
+
+	if err := server.Migrate(context.Background(), *cfg); err != nil {
+		log.WithError(err).Error("Database migration failed")
+		return 1
+	}
+	log.Info("Database migrations applied")
+	return 0
+}
+
+// printOpenAPI imprime el documento OpenAPI en JSON
+func printOpenAPI() int {
+	doc, err := handlers.APIDocument()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// FirebaseCertsURL publica los certificados con los que Firebase firma los ID tokens
+const FirebaseCertsURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+
+// defaultCertsMaxAge se usa cuando la respuesta de certificados no trae Cache-Control
+const defaultCertsMaxAge = time.Hour
+
+// FirebaseClaims son los claims de un ID token de Firebase Authentication
+type FirebaseClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	AuthTime      int64  `json:"auth_time"`
+	Firebase      struct {
+		SignInProvider string `json:"sign_in_provider"`
+	} `json:"firebase"`
+	jwt.RegisteredClaims
+}
+
+// FirebaseVerifier verifica ID tokens de Firebase contra los certificados públicos de Google
+type FirebaseVerifier struct {
+	projectID  string
+	certsURL   string
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	expiresAt time.Time
+}
+
+func NewFirebaseVerifier(projectID string) *FirebaseVerifier {
+	return &FirebaseVerifier{
+		projectID:  projectID,
+		certsURL:   FirebaseCertsURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Verify valida firma, expiración, audiencia, emisor y subject de un ID token de Firebase
+func (v *FirebaseVerifier) Verify(ctx context.Context, idToken string) (*FirebaseClaims, error) {
+	claims := &FirebaseClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("firebase token has no kid header")
+		}
+		return v.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithAudience(v.projectID),
+		jwt.WithIssuer("https://securetoken.google.com/"+v.projectID),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid firebase token: %w", err)
+	}
+
+	if claims.Subject == "" || len(claims.Subject) > 128 {
+		return nil, errors.New("invalid firebase token: bad subject")
+	}
+	if claims.AuthTime > time.Now().Unix() {
+		return nil, errors.New("invalid firebase token: auth_time in the future")
+	}
+	return claims, nil
+}
+
+func (v *FirebaseVerifier) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	fresh := time.Now().Before(v.expiresAt)
+	v.mu.RUnlock()
+	if ok && fresh {
+		return key, nil
+	}
+
+	if err := v.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	key, ok = v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown firebase key id %q", kid)
+	}
+	return key, nil
+}
+
+// refreshKeys descarga los certificados y los cachea según Cache-Control max-age
+func (v *FirebaseVerifier) refreshKeys(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.certsURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch firebase certificates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch firebase certificates: status %d", resp.StatusCode)
+	}
+
+	var certs map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
+		return fmt.Errorf("failed to decode firebase certificates: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for kid, pemCert := range certs {
+		key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(pemCert))
+		if err != nil {
+			return fmt.Errorf("invalid firebase certificate %q: %w", kid, err)
+		}
+		keys[kid] = key
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.expiresAt = time.Now().Add(cacheMaxAge(resp.Header.Get("Cache-Control")))
+	v.mu.Unlock()
+	return nil
+}
+
+func cacheMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.HasPrefix(directive, "max-age=") {
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return defaultCertsMaxAge
+}
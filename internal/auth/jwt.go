@@ -4,21 +4,35 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// DefaultTokenTTL es la vigencia de los tokens emitidos por GenerateToken
+const DefaultTokenTTL = 24 * time.Hour
+
+// JWTManager emite y valida los tokens del servicio. Con un key ring firma con la llave
+// activa (RS256/ES256) y valida por kid, y no acepta tokens HMAC; sin key ring firma y
+// valida con el secreto HMAC
 type JWTManager struct {
-	secretKey string
-	issuer    string
+	issuer  string
+	keyRing *KeyRing
+
+	mu             sync.RWMutex
+	secretKey      string
+	previousSecret string
 }
 
 type Claims struct {
 	UserID string   `json:"user_id"`
 	Email  string   `json:"email"`
 	Roles  []string `json:"roles"`
+	// SessionID identifica la sesión/dispositivo para poder revocar el token
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -29,30 +43,94 @@ func NewJWTManager(secretKey, issuer string) *JWTManager {
 	}
 }
 
+// NewJWTManagerWithKeyRing crea un JWTManager que firma con llaves asimétricas. Los
+// tokens HS256 se rechazan: quien tenga el secreto compartido no puede emitir tokens
+func NewJWTManagerWithKeyRing(keyRing *KeyRing, issuer string) *JWTManager {
+	return &JWTManager{
+		issuer:  issuer,
+		keyRing: keyRing,
+	}
+}
+
+// SetSecret rota el secreto HMAC. El secreto anterior se sigue aceptando para validar
+// los tokens ya emitidos hasta la próxima rotación
+func (j *JWTManager) SetSecret(secretKey string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if secretKey == j.secretKey {
+		return
+	}
+	j.previousSecret = j.secretKey
+	j.secretKey = secretKey
+}
+
+// KeyRing retorna el key ring usado para firmar, o nil si se usa HMAC
+func (j *JWTManager) KeyRing() *KeyRing {
+	return j.keyRing
+}
+
 func (j *JWTManager) GenerateToken(userID, email string, roles []string) (string, error) {
 	claims := Claims{
 		UserID: userID,
 		Email:  email,
 		Roles:  roles,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(DefaultTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    j.issuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	return j.sign(claims)
+}
+
+// GenerateSessionToken emite un access token de corta duración ligado a una sesión.
+// Retorna el token y su fecha de expiración
+func (j *JWTManager) GenerateSessionToken(userID, email, sessionID string, roles []string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := Claims{
+		UserID:    userID,
+		Email:     email,
+		Roles:     roles,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    j.issuer,
+		},
+	}
+
+	signed, err := j.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// sign firma los claims con la llave activa del key ring, o con el secreto HMAC
+func (j *JWTManager) sign(claims Claims) (string, error) {
+	if j.keyRing == nil {
+		j.mu.RLock()
+		secretKey := j.secretKey
+		j.mu.RUnlock()
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(secretKey))
+	}
+
+	key := j.keyRing.SigningKey()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
 }
 
+// ValidateToken valida la firma, la vigencia y el emisor (iss) del token
 func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(j.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, j.verificationKey, jwt.WithIssuer(j.issuer))
 
 	if err != nil {
 		return nil, err
@@ -65,8 +143,41 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
+// verificationKey selecciona la llave de verificación: por kid para tokens asimétricos,
+// el secreto HMAC para tokens HS256 cuando no hay key ring
+func (j *JWTManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		j.mu.RLock()
+		defer j.mu.RUnlock()
+		if j.keyRing != nil || j.secretKey == "" {
+			return nil, errors.New("HMAC signed tokens are not accepted")
+		}
+		if j.previousSecret == "" {
+			return []byte(j.secretKey), nil
+		}
+		return jwt.VerificationKeySet{Keys: []jwt.VerificationKey{[]byte(j.secretKey), []byte(j.previousSecret)}}, nil
+	}
+
+	if j.keyRing == nil {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := j.keyRing.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key id %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.PublicKey(), nil
+}
+
 func (j *JWTManager) ExtractTokenFromHeader(c *gin.Context) (string, error) {
-	authHeader := c.GetHeader("Authorization")
+	return ExtractBearerToken(c.GetHeader("Authorization"))
+}
+
+// ExtractBearerToken obtiene el token de un header Authorization con formato "Bearer {token}"
+func ExtractBearerToken(authHeader string) (string, error) {
 	if authHeader == "" {
 		return "", errors.New("authorization header required")
 	}
@@ -77,4 +188,4 @@ func (j *JWTManager) ExtractTokenFromHeader(c *gin.Context) (string, error) {
 	}
 
 	return parts[1], nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,513 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"it-user-service/internal/logger"
+)
+
+// Algoritmos de firma asimétricos soportados por el key ring
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+)
+
+// KeyState es el estado de una llave dentro del key ring
+type KeyState string
+
+const (
+	// KeyStateActive es la llave con la que se firman los tokens nuevos
+	KeyStateActive KeyState = "active"
+	// KeyStateNext se publica antes de activarse para que los verificadores la cacheen
+	KeyStateNext KeyState = "next"
+	// KeyStateRetired ya no firma, pero valida los tokens emitidos antes de rotar
+	KeyStateRetired KeyState = "retired"
+)
+
+// rotationCheckInterval es cada cuánto se revisa si toca rotar
+const rotationCheckInterval = time.Minute
+
+var ErrNoNextSigningKey = errors.New("no next signing key to rotate to")
+
+// SigningKey es una llave asimétrica identificada por su kid (thumbprint RFC 7638)
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	State     KeyState
+	RetiredAt time.Time
+
+	private crypto.Signer
+}
+
+// PublicKey retorna la llave pública usada para verificar firmas
+func (k *SigningKey) PublicKey() crypto.PublicKey {
+	return k.private.Public()
+}
+
+// KeySet son las llaves en PEM entregadas por un KeySource
+type KeySet struct {
+	Active  []byte
+	Next    []byte
+	Retired [][]byte
+}
+
+// KeySource provee las llaves del key ring (archivos, proveedor de secretos, ...)
+type KeySource interface {
+	LoadKeys(ctx context.Context) (*KeySet, error)
+}
+
+// FileKeySource lee las llaves desde archivos PEM
+type FileKeySource struct {
+	ActivePath   string
+	NextPath     string
+	RetiredPaths []string
+}
+
+// LoadKeys lee los archivos configurados. Los archivos next y retired son opcionales
+func (s FileKeySource) LoadKeys(ctx context.Context) (*KeySet, error) {
+	set := &KeySet{}
+	var err error
+	if set.Active, err = os.ReadFile(s.ActivePath); err != nil {
+		return nil, fmt.Errorf("failed to read active signing key: %w", err)
+	}
+	if s.NextPath != "" {
+		if set.Next, err = os.ReadFile(s.NextPath); err != nil {
+			return nil, fmt.Errorf("failed to read next signing key: %w", err)
+		}
+	}
+	for _, path := range s.RetiredPaths {
+		pemKey, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read retired signing key: %w", err)
+		}
+		set.Retired = append(set.Retired, pemKey)
+	}
+	return set, nil
+}
+
+// KeyRingConfig configura la rotación del key ring
+type KeyRingConfig struct {
+	// Algorithm es el algoritmo de las llaves generadas cuando no hay KeySource
+	Algorithm string
+	// RotationInterval activa la llave siguiente en cada múltiplo del intervalo
+	// (0 = sin rotación programada)
+	RotationInterval time.Duration
+	// RotationOverlap es cuánto se publica la llave siguiente antes de activarse, para
+	// que los verificadores la tengan en caché
+	RotationOverlap time.Duration
+	// RetiredKeyTTL es cuánto se conserva una llave después de que el ring la retira.
+	// Debe ser mayor que la vigencia de los tokens
+	RetiredKeyTTL time.Duration
+	// ReloadInterval recarga las llaves desde el KeySource (0 = sin recarga)
+	ReloadInterval time.Duration
+}
+
+// KeyRing mantiene las llaves activa, siguiente y retiradas. Con un KeySource el ring
+// recarga las llaves periódicamente y, según RotationInterval, activa la siguiente de la
+// fuente; el operador publica después una nueva siguiente. Sin fuente las llaves se
+// generan en memoria (solo apto para una réplica)
+type KeyRing struct {
+	source KeySource
+	cfg    KeyRingConfig
+
+	mu      sync.RWMutex
+	active  *SigningKey
+	next    *SigningKey
+	retired []*SigningKey
+	// activeSince y nextSince indican desde cuándo el ring firma con la activa y publica la siguiente
+	activeSince time.Time
+	nextSince   time.Time
+	// promoted es el kid de la llave siguiente de la fuente que el ring ya activó
+	promoted string
+}
+
+// NewKeyRing crea el key ring cargando las llaves desde source, o generándolas si source es nil
+func NewKeyRing(ctx context.Context, source KeySource, cfg KeyRingConfig) (*KeyRing, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgorithmES256
+	}
+	if cfg.Algorithm != AlgorithmRS256 && cfg.Algorithm != AlgorithmES256 {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", cfg.Algorithm)
+	}
+
+	ring := &KeyRing{source: source, cfg: cfg}
+	if source != nil {
+		if err := ring.Reload(ctx); err != nil {
+			return nil, err
+		}
+		return ring, nil
+	}
+
+	active, err := GenerateSigningKey(cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	next, err := GenerateSigningKey(cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	active.State = KeyStateActive
+	next.State = KeyStateNext
+	ring.active, ring.next = active, next
+	ring.activeSince = time.Now()
+	ring.nextSince = ring.activeSince
+	return ring, nil
+}
+
+// SigningKey retorna la llave activa
+func (r *KeyRing) SigningKey() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// Key obtiene una llave por su kid
+func (r *KeyRing) Key(kid string) (*SigningKey, bool) {
+	for _, key := range r.Keys() {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Keys retorna todas las llaves publicadas: activa, siguiente y retiradas
+func (r *KeyRing) Keys() []*SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := []*SigningKey{r.active}
+	if r.next != nil {
+		keys = append(keys, r.next)
+	}
+	return append(keys, r.retired...)
+}
+
+// Rotate activa la llave siguiente y retira la activa. Con llaves generadas también
+// genera una nueva siguiente; con un KeySource la siguiente queda vacía hasta que la
+// fuente publique otra
+func (r *KeyRing) Rotate() error {
+	var next *SigningKey
+	if r.source == nil {
+		var err error
+		if next, err = GenerateSigningKey(r.cfg.Algorithm); err != nil {
+			return err
+		}
+		next.State = KeyStateNext
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next == nil {
+		return ErrNoNextSigningKey
+	}
+
+	now := time.Now()
+	retired := *r.active
+	retired.State = KeyStateRetired
+	retired.RetiredAt = now
+
+	active := *r.next
+	active.State = KeyStateActive
+	if r.source != nil {
+		r.promoted = active.ID
+	}
+	r.active, r.next = &active, next
+	r.activeSince, r.nextSince = now, now
+	r.retired = append([]*SigningKey{&retired}, r.retired...)
+	r.pruneRetired(now)
+	return nil
+}
+
+// Reload vuelve a cargar las llaves desde el KeySource. La activa anterior que ya no
+// está en la fuente se conserva retirada durante RetiredKeyTTL, para que los tokens que
+// firmó sigan validando aunque el operador no la liste como retirada
+func (r *KeyRing) Reload(ctx context.Context) error {
+	if r.source == nil {
+		return nil
+	}
+
+	set, err := r.source.LoadKeys(ctx)
+	if err != nil {
+		return err
+	}
+	active, err := ParseSigningKey(set.Active, KeyStateActive)
+	if err != nil {
+		return fmt.Errorf("invalid active signing key: %w", err)
+	}
+	var next *SigningKey
+	if len(set.Next) > 0 {
+		if next, err = ParseSigningKey(set.Next, KeyStateNext); err != nil {
+			return fmt.Errorf("invalid next signing key: %w", err)
+		}
+	}
+	retired := make([]*SigningKey, 0, len(set.Retired))
+	for _, pemKey := range set.Retired {
+		key, err := ParseSigningKey(pemKey, KeyStateRetired)
+		if err != nil {
+			return fmt.Errorf("invalid retired signing key: %w", err)
+		}
+		retired = append(retired, key)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+
+	// La fuente todavía no refleja la rotación que hizo el ring
+	if next != nil && next.ID == r.promoted {
+		active.State = KeyStateRetired
+		active.RetiredAt = r.activeSince
+		next.State = KeyStateActive
+		retired = append([]*SigningKey{active}, retired...)
+		active, next = next, nil
+	} else {
+		r.promoted = ""
+	}
+
+	loaded := map[string]bool{active.ID: true}
+	if next != nil {
+		loaded[next.ID] = true
+	}
+	for _, key := range retired {
+		loaded[key.ID] = true
+	}
+	if r.active != nil && !loaded[r.active.ID] {
+		previous := *r.active
+		previous.State = KeyStateRetired
+		previous.RetiredAt = now
+		retired = append(retired, &previous)
+	}
+	for _, key := range r.retired {
+		if !loaded[key.ID] && !key.RetiredAt.IsZero() {
+			retired = append(retired, key)
+		}
+	}
+
+	if r.active == nil || r.active.ID != active.ID {
+		r.activeSince = now
+	}
+	if next != nil && (r.next == nil || r.next.ID != next.ID) {
+		r.nextSince = now
+	}
+	r.active, r.next, r.retired = active, next, retired
+	r.pruneRetired(now)
+	return nil
+}
+
+// Start recarga las llaves desde la fuente y ejecuta la rotación programada hasta que
+// ctx se cancele
+func (r *KeyRing) Start(ctx context.Context) {
+	var reload, rotate <-chan time.Time
+	if r.source != nil && r.cfg.ReloadInterval > 0 {
+		ticker := time.NewTicker(r.cfg.ReloadInterval)
+		reload = ticker.C
+		context.AfterFunc(ctx, ticker.Stop)
+	}
+	if r.cfg.RotationInterval > 0 {
+		ticker := time.NewTicker(min(r.cfg.RotationInterval, rotationCheckInterval))
+		rotate = ticker.C
+		context.AfterFunc(ctx, ticker.Stop)
+	}
+	if reload == nil && rotate == nil {
+		return
+	}
+
+	go func() {
+		log := logger.GetLogger()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reload:
+				if err := r.Reload(ctx); err != nil {
+					log.WithError(err).Error("Failed to reload signing keys")
+				}
+			case now := <-rotate:
+				if !r.rotationDue(now) {
+					continue
+				}
+				if err := r.Rotate(); err != nil {
+					log.WithError(err).Error("Failed to rotate signing keys")
+					continue
+				}
+				log.WithField("kid", r.SigningKey().ID).Info("Signing key rotated")
+			}
+		}
+	}()
+}
+
+// rotationDue indica si toca activar la llave siguiente. Se rota en los múltiplos de
+// RotationInterval, así las réplicas rotan a la vez, y solo cuando la siguiente lleva
+// publicada al menos RotationOverlap
+func (r *KeyRing) rotationDue(now time.Time) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cfg.RotationInterval <= 0 || r.next == nil {
+		return false
+	}
+	boundary := now.Truncate(r.cfg.RotationInterval)
+	return boundary.After(r.activeSince) && now.Sub(r.nextSince) >= r.cfg.RotationOverlap
+}
+
+// pruneRetired descarta las llaves que el ring retiró hace más de RetiredKeyTTL. Las
+// retiradas de la fuente se conservan mientras sigan en ella
+func (r *KeyRing) pruneRetired(now time.Time) {
+	if r.cfg.RetiredKeyTTL <= 0 {
+		return
+	}
+	kept := r.retired[:0]
+	for _, key := range r.retired {
+		if key.RetiredAt.IsZero() || now.Sub(key.RetiredAt) < r.cfg.RetiredKeyTTL {
+			kept = append(kept, key)
+		}
+	}
+	r.retired = kept
+}
+
+// GenerateSigningKey genera una llave nueva para el algoritmo indicado
+func GenerateSigningKey(algorithm string) (*SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newSigningKey(private, algorithm)
+}
+
+// ParseSigningKey lee una llave privada PEM (RSA para RS256, EC P-256 para ES256)
+func ParseSigningKey(pemKey []byte, state KeyState) (*SigningKey, error) {
+	var key *SigningKey
+	var err error
+	if rsaKey, rsaErr := jwt.ParseRSAPrivateKeyFromPEM(pemKey); rsaErr == nil {
+		key, err = newSigningKey(rsaKey, AlgorithmRS256)
+	} else if ecKey, ecErr := jwt.ParseECPrivateKeyFromPEM(pemKey); ecErr == nil {
+		if ecKey.Curve != elliptic.P256() {
+			return nil, errors.New("ES256 requires a P-256 key")
+		}
+		key, err = newSigningKey(ecKey, AlgorithmES256)
+	} else {
+		return nil, errors.New("key must be a PEM encoded RSA or EC private key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.State = state
+	return key, nil
+}
+
+func newSigningKey(private crypto.Signer, algorithm string) (*SigningKey, error) {
+	jwk, err := publicJWK(private.Public(), algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{
+		ID:        jwk.thumbprint(),
+		Algorithm: algorithm,
+		private:   private,
+	}, nil
+}
+
+// JWK es una llave pública en formato JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS es el documento publicado en /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS retorna las llaves públicas del ring
+func (r *KeyRing) JWKS() JWKS {
+	keys := r.Keys()
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := publicJWK(key.PublicKey(), key.Algorithm)
+		if err != nil {
+			continue
+		}
+		jwk.Kid = key.ID
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func publicJWK(public crypto.PublicKey, algorithm string) (JWK, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: algorithm,
+			N:   encode(key.N.Bytes()),
+			E:   encode(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Use: "sig",
+			Alg: algorithm,
+			Crv: key.Curve.Params().Name,
+			X:   encode(key.X.FillBytes(make([]byte, size))),
+			Y:   encode(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
+
+// thumbprint calcula el kid según RFC 7638 (miembros requeridos en orden lexicográfico)
+func (k JWK) thumbprint() string {
+	var members interface{}
+	if k.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Crv, k.Kty, k.X, k.Y}
+	}
+	canonical, _ := json.Marshal(members)
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticKeySource struct {
+	set KeySet
+}
+
+func (s *staticKeySource) LoadKeys(ctx context.Context) (*KeySet, error) {
+	set := s.set
+	return &set, nil
+}
+
+func newPEMKey(t *testing.T) ([]byte, string) {
+	t.Helper()
+	key, err := GenerateSigningKey(AlgorithmES256)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key.private.(*ecdsa.PrivateKey))
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), key.ID
+}
+
+func TestKeyRingSignsAndValidatesByKid(t *testing.T) {
+	ring, err := NewKeyRing(context.Background(), nil, KeyRingConfig{Algorithm: AlgorithmES256})
+	assert.NoError(t, err)
+	manager := NewJWTManagerWithKeyRing(ring, "it-user-service")
+
+	token, _, err := manager.GenerateSessionToken("user-1", "user@example.com", "session-1", []string{"admin"}, time.Minute)
+	assert.NoError(t, err)
+
+	claims, err := manager.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Equal(t, "session-1", claims.SessionID)
+}
+
+func TestKeyRingRotationKeepsRetiredKeys(t *testing.T) {
+	ring, err := NewKeyRing(context.Background(), nil, KeyRingConfig{Algorithm: AlgorithmRS256, RetiredKeyTTL: time.Hour})
+	assert.NoError(t, err)
+	manager := NewJWTManagerWithKeyRing(ring, "it-user-service")
+
+	oldToken, err := manager.GenerateToken("user-1", "user@example.com", nil)
+	assert.NoError(t, err)
+	oldKid := ring.SigningKey().ID
+	nextKid := ring.Keys()[1].ID
+
+	assert.NoError(t, ring.Rotate())
+	assert.Equal(t, nextKid, ring.SigningKey().ID)
+
+	retired, ok := ring.Key(oldKid)
+	assert.True(t, ok)
+	assert.Equal(t, KeyStateRetired, retired.State)
+	assert.Len(t, ring.JWKS().Keys, 3)
+
+	_, err = manager.ValidateToken(oldToken)
+	assert.NoError(t, err)
+}
+
+func TestKeyRingRotatesKeysFromSource(t *testing.T) {
+	ctx := context.Background()
+	activePEM, activeKid := newPEMKey(t)
+	nextPEM, nextKid := newPEMKey(t)
+	source := &staticKeySource{set: KeySet{Active: activePEM, Next: nextPEM}}
+	ring, err := NewKeyRing(ctx, source, KeyRingConfig{
+		RotationInterval: time.Hour,
+		RotationOverlap:  30 * time.Minute,
+		RetiredKeyTTL:    time.Hour,
+	})
+	require.NoError(t, err)
+	manager := NewJWTManagerWithKeyRing(ring, "it-user-service")
+	oldToken, err := manager.GenerateToken("user-1", "user@example.com", nil)
+	require.NoError(t, err)
+
+	// La siguiente recién se publicó: todavía no se activa
+	assert.False(t, ring.rotationDue(time.Now()))
+	assert.True(t, ring.rotationDue(time.Now().Add(time.Hour)))
+
+	require.NoError(t, ring.Rotate())
+	assert.Equal(t, nextKid, ring.SigningKey().ID)
+
+	// La fuente todavía no refleja la rotación: la recarga la mantiene
+	require.NoError(t, ring.Reload(ctx))
+	assert.Equal(t, nextKid, ring.SigningKey().ID)
+	assert.ErrorIs(t, ring.Rotate(), ErrNoNextSigningKey)
+
+	// El operador publica la rotación sin listar la llave anterior como retirada
+	newNextPEM, _ := newPEMKey(t)
+	source.set = KeySet{Active: nextPEM, Next: newNextPEM}
+	require.NoError(t, ring.Reload(ctx))
+	assert.Equal(t, nextKid, ring.SigningKey().ID)
+	assert.Len(t, ring.Keys(), 3)
+
+	retired, ok := ring.Key(activeKid)
+	require.True(t, ok)
+	assert.Equal(t, KeyStateRetired, retired.State)
+	_, err = manager.ValidateToken(oldToken)
+	assert.NoError(t, err)
+}
+
+func TestJWTManagerWithKeyRingRejectsHMAC(t *testing.T) {
+	ring, err := NewKeyRing(context.Background(), nil, KeyRingConfig{})
+	assert.NoError(t, err)
+
+	hmacToken, err := NewJWTManager("secret", "it-user-service").GenerateToken("user-1", "user@example.com", nil)
+	assert.NoError(t, err)
+
+	manager := NewJWTManagerWithKeyRing(ring, "it-user-service")
+	manager.SetSecret("secret")
+	_, err = manager.ValidateToken(hmacToken)
+	assert.Error(t, err)
+}
+
+func TestJWTManagerRejectsOtherIssuer(t *testing.T) {
+	ring, err := NewKeyRing(context.Background(), nil, KeyRingConfig{})
+	assert.NoError(t, err)
+
+	token, err := NewJWTManagerWithKeyRing(ring, "other-service").GenerateToken("user-1", "user@example.com", nil)
+	assert.NoError(t, err)
+
+	_, err = NewJWTManagerWithKeyRing(ring, "it-user-service").ValidateToken(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+}
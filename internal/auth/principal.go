@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrRateLimited indica que la credencial superó su rate limit (el middleware responde 429)
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+type principalKey struct{}
+
+type authenticatedKey struct{}
+
+// Principal es la identidad autenticada de un request: un usuario (token bearer) o un
+// servicio (API key con scopes)
+type Principal struct {
+	UserID    string
+	Email     string
+	Roles     []string
+	SessionID string
+
+	APIKeyID string
+	Scopes   []string
+}
+
+// IsAPIKey indica si el principal se autenticó con una API key
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != ""
+}
+
+// Subject identifica al principal en logs y rate limiting: "user:<id>" o "apikey:<id>"
+func (p *Principal) Subject() string {
+	if p.IsAPIKey() {
+		return "apikey:" + p.APIKeyID
+	}
+	if p.UserID != "" {
+		return "user:" + p.UserID
+	}
+	return ""
+}
+
+// HasScope indica si el principal tiene el scope indicado. Los usuarios no tienen
+// scopes: su acceso se controla por roles
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole indica si el principal tiene el rol indicado
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessUser indica si el principal puede operar sobre los recursos de userID
+// (es el propio usuario, es administrador o es una API key, cuyos scopes ya se verificaron)
+func (p *Principal) CanAccessUser(userID string) bool {
+	return p.UserID == userID || p.HasRole(RoleAdmin) || p.IsAPIKey()
+}
+
+// RoleAdmin es el rol con acceso a los recursos de cualquier usuario
+const RoleAdmin = "admin"
+
+// WithPrincipal agrega el principal autenticado al contexto
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext obtiene el principal autenticado del contexto, si existe
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// WithAuthentication marca que el request pasó por el middleware de autenticación: sin
+// principal es un request anónimo
+func WithAuthentication(ctx context.Context) context.Context {
+	return context.WithValue(ctx, authenticatedKey{}, true)
+}
+
+// IsAnonymous indica si el request pasó por la autenticación sin credenciales. Sin
+// autenticación configurada (desarrollo local) los requests no son anónimos
+func IsAnonymous(ctx context.Context) bool {
+	_, hasPrincipal := PrincipalFromContext(ctx)
+	authenticated, _ := ctx.Value(authenticatedKey{}).(bool)
+	return authenticated && !hasPrincipal
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationList mantiene las sesiones revocadas cuyos tokens aún no expiran
+type RevocationList interface {
+	Revoke(ctx context.Context, sessionID string, until time.Time)
+	IsRevoked(ctx context.Context, sessionID string) bool
+}
+
+// MemoryRevocationList es una RevocationList en memoria. Cada entrada se conserva
+// hasta until (la expiración máxima de los tokens emitidos para esa sesión)
+type MemoryRevocationList struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// Revoke marca la sesión como revocada hasta until
+func (l *MemoryRevocationList) Revoke(ctx context.Context, sessionID string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if current, ok := l.entries[sessionID]; ok && current.After(until) {
+		return
+	}
+	l.entries[sessionID] = until
+}
+
+// IsRevoked indica si la sesión está revocada
+func (l *MemoryRevocationList) IsRevoked(ctx context.Context, sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+	l.mu.RLock()
+	until, ok := l.entries[sessionID]
+	l.mu.RUnlock()
+	return ok && time.Now().Before(until)
+}
+
+// Prune elimina las entradas cuyos tokens ya expiraron
+func (l *MemoryRevocationList) Prune() {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, until := range l.entries {
+		if !now.Before(until) {
+			delete(l.entries, id)
+		}
+	}
+}
+
+// Len retorna el número de sesiones revocadas vigentes en la lista
+func (l *MemoryRevocationList) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.entries)
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"it-user-service/internal/logger"
+)
+
+// RedisRevocationList comparte las sesiones revocadas entre réplicas usando Redis: una
+// revocación se aplica en todas desde el siguiente request. Cada entrada es una key que
+// expira junto con los tokens de la sesión. Las revocaciones también se guardan en la lista
+// local, que se sincroniza con la base de datos y responde si Redis no está disponible
+type RedisRevocationList struct {
+	local  *MemoryRevocationList
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisRevocationList(local *MemoryRevocationList, client *redis.Client, prefix string) *RedisRevocationList {
+	return &RedisRevocationList{
+		local:  local,
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Revoke marca la sesión como revocada hasta until en la lista local y en Redis
+func (l *RedisRevocationList) Revoke(ctx context.Context, sessionID string, until time.Time) {
+	l.local.Revoke(ctx, sessionID, until)
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return
+	}
+	if err := l.client.Set(ctx, l.prefix+sessionID, 1, ttl).Err(); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to share session revocation")
+	}
+}
+
+// IsRevoked indica si la sesión está revocada en esta réplica o en cualquier otra
+func (l *RedisRevocationList) IsRevoked(ctx context.Context, sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+	if l.local.IsRevoked(ctx, sessionID) {
+		return true
+	}
+	n, err := l.client.Exists(ctx, l.prefix+sessionID).Result()
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Warn("Failed to check shared session revocations, using local list")
+		return false
+	}
+	return n > 0
+}
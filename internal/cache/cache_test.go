@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+var errNotFound = errors.New("not found")
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+	lru.Set(ctx, "a", []byte("1"), time.Minute)
+	lru.Set(ctx, "b", []byte("2"), time.Minute)
+	lru.Get(ctx, "a")
+	lru.Set(ctx, "c", []byte("3"), time.Minute)
+
+	_, ok, _ := lru.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok, _ = lru.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, lru.Len())
+}
+
+func TestLoaderSingleFlightAndNegativeCaching(t *testing.T) {
+	ctx := context.Background()
+	loader := NewLoader(NewLRU(100), time.Minute, time.Minute, errNotFound)
+
+	var calls int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var value string
+			err := loader.Load(ctx, "user:1", &value, func(ctx context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "alice", nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "alice", value)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	missing := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errNotFound
+	}
+	var value string
+	assert.ErrorIs(t, loader.Load(ctx, "user:2", &value, missing), errNotFound)
+	assert.ErrorIs(t, loader.Load(ctx, "user:2", &value, missing), errNotFound)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	loader.Invalidate(ctx, "user:2")
+	assert.ErrorIs(t, loader.Load(ctx, "user:2", &value, missing), errNotFound)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestLoaderSkipsWriteInvalidatedDuringFetch(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(100)
+	loader := NewLoader(lru, time.Minute, time.Minute, errNotFound)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		var value string
+		done <- loader.Load(ctx, "user:1", &value, func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			return "stale", nil
+		})
+	}()
+	<-started
+	loader.Invalidate(ctx, "user:1")
+	close(release)
+	assert.NoError(t, <-done)
+
+	_, ok, _ := lru.Get(ctx, "user:1")
+	assert.False(t, ok)
+}
+
+func TestLoaderCancelledCallerDoesNotFailOthers(t *testing.T) {
+	loader := NewLoader(NewLRU(100), time.Minute, time.Minute, errNotFound)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		select {
+		case <-release:
+			return "alice", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		var value string
+		first <- loader.Load(firstCtx, "user:1", &value, fetch)
+	}()
+	<-started
+
+	second := make(chan string)
+	go func() {
+		var value string
+		assert.NoError(t, loader.Load(context.Background(), "user:1", &value, fetch))
+		second <- value
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(release)
+	assert.Equal(t, "alice", <-second)
+}
+
+func TestLayeredInvalidatesOtherReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := miniredis.RunT(t)
+
+	replicaA := NewLayered(NewLRU(100), redis.NewClient(&redis.Options{Addr: server.Addr()}), "cache:", time.Minute)
+	replicaB := NewLayered(NewLRU(100), redis.NewClient(&redis.Options{Addr: server.Addr()}), "cache:", time.Minute)
+	replicaB.StartInvalidationListener(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, replicaA.Set(ctx, "user:id:1", []byte(`"v1"`), time.Minute))
+	value, ok, err := replicaB.Get(ctx, "user:id:1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `"v1"`, string(value))
+
+	assert.NoError(t, replicaA.Delete(ctx, "user:id:1"))
+	assert.Eventually(t, func() bool {
+		_, ok, _ := replicaB.local.Get(ctx, "user:id:1")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
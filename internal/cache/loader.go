@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"it-user-service/internal/logger"
+)
+
+// negativeMarker se guarda en lugar del valor cuando la entidad no existe
+var negativeMarker = []byte{0}
+
+// fetchTimeout acota las cargas compartidas, que no se cancelan con el request
+const fetchTimeout = 30 * time.Second
+
+// Loader implementa read-through sobre un Cache: serializa los valores en JSON, cachea
+// los "no encontrado" (negative caching) y agrupa las cargas concurrentes de una misma
+// clave en una sola consulta (single-flight)
+type Loader struct {
+	cache       Cache
+	group       singleflight.Group
+	ttl         time.Duration
+	negativeTTL time.Duration
+	notFound    error
+
+	mu sync.Mutex
+	// fetching tiene las claves con una carga en curso; Invalidate las marca como
+	// obsoletas para que la carga no guarde un valor leído antes de la invalidación
+	fetching map[string]bool
+}
+
+// NewLoader crea un Loader. notFound es el error que retorna fetch cuando la entidad no
+// existe; se cachea durante negativeTTL (0 = sin negative caching)
+func NewLoader(cache Cache, ttl, negativeTTL time.Duration, notFound error) *Loader {
+	return &Loader{
+		cache:       cache,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		notFound:    notFound,
+		fetching:    make(map[string]bool),
+	}
+}
+
+// Load obtiene key del caché decodificándola en dst o, si no está, la carga con fetch.
+// La carga se comparte entre los requests concurrentes y recibe un ctx que no se cancela
+// con el de ninguno de ellos; cada request deja de esperarla si se cancela su ctx
+func (l *Loader) Load(ctx context.Context, key string, dst interface{}, fetch func(ctx context.Context) (interface{}, error)) error {
+	log := logger.FromContext(ctx)
+
+	cached, ok, err := l.cache.Get(ctx, key)
+	if err != nil {
+		log.WithError(err).WithField("key", key).Warn("Cache read failed")
+	}
+	if ok {
+		if isNegative(cached) {
+			return l.notFound
+		}
+		if err := json.Unmarshal(cached, dst); err == nil {
+			return nil
+		}
+		l.cache.Delete(ctx, key)
+	}
+
+	result := l.group.DoChan(key, func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		return l.fetch(fetchCtx, key, fetch)
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return res.Err
+		}
+		return json.Unmarshal(res.Val.([]byte), dst)
+	}
+}
+
+// fetch carga el valor y lo guarda en el caché, salvo que se haya invalidado mientras se cargaba
+func (l *Loader) fetch(ctx context.Context, key string, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	l.mu.Lock()
+	l.fetching[key] = false
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.fetching, key)
+		l.mu.Unlock()
+	}()
+
+	value, err := fetch(ctx)
+	if err != nil {
+		if l.negativeTTL > 0 && errors.Is(err, l.notFound) {
+			l.store(ctx, key, negativeMarker, l.negativeTTL)
+		}
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	l.store(ctx, key, encoded, l.ttl)
+	return encoded, nil
+}
+
+// store guarda el valor cargado si la clave no se invalidó durante la carga
+func (l *Loader) store(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	l.mu.Lock()
+	stale := l.fetching[key]
+	l.mu.Unlock()
+	if stale {
+		logger.FromContext(ctx).WithField("key", key).Debug("Cache key invalidated while loading, skipping write")
+		return
+	}
+	if err := l.cache.Set(ctx, key, value, ttl); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("key", key).Warn("Cache write failed")
+	}
+}
+
+// Invalidate elimina las claves indicadas. Las cargas en curso de esas claves no guardan su valor
+func (l *Loader) Invalidate(ctx context.Context, keys ...string) {
+	l.mu.Lock()
+	for _, key := range keys {
+		if _, ok := l.fetching[key]; ok {
+			l.fetching[key] = true
+		}
+	}
+	l.mu.Unlock()
+
+	if err := l.cache.Delete(ctx, keys...); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("keys", keys).Error("Cache invalidation failed")
+	}
+}
+
+func isNegative(value []byte) bool {
+	return len(value) == 1 && value[0] == negativeMarker[0]
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"it-user-service/internal/logger"
+)
+
+// invalidationChannel es el canal donde las réplicas publican las claves invalidadas
+const invalidationChannel = "cache:invalidate"
+
+// Layered combina un LRU local con Redis compartido. Las lecturas van primero al LRU;
+// las invalidaciones se publican por Redis para que las demás réplicas descarten su copia local
+type Layered struct {
+	local    *LRU
+	client   *redis.Client
+	prefix   string
+	localTTL time.Duration
+}
+
+// NewLayered crea el caché de dos niveles. localTTL acota la vida de las copias locales
+// por si se pierde algún mensaje de invalidación
+func NewLayered(local *LRU, client *redis.Client, prefix string, localTTL time.Duration) *Layered {
+	return &Layered{
+		local:    local,
+		client:   client,
+		prefix:   prefix,
+		localTTL: localTTL,
+	}
+}
+
+// Get busca en el LRU y luego en Redis, poblando el LRU
+func (c *Layered) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if value, ok, _ := c.local.Get(ctx, key); ok {
+		return value, true, nil
+	}
+
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	ttl := c.localTTL
+	if remaining, err := c.client.PTTL(ctx, c.prefix+key).Result(); err == nil && remaining > 0 && remaining < ttl {
+		ttl = remaining
+	}
+	c.local.Set(ctx, key, value, ttl)
+	return value, true, nil
+}
+
+// Set guarda en Redis y en el LRU
+func (c *Layered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	localTTL := ttl
+	if localTTL > c.localTTL {
+		localTTL = c.localTTL
+	}
+	c.local.Set(ctx, key, value, localTTL)
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+// Delete elimina las claves en Redis y en el LRU, y avisa a las demás réplicas
+func (c *Layered) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	c.local.Delete(ctx, keys...)
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	if err := c.client.Del(ctx, prefixed...).Err(); err != nil {
+		return err
+	}
+	return c.client.Publish(ctx, c.prefix+invalidationChannel, strings.Join(keys, "\n")).Err()
+}
+
+// StartInvalidationListener aplica al LRU local las invalidaciones publicadas por otras
+// réplicas hasta que ctx se cancele
+func (c *Layered) StartInvalidationListener(ctx context.Context) {
+	pubsub := c.client.Subscribe(ctx, c.prefix+invalidationChannel)
+	go func() {
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					logger.GetLogger().Warn("Cache invalidation channel closed")
+					return
+				}
+				c.local.Delete(ctx, strings.Split(message.Payload, "\n")...)
+			}
+		}
+	}()
+}
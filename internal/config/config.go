@@ -1,52 +1,281 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"time"
 )
 
+// Config es la configuración tipada del servicio. Cada campo declara su clave YAML,
+// su variable de entorno, su valor por defecto y si es un secreto (se oculta al
+// imprimir). Ver Load para el orden de precedencia de las fuentes. Los secretos y las
+// llaves de firma aceptan referencias (vault://mount/path#key, file://, env://) que se
+// resuelven al iniciar el servidor
 type Config struct {
-	DBHost            string
-	DBPort            string
-	DBUser            string
-	DBPassword        string
-	DBName            string
-	Port              string
-	FirebaseProjectID string
-	LogLevel          string
-	Environment       string
-	RateLimitRPS      int
-	RateLimitBurst    int
-}
-
-func LoadConfig() Config {
-	return Config{
-		DBHost:            getEnv("DB_HOST", "localhost"),
-		DBPort:            getEnv("DB_PORT", "5432"),
-		DBUser:            getEnv("DB_USER", "postgres"),
-		DBPassword:        getEnv("DB_PASSWORD", "postgres"),
-		DBName:            getEnv("DB_NAME", "itapp"),
-		Port:              getEnv("PORT", "8081"),
-		FirebaseProjectID: getEnv("FIREBASE_PROJECT_ID", ""),
-		LogLevel:          getEnv("LOG_LEVEL", "info"),
-		Environment:       getEnv("ENVIRONMENT", "development"),
-		RateLimitRPS:      getEnvAsInt("RATE_LIMIT_RPS", 100),
-		RateLimitBurst:    getEnvAsInt("RATE_LIMIT_BURST", 200),
-	}
+	Environment string `yaml:"environment" env:"ENVIRONMENT" default:"development"`
+	LogLevel    string `yaml:"log_level" env:"LOG_LEVEL" default:"info"`
+	// Cómo se ocultan en los logs los campos con datos personales: mask, hash, drop o none
+	LogRedaction      string   `yaml:"log_redaction" env:"LOG_REDACTION" default:"mask"`
+	LogRedactedFields []string `yaml:"log_redacted_fields" env:"LOG_REDACTED_FIELDS" default:"email,ip,login_ip,phone"`
+
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	CORS        CORSConfig        `yaml:"cors"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Mail        MailConfig        `yaml:"mail"`
+	EmailChange EmailChangeConfig `yaml:"email_change"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Cache       CacheConfig       `yaml:"cache"`
+	Features    FeaturesConfig    `yaml:"features"`
+	Secrets     SecretsConfig     `yaml:"secrets"`
+	Health      HealthConfig      `yaml:"health"`
+
+	// Notificaciones a los usuarios por eventos de dominio
+	Notifications NotificationsConfig `yaml:"notifications"`
+	// Vistas de los perfiles públicos
+	ProfileViews ProfileViewsConfig `yaml:"profile_views"`
+
+	// Eventos de login conservados por usuario (0 = sin límite)
+	LoginHistoryLimit int             `yaml:"login_history_limit" env:"LOGIN_HISTORY_LIMIT" default:"100"`
+	LoginRisk         LoginRiskConfig `yaml:"login_risk"`
+	// Cada cuánto se reactivan los usuarios cuya suspensión temporal venció
+	SuspensionExpiryInterval time.Duration `yaml:"suspension_expiry_interval" env:"SUSPENSION_EXPIRY_INTERVAL" default:"1m"`
 }
 
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
+// ServerConfig configura el servidor HTTP
+type ServerConfig struct {
+	Port              string        `yaml:"port" env:"PORT" default:"8081"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"15s"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"5s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"60s"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" default:"65536"`
+	MaxBodyBytes      int           `yaml:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES" default:"1048576"`
+
+	// Valida los requests de la API contra el documento OpenAPI antes de llegar a los handlers
+	ValidateRequests bool `yaml:"validate_requests" env:"OPENAPI_VALIDATE_REQUESTS" default:"false"`
+
+	// Al recibir SIGTERM el servicio deja de estar ready, espera ShutdownDelay para que
+	// el balanceador lo saque de rotación y drena las conexiones durante ShutdownTimeout
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY" default:"0s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"8s"`
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value, exists := os.LookupEnv(key); exists {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
+// DatabaseConfig configura la conexión y el pool de PostgreSQL
+type DatabaseConfig struct {
+	Host            string        `yaml:"host" env:"DB_HOST" default:"localhost"`
+	Port            string        `yaml:"port" env:"DB_PORT" default:"5432"`
+	User            string        `yaml:"user" env:"DB_USER" default:"postgres"`
+	Password        string        `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name            string        `yaml:"name" env:"DB_NAME" default:"itapp"`
+	SSLMode         string        `yaml:"ssl_mode" env:"DB_SSLMODE" default:"disable"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"100"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"10"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" default:"5m"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT" default:"10s"`
+
+	// Deadline de las consultas de cada request (0 = sin deadline)
+	QueryTimeout time.Duration `yaml:"query_timeout" env:"DB_QUERY_TIMEOUT" default:"5s"`
+	// Overrides por ruta con el formato "METHOD /path=duración,..."
+	RouteQueryTimeouts string `yaml:"route_query_timeouts" env:"DB_ROUTE_QUERY_TIMEOUTS" default:"GET /api/v1/users/search=2s"`
+	// Las consultas que tardan más se loguean con su SQL y request id (0 = no se loguean)
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD" default:"200ms"`
+}
+
+// AuthConfig configura la autenticación de requests y la emisión de tokens
+type AuthConfig struct {
+	// Required rechaza requests sin credenciales (los tokens presentes siempre se validan)
+	Required          bool   `yaml:"required" env:"AUTH_REQUIRED" default:"false"`
+	FirebaseProjectID string `yaml:"firebase_project_id" env:"FIREBASE_PROJECT_ID"`
+
+	JWTSecret       string        `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	JWTIssuer       string        `yaml:"jwt_issuer" env:"JWT_ISSUER" default:"it-user-service"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL_SECONDS" unit:"s" default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL_HOURS" unit:"h" default:"720h"`
+
+	// Frecuencia con la que se sincronizan las sesiones revocadas por otras réplicas
+	RevocationSyncInterval time.Duration `yaml:"revocation_sync_interval" env:"SESSION_REVOCATION_SYNC_SECONDS" unit:"s" default:"30s"`
+	// memory (cada réplica aplica las revocaciones de otras al sincronizar) o redis (inmediato)
+	RevocationStore string `yaml:"revocation_store" env:"SESSION_REVOCATION_STORE" default:"memory"`
+	// Proxies (IPs o CIDR) cuyo X-Forwarded-For se considera para obtener la IP del cliente
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+
+	SigningKeys SigningKeysConfig `yaml:"signing_keys"`
+}
+
+// SigningKeysConfig configura la firma asimétrica de tokens (RS256/ES256). Las llaves
+// pueden ser rutas a archivos PEM o referencias a secretos. Sin ActiveKeyFile ni
+// Algorithm se firma con JWTSecret (HS256)
+type SigningKeysConfig struct {
+	Algorithm        string        `yaml:"algorithm" env:"JWT_SIGNING_ALGORITHM"`
+	ActiveKeyFile    string        `yaml:"active_key_file" env:"JWT_SIGNING_KEY_FILE"`
+	NextKeyFile      string        `yaml:"next_key_file" env:"JWT_NEXT_SIGNING_KEY_FILE"`
+	RetiredKeyFiles  []string      `yaml:"retired_key_files" env:"JWT_RETIRED_SIGNING_KEY_FILES"`
+	ReloadInterval   time.Duration `yaml:"reload_interval" env:"JWT_SIGNING_KEYS_RELOAD_SECONDS" unit:"s" default:"5m"`
+	RotationInterval time.Duration `yaml:"rotation_interval" env:"JWT_SIGNING_KEY_ROTATION_HOURS" unit:"h" default:"0s"`
+	RotationOverlap  time.Duration `yaml:"rotation_overlap" env:"JWT_SIGNING_KEY_ROTATION_OVERLAP_HOURS" unit:"h" default:"1h"`
+	RetiredKeyTTL    time.Duration `yaml:"retired_key_ttl" env:"JWT_RETIRED_KEY_TTL_HOURS" unit:"h" default:"48h"`
+}
+
+// Enabled indica si los tokens se firman con llaves asimétricas
+func (c SigningKeysConfig) Enabled() bool {
+	return c.ActiveKeyFile != "" || c.Algorithm != ""
+}
+
+// CORSConfig configura los orígenes y headers permitidos para los navegadores
+type CORSConfig struct {
+	// Orígenes exactos, con comodín de subdominio (https://*.example.com) o de puerto
+	// (http://localhost:*). Vacío usa los orígenes por defecto del entorno (ver Origins)
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS" default:"GET,POST,PUT,PATCH,DELETE,OPTIONS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" default:"Content-Type,Authorization,X-Request-ID,If-Match,If-None-Match,Idempotency-Key"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS" default:"X-Request-ID,ETag,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After,Idempotent-Replayed"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" default:"false"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE" unit:"s" default:"10m"`
+}
+
+// developmentOrigins son los orígenes permitidos en development y test si no se configuran
+var developmentOrigins = []string{"http://localhost:*", "http://127.0.0.1:*"}
+
+// Origins retorna los orígenes permitidos en environment. Sin AllowedOrigins, development
+// y test aceptan localhost en cualquier puerto; staging y production no aceptan ninguno
+func (c CORSConfig) Origins(environment string) []string {
+	if len(c.AllowedOrigins) > 0 {
+		return c.AllowedOrigins
 	}
-	return defaultValue
-}
\ No newline at end of file
+	if environment == "development" || environment == "test" {
+		return developmentOrigins
+	}
+	return nil
+}
+
+// RateLimitConfig configura el rate limiting por usuario, API key o IP
+type RateLimitConfig struct {
+	// RPS = 0 deshabilita el rate limiting
+	RPS   float64 `yaml:"rps" env:"RATE_LIMIT_RPS" default:"100"`
+	Burst int     `yaml:"burst" env:"RATE_LIMIT_BURST" default:"200"`
+	// Overrides por ruta con el formato "METHOD /path=rps:burst,..."
+	Routes string `yaml:"routes" env:"RATE_LIMIT_ROUTES" default:"GET /api/v1/users/search=5:10,POST /api/v1/users/create=2:5"`
+	// Store: memory (una réplica) o redis (compartido entre réplicas)
+	Store string `yaml:"store" env:"RATE_LIMIT_STORE" default:"memory"`
+}
+
+// IdempotencyConfig configura el header Idempotency-Key de los POST
+type IdempotencyConfig struct {
+	Enabled bool `yaml:"enabled" env:"IDEMPOTENCY_ENABLED" default:"true"`
+	// Tiempo durante el que se guarda la respuesta de cada key
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" default:"24h"`
+	// Cuánto espera un request duplicado a que termine el original antes de responder 409
+	LockTimeout time.Duration `yaml:"lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" default:"10s"`
+	// Store: memory (una réplica) o redis (compartido entre réplicas)
+	Store string `yaml:"store" env:"IDEMPOTENCY_STORE" default:"memory"`
+}
+
+// MailConfig configura el envío de emails
+type MailConfig struct {
+	// Backend: log (escribe los emails en el log, para desarrollo y tests) o smtp
+	Backend      string `yaml:"backend" env:"MAIL_BACKEND" default:"log"`
+	From         string `yaml:"from" env:"MAIL_FROM" default:"no-reply@localhost"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST" default:"localhost"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT" default:"1025"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// EmailChangeConfig configura los tokens de verificación del cambio de email
+type EmailChangeConfig struct {
+	// Llave con la que se firman los tokens (vacía usa auth.jwt_secret; sin ninguna de las
+	// dos el cambio de email queda deshabilitado)
+	TokenSecret string        `yaml:"token_secret" env:"EMAIL_TOKEN_SECRET" secret:"true"`
+	TokenTTL    time.Duration `yaml:"token_ttl" env:"EMAIL_TOKEN_TTL" default:"24h"`
+	// Página que confirma el cambio; el token se agrega como ?token=
+	ConfirmURL string `yaml:"confirm_url" env:"EMAIL_CONFIRM_URL" default:"http://localhost:3000/confirm-email"`
+}
+
+// NotificationsConfig configura las notificaciones a los usuarios. El canal email usa la
+// configuración de mail; el canal webhook se habilita con WebhookURL
+type NotificationsConfig struct {
+	Enabled    bool   `yaml:"enabled" env:"NOTIFICATIONS_ENABLED" default:"true"`
+	WebhookURL string `yaml:"webhook_url" env:"NOTIFICATIONS_WEBHOOK_URL"`
+	// Llave con la que se firma el cuerpo de los webhooks (header X-Notification-Signature)
+	WebhookSecret  string        `yaml:"webhook_secret" env:"NOTIFICATIONS_WEBHOOK_SECRET" secret:"true"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" env:"NOTIFICATIONS_WEBHOOK_TIMEOUT" default:"5s"`
+	// Intentos por entrega antes de marcarla como fallida
+	MaxAttempts int `yaml:"max_attempts" env:"NOTIFICATIONS_MAX_ATTEMPTS" default:"5"`
+	// Espera antes del primer reintento; se duplica en cada intento (hasta 1h)
+	RetryBackoff time.Duration `yaml:"retry_backoff" env:"NOTIFICATIONS_RETRY_BACKOFF" default:"1m"`
+	// Cada cuánto se buscan entregas pendientes de reintento
+	RetryInterval time.Duration `yaml:"retry_interval" env:"NOTIFICATIONS_RETRY_INTERVAL" default:"30s"`
+}
+
+// ProfileViewsConfig configura el contador de vistas de los perfiles públicos
+type ProfileViewsConfig struct {
+	// Ventana en la que las vistas repetidas de un mismo visitante cuentan una sola vez
+	Window time.Duration `yaml:"window" env:"PROFILE_VIEWS_WINDOW" default:"30m"`
+	// Store: memory (una réplica) o redis (compartido entre réplicas)
+	Store string `yaml:"store" env:"PROFILE_VIEWS_STORE" default:"memory"`
+}
+
+// RedisConfig configura el Redis compartido (caché, rate limiting e idempotencia)
+type RedisConfig struct {
+	URL string `yaml:"url" env:"REDIS_URL" secret:"true"`
+}
+
+// CacheConfig configura el caché de usuarios y roles
+type CacheConfig struct {
+	Enabled bool `yaml:"enabled" env:"CACHE_ENABLED" default:"true"`
+	// Backend: memory (LRU por réplica) o redis (LRU + Redis compartido)
+	Backend     string        `yaml:"backend" env:"CACHE_BACKEND" default:"memory"`
+	TTL         time.Duration `yaml:"ttl" env:"CACHE_TTL_SECONDS" unit:"s" default:"5m"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env:"CACHE_NEGATIVE_TTL_SECONDS" unit:"s" default:"30s"`
+	LocalTTL    time.Duration `yaml:"local_ttl" env:"CACHE_LOCAL_TTL_SECONDS" unit:"s" default:"30s"`
+	MaxEntries  int           `yaml:"max_entries" env:"CACHE_MAX_ENTRIES" default:"10000"`
+}
+
+// FeaturesConfig habilita o deshabilita funcionalidades completas
+type FeaturesConfig struct {
+	// TokenExchange habilita POST /auth/token, /auth/refresh y /auth/revoke
+	TokenExchange bool `yaml:"token_exchange" env:"FEATURE_TOKEN_EXCHANGE" default:"true"`
+	// APIKeys habilita la autenticación con API keys de servicio
+	APIKeys bool `yaml:"api_keys" env:"FEATURE_API_KEYS" default:"true"`
+}
+
+// SecretsConfig configura los proveedores de secretos referenciados desde la configuración
+type SecretsConfig struct {
+	VaultAddress   string        `yaml:"vault_address" env:"VAULT_ADDR"`
+	VaultToken     string        `yaml:"vault_token" env:"VAULT_TOKEN" secret:"true"`
+	VaultNamespace string        `yaml:"vault_namespace" env:"VAULT_NAMESPACE"`
+	VaultTimeout   time.Duration `yaml:"vault_timeout" env:"VAULT_TIMEOUT" default:"5s"`
+	// Frecuencia con la que se vuelven a leer los secretos referenciados (0 = nunca)
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"SECRETS_REFRESH_INTERVAL" default:"5m"`
+}
+
+// HealthConfig configura los checks de los probes /health/live, /ready y /startup
+type HealthConfig struct {
+	// Tiempo máximo de cada check de dependencia
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	// Retraso máximo de entrega de eventos antes de reportar el bus como degradado
+	MaxEventLag time.Duration `yaml:"max_event_lag" env:"HEALTH_MAX_EVENT_LAG" default:"30s"`
+}
+
+// LoginRiskConfig contiene los umbrales de la detección de logins sospechosos
+type LoginRiskConfig struct {
+	Enabled     bool   `yaml:"enabled" env:"LOGIN_RISK_ENABLED" default:"true"`
+	GeoIPDBPath string `yaml:"geoip_db_path" env:"GEOIP_DB_PATH"`
+
+	// Puntaje a partir del cual un login se marca como sospechoso
+	FlagThreshold int `yaml:"flag_threshold" env:"LOGIN_RISK_FLAG_THRESHOLD" default:"50"`
+	// Puntaje a partir del cual el usuario pasa a estado pending (0 = deshabilitado)
+	AutoPendingThreshold int `yaml:"auto_pending_threshold" env:"LOGIN_RISK_AUTO_PENDING_THRESHOLD" default:"0"`
+	// Emitir un evento de seguridad por cada login sospechoso
+	EmitEvents bool `yaml:"emit_events" env:"LOGIN_RISK_EMIT_EVENTS" default:"true"`
+
+	NewDeviceScore        int     `yaml:"new_device_score" env:"LOGIN_RISK_NEW_DEVICE_SCORE" default:"30"`
+	NewCountryScore       int     `yaml:"new_country_score" env:"LOGIN_RISK_NEW_COUNTRY_SCORE" default:"40"`
+	ImpossibleTravelScore int     `yaml:"impossible_travel_score" env:"LOGIN_RISK_IMPOSSIBLE_TRAVEL_SCORE" default:"70"`
+	MaxTravelSpeedKmh     float64 `yaml:"max_travel_speed_kmh" env:"LOGIN_RISK_MAX_TRAVEL_SPEED_KMH" default:"900"`
+	BurstScore            int     `yaml:"burst_score" env:"LOGIN_RISK_BURST_SCORE" default:"30"`
+	BurstCount            int     `yaml:"burst_count" env:"LOGIN_RISK_BURST_COUNT" default:"5"`
+	BurstWindowSeconds    int     `yaml:"burst_window_seconds" env:"LOGIN_RISK_BURST_WINDOW_SECONDS" default:"300"`
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseForTest(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	return Parse(flag.NewFlagSet("test", flag.ContinueOnError), args)
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestParse_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  port: "9000"
+rate_limit:
+  rps: 10
+  burst: 20
+cache:
+  ttl: 2m
+`)
+	t.Setenv("RATE_LIMIT_RPS", "5")
+	t.Setenv("RATE_LIMIT_BURST", "15")
+
+	cfg, err := parseForTest(t, "--config", path, "--rate_limit.burst", "30")
+	require.NoError(t, err)
+
+	assert.Equal(t, "9000", cfg.Server.Port)        // YAML sobre el default
+	assert.Equal(t, 5.0, cfg.RateLimit.RPS)         // entorno sobre YAML
+	assert.Equal(t, 30, cfg.RateLimit.Burst)        // flag sobre entorno
+	assert.Equal(t, 2*time.Minute, cfg.Cache.TTL)   // YAML
+	assert.Equal(t, "localhost", cfg.Database.Host) // default
+}
+
+func TestParse_LegacyUnitsAndSecretFiles(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_TTL_SECONDS", "900")
+	t.Setenv("REFRESH_TOKEN_TTL_HOURS", "24")
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "s3cret\n"))
+
+	cfg, err := parseForTest(t)
+	require.NoError(t, err)
+
+	assert.Equal(t, 15*time.Minute, cfg.Auth.AccessTokenTTL)
+	assert.Equal(t, 24*time.Hour, cfg.Auth.RefreshTokenTTL)
+	assert.Equal(t, "s3cret", cfg.Database.Password)
+}
+
+func TestParse_ReportsAllInvalidValues(t *testing.T) {
+	t.Setenv("RATE_LIMIT_RPS", "abc")
+	t.Setenv("CACHE_ENABLED", "maybe")
+
+	_, err := parseForTest(t)
+
+	var cfgErr *Error
+	require.True(t, errors.As(err, &cfgErr))
+	assert.Len(t, cfgErr.Problems, 2)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_RPS")
+	assert.Contains(t, err.Error(), "CACHE_ENABLED")
+}
+
+func TestParse_RejectsUnknownYAMLKeys(t *testing.T) {
+	path := writeFile(t, "config.yaml", "databse:\n  host: db\n")
+
+	_, err := parseForTest(t, "--config", path)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	cfg, err := parseForTest(t)
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.RateLimit.Store = "redis"
+	cfg.Database.MaxIdleConns = cfg.Database.MaxOpenConns + 1
+	cfg.CORS.AllowedOrigins = []string{"*"}
+	cfg.CORS.AllowCredentials = true
+
+	err = cfg.Validate()
+	var cfgErr *Error
+	require.True(t, errors.As(err, &cfgErr))
+	assert.Len(t, cfgErr.Problems, 3)
+	assert.Contains(t, err.Error(), "redis.url (REDIS_URL)")
+}
+
+func TestCORSOrigins_EnvironmentProfiles(t *testing.T) {
+	tests := []struct {
+		environment string
+		origins     []string
+		expected    []string
+		valid       bool
+	}{
+		{"development", nil, []string{"http://localhost:*", "http://127.0.0.1:*"}, true},
+		{"test", nil, []string{"http://localhost:*", "http://127.0.0.1:*"}, true},
+		{"staging", nil, nil, true},
+		{"staging", []string{"*"}, []string{"*"}, false},
+		{"production", nil, nil, true},
+		{"production", []string{"https://*.example.com"}, []string{"https://*.example.com"}, true},
+		{"production", []string{"http://app.example.com"}, []string{"http://app.example.com"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.environment, func(t *testing.T) {
+			cfg, err := parseForTest(t)
+			require.NoError(t, err)
+			cfg.Environment = tt.environment
+			cfg.Auth.Required = true
+			cfg.CORS.AllowedOrigins = tt.origins
+
+			assert.Equal(t, tt.expected, cfg.CORS.Origins(tt.environment))
+			assert.Equal(t, tt.valid, cfg.Validate() == nil)
+		})
+	}
+}
+
+func TestSigningKeys_RequireKeyFileOutsideDevelopment(t *testing.T) {
+	cfg, err := parseForTest(t, "--auth.signing_keys.algorithm", "ES256")
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Environment = "production"
+	cfg.Auth.Required = true
+	assert.ErrorContains(t, cfg.Validate(), "auth.signing_keys.active_key_file")
+
+	cfg.Auth.SigningKeys.ActiveKeyFile = "/run/secrets/jwt_signing_key.pem"
+	assert.NoError(t, cfg.Validate())
+
+	// Rotar llaves de la fuente requiere publicar la siguiente
+	cfg.Auth.SigningKeys.RotationInterval = 24 * time.Hour
+	assert.ErrorContains(t, cfg.Validate(), "auth.signing_keys.next_key_file")
+
+	cfg.Auth.SigningKeys.NextKeyFile = "/run/secrets/jwt_next_signing_key.pem"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestAuthRequired_OutsideDevelopment(t *testing.T) {
+	cfg, err := parseForTest(t)
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Environment = "staging"
+	assert.ErrorContains(t, cfg.Validate(), "auth.required (AUTH_REQUIRED)")
+
+	cfg.Auth.Required = true
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestWriteYAML_RedactsSecrets(t *testing.T) {
+	t.Setenv("JWT_SECRET", "super-secret")
+
+	cfg, err := parseForTest(t)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.WriteYAML(&out, true))
+	assert.NotContains(t, out.String(), "super-secret")
+	assert.Contains(t, out.String(), "jwt_secret: <redacted>")
+
+	// La salida se puede volver a cargar con --config
+	path := writeFile(t, "printed.yaml", out.String())
+	reloaded, err := parseForTest(t, "--config", path)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Auth.AccessTokenTTL, reloaded.Auth.AccessTokenTTL)
+}
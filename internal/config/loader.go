@@ -0,0 +1,263 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv indica el archivo YAML de configuración si no se pasa --config
+const ConfigFileEnv = "CONFIG_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field es un campo hoja de Config con los metadatos de sus tags
+type field struct {
+	path   string // clave YAML completa, por ejemplo database.max_open_conns
+	env    string
+	def    string
+	hasDef bool
+	unit   string
+	secret bool
+	value  reflect.Value
+}
+
+// source describe de dónde se lee el campo, para los mensajes de error
+func (f field) source() string {
+	if f.env == "" {
+		return f.path
+	}
+	return fmt.Sprintf("%s (%s)", f.path, f.env)
+}
+
+// Error reúne todos los problemas encontrados al cargar o validar la configuración
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load carga y valida la configuración a partir de los argumentos de línea de comandos
+func Load(args []string) (*Config, error) {
+	cfg, err := Parse(flag.NewFlagSet("it-user-service", flag.ContinueOnError), args)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Parse carga la configuración sin validarla. Registra en fs el flag --config y un flag
+// por campo (--database.host, --rate_limit.rps, ...). Precedencia, de menor a mayor:
+// valores por defecto, archivo YAML (--config o CONFIG_FILE), variables de entorno
+// (los secretos también desde VAR_FILE) y flags
+func Parse(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := &Config{}
+	fields := collectFields(reflect.ValueOf(cfg).Elem(), "")
+
+	configFile := fs.String("config", os.Getenv(ConfigFileEnv), "YAML configuration file")
+	overrides := make(map[string]string)
+	for _, f := range fields {
+		path := f.path
+		usage := "overrides " + path
+		if f.env != "" {
+			usage += " (env " + f.env + ")"
+		}
+		fs.Func(path, usage, func(value string) error {
+			overrides[path] = value
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields {
+		if !f.hasDef {
+			continue
+		}
+		if err := setValue(f.value, f.def, f.unit); err != nil {
+			return nil, fmt.Errorf("invalid default for %s: %w", f.path, err)
+		}
+	}
+
+	if *configFile != "" {
+		if err := loadYAML(cfg, *configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var problems []string
+	for _, f := range fields {
+		raw, ok, err := lookupEnv(f)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, raw, f.unit); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", f.env, err))
+		}
+	}
+
+	for _, f := range fields {
+		raw, ok := overrides[f.path]
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, raw, f.unit); err != nil {
+			problems = append(problems, fmt.Sprintf("--%s: %v", f.path, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &Error{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// loadYAML aplica el archivo sobre cfg. Las claves desconocidas son un error
+func loadYAML(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// lookupEnv lee la variable del campo. Los secretos también se pueden leer desde el
+// archivo indicado en VAR_FILE (por ejemplo, secretos montados en /run/secrets)
+func lookupEnv(f field) (string, bool, error) {
+	if f.env == "" {
+		return "", false, nil
+	}
+	if value, ok := os.LookupEnv(f.env); ok {
+		return value, true, nil
+	}
+	if !f.secret {
+		return "", false, nil
+	}
+	path, ok := os.LookupEnv(f.env + "_FILE")
+	if !ok || path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %v", f.env, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// collectFields recorre el struct y retorna sus campos hoja
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		name := strings.Split(structField.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct && value.Type() != durationType {
+			fields = append(fields, collectFields(value, path+".")...)
+			continue
+		}
+
+		def, hasDef := structField.Tag.Lookup("default")
+		fields = append(fields, field{
+			path:   path,
+			env:    structField.Tag.Get("env"),
+			def:    def,
+			hasDef: hasDef,
+			unit:   structField.Tag.Get("unit"),
+			secret: structField.Tag.Get("secret") == "true",
+			value:  value,
+		})
+	}
+	return fields
+}
+
+// setValue interpreta raw según el tipo del campo
+func setValue(v reflect.Value, raw, unit string) error {
+	raw = strings.TrimSpace(raw)
+
+	if v.Type() == durationType {
+		d, err := parseDuration(raw, unit)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		v.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
+
+// parseDuration acepta duraciones de Go ("90s", "15m") o, si el campo tiene unidad,
+// un número en esa unidad (compatibilidad con variables como ACCESS_TOKEN_TTL_SECONDS)
+func parseDuration(raw, unit string) (time.Duration, error) {
+	if n, err := strconv.ParseFloat(raw, 64); err == nil && unit != "" {
+		multiplier := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}[unit]
+		return time.Duration(n * float64(multiplier)), nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", raw)
+	}
+	return d, nil
+}
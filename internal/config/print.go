@@ -0,0 +1,77 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue reemplaza los secretos al imprimir la configuración
+const redactedValue = "<redacted>"
+
+// WriteYAML escribe la configuración efectiva en YAML, en el mismo formato que acepta
+// --config. Con redacted los secretos configurados se reemplazan por <redacted>
+func (c *Config) WriteYAML(w io.Writer, redacted bool) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlNode(reflect.ValueOf(c).Elem(), redacted)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func yamlNode(v reflect.Value, redacted bool) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		name := strings.Split(structField.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: name}
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct && value.Type() != durationType {
+			node.Content = append(node.Content, key, yamlNode(value, redacted))
+			continue
+		}
+
+		secret := structField.Tag.Get("secret") == "true"
+		node.Content = append(node.Content, key, scalarNode(value, redacted && secret))
+	}
+	return node
+}
+
+func scalarNode(v reflect.Value, redact bool) *yaml.Node {
+	if redact {
+		if v.IsZero() {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: ""}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: redactedValue}
+	}
+
+	if v.Type() == durationType {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.Interface().(interface{ String() string }).String()}
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < v.Len(); i++ {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.Index(i).String()})
+		}
+		return seq
+	case reflect.Bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v.Bool())}
+	case reflect.Int:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(v.Int(), 10)}
+	case reflect.Float64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: strconv.FormatFloat(v.Float(), 'f', -1, 64)}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.String()}
+	}
+}
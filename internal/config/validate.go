@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"it-user-service/internal/middleware"
+	"it-user-service/internal/ratelimit"
+	"it-user-service/internal/secrets"
+)
+
+// validator acumula los problemas de configuración con la clave y la variable afectadas
+type validator struct {
+	sources  map[string]string
+	problems []string
+}
+
+func (v *validator) check(ok bool, path, format string, args ...interface{}) {
+	if ok {
+		return
+	}
+	source, found := v.sources[path]
+	if !found {
+		source = path
+	}
+	v.problems = append(v.problems, source+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) oneOf(value, path string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.check(false, path, "must be one of %v, got %q", allowed, value)
+}
+
+// Validate verifica la configuración y retorna todos los problemas encontrados
+func (c *Config) Validate() error {
+	v := &validator{sources: make(map[string]string)}
+	usesVault := false
+	for _, f := range collectFields(reflect.ValueOf(c).Elem(), "") {
+		v.sources[f.path] = f.source()
+		if f.value.Kind() == reflect.String && strings.HasPrefix(f.value.String(), secrets.SchemeVault+"://") {
+			usesVault = true
+		}
+	}
+
+	v.oneOf(c.Environment, "environment", "development", "test", "staging", "production")
+	v.oneOf(c.LogLevel, "log_level", "debug", "info", "warn", "error")
+	v.oneOf(c.LogRedaction, "log_redaction", "mask", "hash", "drop", "none")
+
+	port, err := strconv.Atoi(c.Server.Port)
+	v.check(err == nil && port > 0 && port < 65536, "server.port", "must be a TCP port, got %q", c.Server.Port)
+	v.check(c.Server.ReadTimeout > 0, "server.read_timeout", "must be positive")
+	v.check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout", "must be positive")
+	v.check(c.Server.WriteTimeout > 0, "server.write_timeout", "must be positive")
+	v.check(c.Server.IdleTimeout > 0, "server.idle_timeout", "must be positive")
+	v.check(c.Server.MaxHeaderBytes >= 4096, "server.max_header_bytes", "must be at least 4096")
+	v.check(c.Server.MaxBodyBytes >= 0, "server.max_body_bytes", "must not be negative")
+	v.check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay", "must not be negative")
+	v.check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive")
+
+	db := c.Database
+	v.check(db.Host != "", "database.host", "is required")
+	v.check(db.User != "", "database.user", "is required")
+	v.check(db.Name != "", "database.name", "is required")
+	dbPort, err := strconv.Atoi(db.Port)
+	v.check(err == nil && dbPort > 0 && dbPort < 65536, "database.port", "must be a TCP port, got %q", db.Port)
+	v.oneOf(db.SSLMode, "database.ssl_mode", "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	v.check(db.MaxOpenConns > 0, "database.max_open_conns", "must be positive")
+	v.check(db.MaxIdleConns >= 0 && db.MaxIdleConns <= db.MaxOpenConns, "database.max_idle_conns", "must be between 0 and database.max_open_conns (%d)", db.MaxOpenConns)
+	v.check(db.ConnMaxLifetime >= 0, "database.conn_max_lifetime", "must not be negative")
+	v.check(db.ConnMaxIdleTime >= 0, "database.conn_max_idle_time", "must not be negative")
+	v.check(db.ConnectTimeout > 0, "database.connect_timeout", "must be positive")
+	v.check(db.QueryTimeout >= 0, "database.query_timeout", "must not be negative")
+	v.check(db.SlowQueryThreshold >= 0, "database.slow_query_threshold", "must not be negative")
+	if _, err := middleware.ParseRouteTimeouts(db.RouteQueryTimeouts); err != nil {
+		v.check(false, "database.route_query_timeouts", "%v", err)
+	}
+
+	a := c.Auth
+	v.check(a.JWTIssuer != "", "auth.jwt_issuer", "is required")
+	v.check(a.AccessTokenTTL > 0, "auth.access_token_ttl", "must be positive")
+	v.check(a.RefreshTokenTTL > a.AccessTokenTTL, "auth.refresh_token_ttl", "must be longer than auth.access_token_ttl")
+	v.check(a.RevocationSyncInterval > 0, "auth.revocation_sync_interval", "must be positive")
+	v.oneOf(a.RevocationStore, "auth.revocation_store", "memory", "redis")
+	v.check(a.RevocationStore != "redis" || c.Redis.URL != "", "redis.url", "is required when auth.revocation_store is redis")
+	for _, proxy := range a.TrustedProxies {
+		v.check(isIPOrCIDR(proxy), "auth.trusted_proxies", "invalid IP or CIDR %q", proxy)
+	}
+	keys := a.SigningKeys
+	v.oneOf(keys.Algorithm, "auth.signing_keys.algorithm", "", "RS256", "ES256")
+	v.check(keys.NextKeyFile == "" || keys.ActiveKeyFile != "", "auth.signing_keys.next_key_file", "requires auth.signing_keys.active_key_file")
+	if c.Environment == "staging" || c.Environment == "production" {
+		// Sin credenciales los requests no tienen principal y se rechazan en cada handler
+		v.check(a.Required, "auth.required", "must be true in %s", c.Environment)
+		// Las llaves generadas en memoria no se comparten entre réplicas
+		v.check(!keys.Enabled() || keys.ActiveKeyFile != "", "auth.signing_keys.active_key_file", "is required in %s when auth.signing_keys.algorithm is set", c.Environment)
+	}
+	v.check(keys.RotationInterval >= 0, "auth.signing_keys.rotation_interval", "must not be negative")
+	v.check(keys.RotationOverlap >= 0 && (keys.RotationInterval == 0 || keys.RotationOverlap < keys.RotationInterval), "auth.signing_keys.rotation_overlap", "must not be negative and must be shorter than auth.signing_keys.rotation_interval")
+	v.check(keys.RotationInterval == 0 || keys.ActiveKeyFile == "" || keys.NextKeyFile != "", "auth.signing_keys.next_key_file", "is required to rotate keys loaded from auth.signing_keys.active_key_file")
+	v.check(keys.RotationInterval == 0 || keys.RetiredKeyTTL >= a.AccessTokenTTL, "auth.signing_keys.retired_key_ttl", "must be at least auth.access_token_ttl so rotated tokens stay valid")
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if err := middleware.ValidateOrigin(origin); err != nil {
+			v.check(false, "cors.allowed_origins", "%v", err)
+		}
+		v.check(origin != "*" || !c.CORS.AllowCredentials, "cors.allow_credentials", "cannot be used with the * origin")
+		if c.Environment == "staging" || c.Environment == "production" {
+			v.check(origin != "*", "cors.allowed_origins", "cannot be * in %s", c.Environment)
+		}
+		if c.Environment == "production" {
+			v.check(!strings.HasPrefix(origin, "http://"), "cors.allowed_origins", "must use https in production, got %q", origin)
+		}
+	}
+	v.check(c.CORS.MaxAge >= 0, "cors.max_age", "must not be negative")
+
+	rl := c.RateLimit
+	v.check(rl.RPS >= 0, "rate_limit.rps", "must not be negative")
+	v.check(rl.RPS == 0 || rl.Burst >= 1, "rate_limit.burst", "must be at least 1 when rate_limit.rps is set")
+	v.oneOf(rl.Store, "rate_limit.store", "memory", "redis")
+	v.check(rl.Store != "redis" || c.Redis.URL != "", "redis.url", "is required when rate_limit.store is redis")
+	if _, err := ratelimit.ParseRouteLimits(rl.Routes); err != nil {
+		v.check(false, "rate_limit.routes", "%v", err)
+	}
+
+	if c.Idempotency.Enabled {
+		v.check(c.Idempotency.TTL > 0, "idempotency.ttl", "must be positive")
+		v.check(c.Idempotency.LockTimeout > 0, "idempotency.lock_timeout", "must be positive")
+		v.oneOf(c.Idempotency.Store, "idempotency.store", "memory", "redis")
+		v.check(c.Idempotency.Store != "redis" || c.Redis.URL != "", "redis.url", "is required when idempotency.store is redis")
+	}
+
+	v.oneOf(c.Mail.Backend, "mail.backend", "log", "smtp")
+	if c.Mail.Backend == "smtp" {
+		v.check(c.Mail.SMTPHost != "", "mail.smtp_host", "is required when mail.backend is smtp")
+		v.check(c.Mail.SMTPPort > 0 && c.Mail.SMTPPort <= 65535, "mail.smtp_port", "must be a valid port")
+	}
+	if _, err := netmail.ParseAddress(c.Mail.From); err != nil {
+		v.check(false, "mail.from", "must be an email address")
+	}
+	v.check(c.EmailChange.TokenTTL > 0, "email_change.token_ttl", "must be positive")
+	if u, err := url.Parse(c.EmailChange.ConfirmURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.check(false, "email_change.confirm_url", "must be an absolute http(s) URL")
+	}
+
+	if c.Notifications.Enabled {
+		if c.Notifications.WebhookURL != "" {
+			u, err := url.Parse(c.Notifications.WebhookURL)
+			v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "notifications.webhook_url", "must be an absolute http(s) URL")
+			v.check(c.Notifications.WebhookTimeout > 0, "notifications.webhook_timeout", "must be positive")
+		}
+		v.check(c.Notifications.MaxAttempts > 0, "notifications.max_attempts", "must be positive")
+		v.check(c.Notifications.RetryBackoff > 0, "notifications.retry_backoff", "must be positive")
+		v.check(c.Notifications.RetryInterval > 0, "notifications.retry_interval", "must be positive")
+	}
+
+	v.check(c.ProfileViews.Window > 0, "profile_views.window", "must be positive")
+	v.oneOf(c.ProfileViews.Store, "profile_views.store", "memory", "redis")
+	v.check(c.ProfileViews.Store != "redis" || c.Redis.URL != "", "redis.url", "is required when profile_views.store is redis")
+
+	if c.Redis.URL != "" && !secrets.IsReference(c.Redis.URL) {
+		u, err := url.Parse(c.Redis.URL)
+		v.check(err == nil && (u.Scheme == "redis" || u.Scheme == "rediss"), "redis.url", "must be a redis:// or rediss:// URL")
+	}
+
+	if c.Cache.Enabled {
+		v.oneOf(c.Cache.Backend, "cache.backend", "memory", "redis")
+		v.check(c.Cache.Backend != "redis" || c.Redis.URL != "", "redis.url", "is required when cache.backend is redis")
+		v.check(c.Cache.TTL > 0, "cache.ttl", "must be positive")
+		v.check(c.Cache.NegativeTTL >= 0, "cache.negative_ttl", "must not be negative")
+		v.check(c.Cache.LocalTTL > 0, "cache.local_ttl", "must be positive")
+		v.check(c.Cache.MaxEntries > 0, "cache.max_entries", "must be positive")
+	}
+
+	v.check(!usesVault || c.Secrets.VaultAddress != "", "secrets.vault_address", "is required to resolve vault:// references")
+	if c.Secrets.VaultAddress != "" {
+		u, err := url.Parse(c.Secrets.VaultAddress)
+		v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "secrets.vault_address", "must be an http(s) URL")
+		v.check(c.Secrets.VaultToken != "", "secrets.vault_token", "is required when secrets.vault_address is set")
+	}
+	v.check(c.Secrets.RefreshInterval >= 0, "secrets.refresh_interval", "must not be negative")
+
+	v.check(c.Health.CheckTimeout > 0, "health.check_timeout", "must be positive")
+	v.check(c.Health.MaxEventLag >= 0, "health.max_event_lag", "must not be negative")
+
+	v.check(c.LoginHistoryLimit >= 0, "login_history_limit", "must not be negative")
+	v.check(c.SuspensionExpiryInterval > 0, "suspension_expiry_interval", "must be positive")
+	risk := c.LoginRisk
+	v.check(risk.FlagThreshold >= 0 && risk.FlagThreshold <= 100, "login_risk.flag_threshold", "must be between 0 and 100")
+	v.check(risk.AutoPendingThreshold >= 0 && risk.AutoPendingThreshold <= 100, "login_risk.auto_pending_threshold", "must be between 0 and 100")
+	v.check(risk.MaxTravelSpeedKmh >= 0, "login_risk.max_travel_speed_kmh", "must not be negative")
+	v.check(risk.BurstCount >= 0, "login_risk.burst_count", "must not be negative")
+	v.check(risk.BurstWindowSeconds >= 0, "login_risk.burst_window_seconds", "must not be negative")
+
+	if len(v.problems) > 0 {
+		return &Error{Problems: v.problems}
+	}
+	return nil
+}
+
+func isIPOrCIDR(value string) bool {
+	if net.ParseIP(value) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
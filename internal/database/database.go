@@ -1,46 +1,68 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"os"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"it-user-service/internal/config"
 )
 
 var DB *gorm.DB
 
-// ConnectDB establece la conexión con la base de datos PostgreSQL
-func ConnectDB() {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
+// PasswordFunc retorna la contraseña vigente de la base de datos
+type PasswordFunc func() string
+
+// ConnectDB establece la conexión con la base de datos PostgreSQL. Si password no es
+// nil se consulta en cada conexión nueva, de modo que una contraseña rotada se usa sin
+// reiniciar; si es nil se usa cfg.Password
+func ConnectDB(cfg config.DatabaseConfig, password PasswordFunc) error {
+	dsn := fmt.Sprintf("host=%s user=%s dbname=%s port=%s sslmode=%s connect_timeout=%d",
+		cfg.Host,
+		cfg.User,
+		cfg.Name,
+		cfg.Port,
+		cfg.SSLMode,
+		int(cfg.ConnectTimeout.Seconds()),
 	)
+	if password == nil {
+		password = func() string { return cfg.Password }
+	}
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	
+	connConfig, err := pgx.ParseConfig(dsn)
 	if err != nil {
-		log.Fatalf("Error al conectar con la base de datos: %v", err)
+		return fmt.Errorf("invalid database configuration: %w", err)
 	}
+	sqlDB := stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(func(ctx context.Context, cc *pgx.ConnConfig) error {
+		cc.Password = password()
+		return nil
+	}))
 
-	// Configurar pool de conexiones
-	sqlDB, err := DB.DB()
+	DB, err = gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
 	if err != nil {
-		log.Fatalf("Error al obtener la instancia de base de datos: %v", err)
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	// Spans, métricas y log de consultas lentas para cada consulta
+	if err := DB.Use(NewInstrumentation(cfg.SlowQueryThreshold)); err != nil {
+		return fmt.Errorf("failed to instrument database: %w", err)
 	}
 
+	// Configurar pool de conexiones
 	// Configuraciones del pool
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	log.Println("Conexión a base de datos establecida exitosamente")
+	return nil
 }
 
 // GetDB retorna la instancia de la base de datos
 func GetDB() *gorm.DB {
 	return DB
-}
\ No newline at end of file
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"it-user-service/internal/logger"
+)
+
+const (
+	instrumentationName = "it-user-service/database"
+	instrumentationKey  = "instrumentation:query"
+	// maxStatementLength trunca el SQL que se adjunta a spans y logs
+	maxStatementLength = 2048
+)
+
+var (
+	dbQueryDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of database queries in seconds",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"operation", "table"},
+	)
+
+	dbQueryErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "Total number of failed database queries",
+		},
+		[]string{"operation", "table"},
+	)
+)
+
+// Instrumentation es un plugin de GORM que crea un span de OpenTelemetry por consulta,
+// registra su duración en Prometheus por operación y tabla, y loguea las consultas lentas
+type Instrumentation struct {
+	tracer trace.Tracer
+	// SlowQueryThreshold es la duración a partir de la cual se loguea la consulta (0 = nunca)
+	SlowQueryThreshold time.Duration
+}
+
+// NewInstrumentation crea el plugin con el umbral de consultas lentas indicado
+func NewInstrumentation(slowQueryThreshold time.Duration) *Instrumentation {
+	return &Instrumentation{
+		tracer:             otel.Tracer(instrumentationName),
+		SlowQueryThreshold: slowQueryThreshold,
+	}
+}
+
+// queryState guarda el span y el inicio de una consulta entre los callbacks before y after
+type queryState struct {
+	start     time.Time
+	span      trace.Span
+	parentCtx context.Context
+}
+
+// Name implementa gorm.Plugin
+func (i *Instrumentation) Name() string {
+	return "instrumentation"
+}
+
+// Initialize implementa gorm.Plugin registrando callbacks antes y después de cada operación
+func (i *Instrumentation) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+	for _, p := range processors {
+		if err := p.before("instrumentation:before_"+p.operation, i.before(p.operation)); err != nil {
+			return err
+		}
+		if err := p.after("instrumentation:after_"+p.operation, i.after(p.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Instrumentation) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parentCtx := db.Statement.Context
+		if parentCtx == nil {
+			parentCtx = context.Background()
+		}
+		ctx, span := i.tracer.Start(parentCtx, "db."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.operation", operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(instrumentationKey, &queryState{start: time.Now(), span: span, parentCtx: parentCtx})
+	}
+}
+
+func (i *Instrumentation) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(instrumentationKey)
+		if !ok {
+			return
+		}
+		state := value.(*queryState)
+		duration := time.Since(state.start)
+		db.Statement.Context = state.parentCtx
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		statement := SanitizeSQL(db.Statement.SQL.String())
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+
+		span := state.span
+		span.SetAttributes(
+			attribute.String("db.sql.table", table),
+			attribute.String("db.statement", statement),
+			attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+		)
+		if failed {
+			span.RecordError(db.Error)
+			span.SetStatus(codes.Error, db.Error.Error())
+		}
+		span.End()
+
+		dbQueryDuration.WithLabelValues(operation, table).Observe(duration.Seconds())
+		if failed {
+			dbQueryErrors.WithLabelValues(operation, table).Inc()
+		}
+
+		if i.SlowQueryThreshold > 0 && duration >= i.SlowQueryThreshold {
+			fields := map[string]interface{}{
+				"operation":   operation,
+				"table":       table,
+				"duration_ms": duration.Milliseconds(),
+				"rows":        db.Statement.RowsAffected,
+				"sql":         statement,
+				"request_id":  logger.RequestIDFromContext(state.parentCtx),
+			}
+			if spanContext := span.SpanContext(); spanContext.IsValid() {
+				fields["trace_id"] = spanContext.TraceID().String()
+			}
+			logger.GetLogger().WithFields(fields).Warn("Slow query")
+		}
+	}
+}
+
+var (
+	sqlStringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumericLiteral = regexp.MustCompile(`(^|[^\w$."])-?\d+(?:\.\d+)?`)
+	sqlWhitespace     = regexp.MustCompile(`\s+`)
+)
+
+// SanitizeSQL reemplaza los literales de texto y numéricos por ? y normaliza los espacios,
+// de modo que el SQL se puede adjuntar a spans y logs sin exponer datos
+func SanitizeSQL(sql string) string {
+	sql = sqlStringLiteral.ReplaceAllString(sql, "?")
+	sql = sqlNumericLiteral.ReplaceAllString(sql, "${1}?")
+	sql = strings.TrimSpace(sqlWhitespace.ReplaceAllString(sql, " "))
+	if len(sql) > maxStatementLength {
+		sql = sql[:maxStatementLength] + "..."
+	}
+	return sql
+}
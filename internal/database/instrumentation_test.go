@@ -0,0 +1,54 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"it-user-service/internal/logger"
+)
+
+type instrumentedUser struct {
+	ID    string
+	Email string
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	sql := SanitizeSQL(`SELECT * FROM "users"
+		WHERE email = 'ana@example.com' AND login_count > 10 AND id = $1 LIMIT 20`)
+
+	assert.Equal(t, `SELECT * FROM "users" WHERE email = ? AND login_count > ? AND id = $1 LIMIT ?`, sql)
+}
+
+func TestInstrumentation_LogsSlowQueriesWithRequestID(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(NewInstrumentation(time.Nanosecond)))
+
+	var buf bytes.Buffer
+	log := logger.GetLogger()
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stdout)
+
+	ctx := logger.ContextWithRequestID(context.Background(), "req-123")
+	var user instrumentedUser
+	db.WithContext(ctx).Where("email = ?", "ana@example.com").First(&user)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "Slow query", entry["msg"])
+	assert.Equal(t, "req-123", entry["request_id"])
+	assert.Equal(t, "query", entry["operation"])
+	assert.Equal(t, "instrumented_users", entry["table"])
+	assert.NotContains(t, entry["sql"], "ana@example.com")
+}
@@ -0,0 +1,189 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"it-user-service/internal/logger"
+)
+
+// Tipos de eventos de dominio
+const (
+	TypeSuspiciousLogin    = "security.suspicious_login"
+	TypeRefreshTokenReused = "security.refresh_token_reused"
+	TypeUserStatusChanged  = "user.status_changed"
+	TypeUserEmailChanged   = "user.email_changed"
+	TypeUserCreated        = "user.created"
+	TypeUserRoleGranted    = "user.role_granted"
+	TypeNewDeviceLogin     = "security.new_device_login"
+)
+
+// AllEvents permite suscribirse a todos los tipos de eventos
+const AllEvents = "*"
+
+var (
+	ErrBusClosed = errors.New("events: bus closed")
+	ErrQueueFull = errors.New("events: queue full")
+)
+
+// Event es un evento de dominio emitido por el servicio
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	UserID     string                 `json:"user_id,omitempty"`
+	Payload    map[string]interface{} `json:"payload,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// New crea un evento con ID y fecha de ocurrencia
+func New(eventType, userID string, payload map[string]interface{}) Event {
+	return Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		UserID:     userID,
+		Payload:    payload,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// Publisher publica eventos de dominio
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Handler procesa un evento recibido
+type Handler func(ctx context.Context, event Event) error
+
+// Bus es un bus de eventos en proceso. Publish encola el evento y un worker lo entrega
+// de forma asíncrona a los handlers suscritos, de modo que quien publica no espera
+// por los consumidores
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	queue    chan Event
+	closed   bool
+	done     chan struct{}
+
+	// lastDequeued es el OccurredAt (UnixNano) del último evento tomado de la cola
+	lastDequeued atomic.Int64
+}
+
+// NewBus crea un bus con una cola de bufferSize eventos e inicia su worker
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+	b := &Bus{
+		handlers: make(map[string][]Handler),
+		queue:    make(chan Event, bufferSize),
+		done:     make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Subscribe registra un handler para un tipo de evento (o AllEvents)
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish encola un evento para su entrega
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrBusClosed
+	}
+
+	select {
+	case b.queue <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Pending retorna el número de eventos en cola pendientes de entrega
+func (b *Bus) Pending() int {
+	return len(b.queue)
+}
+
+// Lag estima el retraso de entrega: la antigüedad del último evento tomado de la cola
+// mientras quedan eventos pendientes (0 si la cola está vacía)
+func (b *Bus) Lag() time.Duration {
+	if b.Pending() == 0 {
+		return 0
+	}
+	last := b.lastDequeued.Load()
+	if last == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, last))
+}
+
+// Closed indica si el bus dejó de aceptar eventos
+func (b *Bus) Closed() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.closed
+}
+
+// Close deja de aceptar eventos y espera a que se entreguen los pendientes
+// o a que expire ctx
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bus) run() {
+	defer close(b.done)
+	for event := range b.queue {
+		b.lastDequeued.Store(event.OccurredAt.UnixNano())
+		b.dispatch(event)
+	}
+}
+
+func (b *Bus) dispatch(event Event) {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[event.Type]...), b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(context.Background(), event); err != nil {
+			logger.GetLogger().WithError(err).WithFields(map[string]interface{}{
+				"event_id":   event.ID,
+				"event_type": event.Type,
+			}).Error("Event handler failed")
+		}
+	}
+}
+
+// LogHandler registra en el log cada evento recibido
+func LogHandler(ctx context.Context, event Event) error {
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"user_id":    event.UserID,
+		"payload":    event.Payload,
+	}).Info("Domain event emitted")
+	return nil
+}
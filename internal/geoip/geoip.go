@@ -0,0 +1,98 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// ErrNotFound se retorna cuando la IP no tiene ubicación en la base de datos
+var ErrNotFound = errors.New("geoip: location not found")
+
+// Location es la ubicación aproximada de una IP
+type Location struct {
+	Country   string
+	Latitude  float64
+	Longitude float64
+	// HasCoordinates indica si la base de datos provee coordenadas (bases City)
+	HasCoordinates bool
+}
+
+// Locator resuelve la ubicación de una IP
+type Locator interface {
+	Lookup(ip string) (*Location, error)
+	Close() error
+}
+
+// NewLocator abre la base de datos GeoIP en path. Si path está vacío retorna un
+// locator que nunca encuentra ubicaciones
+func NewLocator(path string) (Locator, error) {
+	if path == "" {
+		return noopLocator{}, nil
+	}
+	return OpenMaxMind(path)
+}
+
+// MaxMindLocator usa una base de datos local en formato MaxMind (GeoLite2/GeoIP2 City o Country)
+type MaxMindLocator struct {
+	db     *geoip2.Reader
+	isCity bool
+}
+
+// OpenMaxMind abre un archivo .mmdb local
+func OpenMaxMind(path string) (*MaxMindLocator, error) {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip database %s: %w", path, err)
+	}
+	return &MaxMindLocator{
+		db:     db,
+		isCity: strings.Contains(db.Metadata().DatabaseType, "City"),
+	}, nil
+}
+
+// Lookup obtiene país y, si la base lo soporta, coordenadas de la IP
+func (l *MaxMindLocator) Lookup(ip string) (*Location, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("geoip: invalid ip %q", ip)
+	}
+
+	if l.isCity {
+		record, err := l.db.City(parsed)
+		if err != nil {
+			return nil, err
+		}
+		if record.Country.IsoCode == "" {
+			return nil, ErrNotFound
+		}
+		return &Location{
+			Country:        record.Country.IsoCode,
+			Latitude:       record.Location.Latitude,
+			Longitude:      record.Location.Longitude,
+			HasCoordinates: record.Location.Latitude != 0 || record.Location.Longitude != 0,
+		}, nil
+	}
+
+	record, err := l.db.Country(parsed)
+	if err != nil {
+		return nil, err
+	}
+	if record.Country.IsoCode == "" {
+		return nil, ErrNotFound
+	}
+	return &Location{Country: record.Country.IsoCode}, nil
+}
+
+// Close libera la base de datos
+func (l *MaxMindLocator) Close() error {
+	return l.db.Close()
+}
+
+type noopLocator struct{}
+
+func (noopLocator) Lookup(ip string) (*Location, error) { return nil, ErrNotFound }
+func (noopLocator) Close() error                        { return nil }
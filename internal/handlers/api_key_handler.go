@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/services"
+	"it-user-service/internal/validator"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// GetAPIKeys maneja GET /admin/api-keys
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	if !isAdmin(r) {
+		log.Warn("Forbidden access to api keys")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	includeRevoked := r.URL.Query().Get("include_revoked") == "true"
+	keys, err := h.apiKeyService.ListKeys(r.Context(), includeRevoked)
+	if err != nil {
+		log.WithError(err).Error("Failed to fetch api keys")
+		http.Error(w, "Error fetching api keys", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithField("count", len(keys)).Info("API keys retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    keys,
+		"count":   len(keys),
+		"message": "API keys retrieved successfully",
+	})
+}
+
+// CreateAPIKey maneja POST /admin/api-keys. La llave en claro solo se entrega en esta respuesta
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	if !isAdmin(r) {
+		log.Warn("Forbidden api key creation")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("Failed to read request body")
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.WithError(err).Error("Failed to unmarshal JSON")
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	// Validar estructura
+	if err := validator.ValidateStruct(&req); err != nil {
+		log.WithError(err).Warn("Validation failed for api key creation")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdBy := ""
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		createdBy = principal.UserID
+	}
+
+	created, err := h.apiKeyService.CreateKey(r.Context(), &req, createdBy)
+	if err != nil {
+		log.WithError(err).Error("Failed to create api key")
+		http.Error(w, "Error creating api key", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"api_key_id": created.APIKey.ID,
+		"prefix":     created.APIKey.Prefix,
+		"scopes":     created.APIKey.Scopes,
+		"created_by": createdBy,
+	}).Info("API key created successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    created,
+		"message": "API key created successfully. Store the key now, it will not be shown again",
+	})
+}
+
+// RevokeAPIKey maneja DELETE /admin/api-keys/{id}
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if !isAdmin(r) {
+		log.WithField("api_key_id", id).Warn("Forbidden api key revocation")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(r.Context(), id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.WithField("api_key_id", id).Warn("API key not found for revocation")
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).WithField("api_key_id", id).Error("Failed to revoke api key")
+		http.Error(w, "Error revoking api key", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithField("api_key_id", id).Info("API key revoked successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "API key revoked successfully",
+	})
+}
+
+// isAdmin verifica que el principal autenticado sea un usuario administrador (los requests
+// anónimos se rechazan). Las API keys nunca administran otras API keys
+func isAdmin(r *http.Request) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return !auth.IsAnonymous(r.Context())
+	}
+	return !principal.IsAPIKey() && principal.HasRole(auth.RoleAdmin)
+}
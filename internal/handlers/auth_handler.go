@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"it-user-service/internal/logger"
+	"it-user-service/internal/middleware"
+	"it-user-service/internal/models"
+	"it-user-service/internal/services"
+	"it-user-service/internal/validator"
+)
+
+type AuthHandler struct {
+	tokenService   *services.TokenService
+	trustedProxies []*net.IPNet
+}
+
+func NewAuthHandler(tokenService *services.TokenService, trustedProxies []*net.IPNet) *AuthHandler {
+	return &AuthHandler{
+		tokenService:   tokenService,
+		trustedProxies: trustedProxies,
+	}
+}
+
+// IssueToken maneja POST /auth/token: intercambia un ID token de Firebase por tokens del servicio
+func (h *AuthHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req models.TokenRequest
+	if !decodeAuthRequest(w, r, &req) {
+		return
+	}
+
+	event := &models.LoginEvent{
+		IP:        middleware.ClientIP(r, h.trustedProxies),
+		UserAgent: r.UserAgent(),
+		Device:    req.DeviceName,
+	}
+	session := &models.Session{
+		DeviceID:   req.DeviceID,
+		DeviceName: req.DeviceName,
+		Platform:   req.Platform,
+		PushToken:  req.PushToken,
+	}
+
+	tokens, err := h.tokenService.ExchangeFirebaseToken(r.Context(), req.IDToken, event, session)
+	if err != nil {
+		writeTokenError(w, r, err)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id":    event.UserID,
+		"session_id": tokens.SessionID,
+	}).Info("Service token issued successfully")
+
+	writeTokenResponse(w, tokens, "Token issued successfully")
+}
+
+// RefreshToken maneja POST /auth/refresh: rota el refresh token y emite un nuevo access token
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req models.RefreshTokenRequest
+	if !decodeAuthRequest(w, r, &req) {
+		return
+	}
+
+	tokens, err := h.tokenService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		writeTokenError(w, r, err)
+		return
+	}
+
+	log.WithField("session_id", tokens.SessionID).Info("Service token refreshed successfully")
+
+	writeTokenResponse(w, tokens, "Token refreshed successfully")
+}
+
+// RevokeToken maneja POST /auth/revoke: cierra la sesión asociada al refresh token
+func (h *AuthHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req models.RefreshTokenRequest
+	if !decodeAuthRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.tokenService.Revoke(r.Context(), req.RefreshToken); err != nil {
+		log.WithError(err).Error("Failed to revoke refresh token")
+		http.Error(w, "Error revoking token", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("Refresh token revoked successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Token revoked successfully",
+	})
+}
+
+func decodeAuthRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	log := logger.FromContext(r.Context())
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("Failed to read request body")
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return false
+	}
+
+	if err := json.Unmarshal(body, req); err != nil {
+		log.WithError(err).Error("Failed to unmarshal JSON")
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return false
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		log.WithError(err).Warn("Validation failed for auth request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeTokenResponse(w http.ResponseWriter, tokens *models.TokenResponse, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    tokens,
+		"message": message,
+	})
+}
+
+func writeTokenError(w http.ResponseWriter, r *http.Request, err error) {
+	log := logger.FromContext(r.Context())
+
+	switch {
+	case errors.Is(err, services.ErrTokenIssuingDisabled):
+		http.Error(w, "Token issuing is not configured", http.StatusServiceUnavailable)
+	case errors.Is(err, services.ErrInvalidIDToken),
+		errors.Is(err, services.ErrInvalidRefreshToken),
+		errors.Is(err, services.ErrRefreshTokenReused):
+		log.WithError(err).Warn("Token request rejected")
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+	case errors.Is(err, services.ErrUserNotRegistered):
+		http.Error(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrUserDisabled):
+		http.Error(w, "User is disabled", http.StatusForbidden)
+	default:
+		log.WithError(err).Error("Failed to issue token")
+		http.Error(w, "Error issuing token", http.StatusInternalServerError)
+	}
+}
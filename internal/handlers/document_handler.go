@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+	"it-user-service/internal/schemas"
+)
+
+// DocumentHandler expone las claves de los documentos JSON del perfil (preferences, privacy)
+// y de las configuraciones (notifications, privacy, security), y sus schemas
+type DocumentHandler struct {
+	profileRepo repositories.ProfileRepositoryInterface
+}
+
+func NewDocumentHandler(profileRepo repositories.ProfileRepositoryInterface) *DocumentHandler {
+	return &DocumentHandler{
+		profileRepo: profileRepo,
+	}
+}
+
+// documentOwner es el perfil o las configuraciones de un usuario, que guardan los documentos
+type documentOwner struct {
+	exists  bool
+	version func() int
+	field   func(name string) (*schemas.Document, *models.JSONDocument, bool)
+	save    func(ctx context.Context) error
+}
+
+type documentLoader func(ctx context.Context, userID string) (*documentOwner, error)
+
+// loadProfile retorna el perfil del usuario, o uno nuevo si todavía no tiene
+func (h *DocumentHandler) loadProfile(ctx context.Context, userID string) (*documentOwner, error) {
+	profile, err := h.profileRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		profile = &models.UserProfile{UserID: userID}
+	}
+	return &documentOwner{
+		exists:  profile.ID != 0,
+		version: func() int { return profile.Version },
+		field: func(name string) (*schemas.Document, *models.JSONDocument, bool) {
+			return schemas.ProfileField(profile, name)
+		},
+		save: func(ctx context.Context) error {
+			if profile.ID == 0 {
+				return h.profileRepo.Create(ctx, profile)
+			}
+			return h.profileRepo.Update(ctx, profile)
+		},
+	}, nil
+}
+
+// loadSettings retorna las configuraciones del usuario, o unas nuevas si todavía no tiene
+func (h *DocumentHandler) loadSettings(ctx context.Context, userID string) (*documentOwner, error) {
+	settings, err := h.profileRepo.GetSettingsByUserID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		settings = &models.UserSettings{
+			UserID:   userID,
+			Language: "en",
+			Timezone: "UTC",
+			Theme:    "light",
+		}
+	}
+	return &documentOwner{
+		exists:  settings.ID != 0,
+		version: func() int { return settings.Version },
+		field: func(name string) (*schemas.Document, *models.JSONDocument, bool) {
+			return schemas.SettingsField(settings, name)
+		},
+		save: func(ctx context.Context) error {
+			if settings.ID == 0 {
+				return h.profileRepo.CreateSettings(ctx, settings)
+			}
+			return h.profileRepo.UpdateSettings(ctx, settings)
+		},
+	}, nil
+}
+
+// GetProfileDocumentKey maneja GET /users/{id}/profile/{document}/{key}
+func (h *DocumentHandler) GetProfileDocumentKey(w http.ResponseWriter, r *http.Request) {
+	h.getKey(w, r, h.loadProfile)
+}
+
+// PutProfileDocumentKey maneja PUT /users/{id}/profile/{document}/{key}
+func (h *DocumentHandler) PutProfileDocumentKey(w http.ResponseWriter, r *http.Request) {
+	h.putKey(w, r, h.loadProfile)
+}
+
+// DeleteProfileDocumentKey maneja DELETE /users/{id}/profile/{document}/{key}
+func (h *DocumentHandler) DeleteProfileDocumentKey(w http.ResponseWriter, r *http.Request) {
+	h.deleteKey(w, r, h.loadProfile)
+}
+
+// GetSettingsDocumentKey maneja GET /users/{id}/settings/{document}/{key}
+func (h *DocumentHandler) GetSettingsDocumentKey(w http.ResponseWriter, r *http.Request) {
+	h.getKey(w, r, h.loadSettings)
+}
+
+// PutSettingsDocumentKey maneja PUT /users/{id}/settings/{document}/{key}
+func (h *DocumentHandler) PutSettingsDocumentKey(w http.ResponseWriter, r *http.Request) {
+	h.putKey(w, r, h.loadSettings)
+}
+
+// DeleteSettingsDocumentKey maneja DELETE /users/{id}/settings/{document}/{key}
+func (h *DocumentHandler) DeleteSettingsDocumentKey(w http.ResponseWriter, r *http.Request) {
+	h.deleteKey(w, r, h.loadSettings)
+}
+
+// GetDocumentSchema maneja GET /schemas/{document}
+func (h *DocumentHandler) GetDocumentSchema(w http.ResponseWriter, r *http.Request) {
+	doc, ok := schemas.Lookup(mux.Vars(r)["document"])
+	if !ok {
+		http.Error(w, "Schema not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	json.NewEncoder(w).Encode(doc.Schema)
+}
+
+// getKey responde el valor de la clave, con el default del schema si el usuario no la escribió
+func (h *DocumentHandler) getKey(w http.ResponseWriter, r *http.Request, load documentLoader) {
+	log := logger.FromContext(r.Context())
+	owner, doc, value, key, ok := h.resolve(w, r, load)
+	if !ok {
+		return
+	}
+	if owner.exists && notModified(w, r, owner.version()) {
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id":  mux.Vars(r)["id"],
+		"document": doc.Field,
+		"key":      key,
+	}).Info("Document key retrieved successfully")
+
+	writeDocumentValue(w, owner, doc, key, *value, "Setting retrieved successfully")
+}
+
+// putKey reemplaza el valor de la clave por el cuerpo del request, validado contra el schema
+func (h *DocumentHandler) putKey(w http.ResponseWriter, r *http.Request, load documentLoader) {
+	log := logger.FromContext(r.Context())
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("Failed to read request body")
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+	var newValue interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&newValue); err != nil {
+		log.WithError(err).Error("Failed to unmarshal JSON")
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	owner, doc, value, key, ok := h.resolve(w, r, load)
+	if !ok {
+		return
+	}
+	if !checkIfMatch(w, r, owner.version(), owner.exists) {
+		return
+	}
+
+	updated, err := doc.Set(*value, key, newValue)
+	if err != nil {
+		log.WithError(err).Warn("Document key does not match its schema")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	*value = updated
+	h.save(w, r, owner, doc, value, key, "Setting updated successfully")
+}
+
+// deleteKey borra la clave del documento, que vuelve a su default
+func (h *DocumentHandler) deleteKey(w http.ResponseWriter, r *http.Request, load documentLoader) {
+	log := logger.FromContext(r.Context())
+	owner, doc, value, key, ok := h.resolve(w, r, load)
+	if !ok {
+		return
+	}
+	if !checkIfMatch(w, r, owner.version(), owner.exists) {
+		return
+	}
+
+	updated, err := doc.Unset(*value, key)
+	if err != nil {
+		// El documento guardado no cumple el schema; se corrige al reparar los documentos
+		log.WithError(err).Warn("Stored document does not match its schema")
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	*value = updated
+	h.save(w, r, owner, doc, value, key, "Setting reset to its default")
+}
+
+// resolve valida el usuario, el documento y la clave de la ruta y carga el dueño del
+// documento. Responde el error y retorna false si algo falla
+func (h *DocumentHandler) resolve(w http.ResponseWriter, r *http.Request, load documentLoader) (*documentOwner, *schemas.Document, *models.JSONDocument, string, bool) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return nil, nil, nil, "", false
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden access to user documents")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil, nil, nil, "", false
+	}
+
+	owner, err := load(r.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("Failed to fetch user documents")
+		http.Error(w, "Error fetching settings", http.StatusInternalServerError)
+		return nil, nil, nil, "", false
+	}
+
+	doc, value, ok := owner.field(vars["document"])
+	if !ok {
+		http.Error(w, "Document not found", http.StatusNotFound)
+		return nil, nil, nil, "", false
+	}
+	key := vars["key"]
+	if _, ok := doc.Property(key); !ok {
+		http.Error(w, "Setting not found", http.StatusNotFound)
+		return nil, nil, nil, "", false
+	}
+	return owner, doc, value, key, true
+}
+
+// save guarda el dueño del documento y responde el nuevo valor de la clave
+func (h *DocumentHandler) save(w http.ResponseWriter, r *http.Request, owner *documentOwner, doc *schemas.Document, value *models.JSONDocument, key, message string) {
+	log := logger.FromContext(r.Context())
+	id := mux.Vars(r)["id"]
+
+	if err := owner.save(r.Context()); err != nil {
+		if writeVersionConflict(w, r, err) {
+			return
+		}
+		log.WithError(err).WithField("user_id", id).Error("Failed to save document key")
+		http.Error(w, "Error updating settings", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id":  id,
+		"document": doc.Field,
+		"key":      key,
+	}).Info("Document key updated successfully")
+
+	owner.exists = true
+	writeDocumentValue(w, owner, doc, key, *value, message)
+}
+
+func writeDocumentValue(w http.ResponseWriter, owner *documentOwner, doc *schemas.Document, key string, stored models.JSONDocument, message string) {
+	if owner.exists {
+		setETag(w, owner.version())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": models.DocumentValue{
+			Document: doc.Field,
+			Key:      key,
+			Value:    doc.WithDefaults(stored)[key],
+		},
+		"message": message,
+	})
+}
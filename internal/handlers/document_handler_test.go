@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+type fakeDocumentRepo struct {
+	repositories.ProfileRepositoryInterface
+	settings *models.UserSettings
+}
+
+func (r *fakeDocumentRepo) GetSettingsByUserID(ctx context.Context, userID string) (*models.UserSettings, error) {
+	if r.settings == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *r.settings
+	return &copied, nil
+}
+
+func (r *fakeDocumentRepo) CreateSettings(ctx context.Context, settings *models.UserSettings) error {
+	settings.ID, settings.Version = 1, 1
+	r.settings = settings
+	return nil
+}
+
+func (r *fakeDocumentRepo) UpdateSettings(ctx context.Context, settings *models.UserSettings) error {
+	settings.Version++
+	r.settings = settings
+	return nil
+}
+
+func TestDocumentHandler_SettingsKeys(t *testing.T) {
+	repo := &fakeDocumentRepo{}
+	handler := NewDocumentHandler(repo)
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}/settings/{document}/{key}", handler.GetSettingsDocumentKey).Methods("GET")
+	router.HandleFunc("/users/{id}/settings/{document}/{key}", handler.PutSettingsDocumentKey).Methods("PUT")
+	router.HandleFunc("/users/{id}/settings/{document}/{key}", handler.DeleteSettingsDocumentKey).Methods("DELETE")
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	value := func(rec *httptest.ResponseRecorder) interface{} {
+		var response struct {
+			Data models.DocumentValue `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response.Data.Value
+	}
+
+	// Sin configuraciones se responde el default del schema
+	rec := serve(http.MethodGet, "/users/u1/settings/security/mfa_enabled", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, false, value(rec))
+
+	rec = serve(http.MethodPut, "/users/u1/settings/notifications/email", `{"kinds": {"welcome": false}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+	assert.Equal(t, map[string]interface{}{
+		"enabled": true,
+		"kinds":   map[string]interface{}{"welcome": false, "role_granted": true, "account_suspended": true, "new_device_login": true},
+	}, value(rec))
+	assert.Equal(t, "en", repo.settings.Language)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/users/u1/settings/notifications/email", `{"enabled": "yes"}`).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/users/u1/settings/notifications/sms", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/users/u1/settings/theme/dark", "").Code)
+
+	rec = serve(http.MethodDelete, "/users/u1/settings/notifications/email", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, repo.settings.Notifications, "email")
+}
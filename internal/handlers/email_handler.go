@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+	"it-user-service/internal/services"
+	"it-user-service/internal/validator"
+)
+
+type EmailHandler struct {
+	userRepo     repositories.UserRepositoryInterface
+	emailService *services.EmailChangeService
+}
+
+func NewEmailHandler(userRepo repositories.UserRepositoryInterface, emailService *services.EmailChangeService) *EmailHandler {
+	return &EmailHandler{
+		userRepo:     userRepo,
+		emailService: emailService,
+	}
+}
+
+// RequestEmailChange maneja POST /users/{id}/email/change
+func (h *EmailHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden email change request")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req models.RequestEmailChangeRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("Failed to read request body")
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.WithError(err).Error("Failed to unmarshal JSON")
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	// Validar estructura
+	if err := validator.ValidateStruct(&req); err != nil {
+		log.WithError(err).Warn("Validation failed for email change request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("User not found for email change")
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	change, err := h.emailService.RequestChange(r.Context(), user, req.NewEmail)
+	if err != nil {
+		writeEmailChangeError(w, r, err)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id":         id,
+		"email_change_id": change.ID,
+	}).Info("Email change requested successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    change,
+		"message": "Confirmation sent to the new email",
+	})
+}
+
+// ConfirmEmailChange maneja POST /auth/email/confirm. Es pública: el token enviado al nuevo
+// email es la credencial
+func (h *EmailHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	var req models.ConfirmEmailChangeRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("Failed to read request body")
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.WithError(err).Error("Failed to unmarshal JSON")
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	// Validar estructura
+	if err := validator.ValidateStruct(&req); err != nil {
+		log.WithError(err).Warn("Validation failed for email change confirmation")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.emailService.Confirm(r.Context(), req.Token)
+	if err != nil {
+		writeEmailChangeError(w, r, err)
+		return
+	}
+
+	log.WithField("user_id", user.ID).Info("Email changed successfully")
+
+	setETag(w, user.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    user,
+		"message": "Email changed successfully",
+	})
+}
+
+// GetEmailHistory maneja GET /users/{id}/email/history
+func (h *EmailHandler) GetEmailHistory(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden access to user email history")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	limit, offset := loginPagination(r)
+
+	history, err := h.emailService.History(r.Context(), id, limit, offset)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("Failed to fetch email history")
+		http.Error(w, "Error fetching email history", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": id,
+		"count":   len(history),
+	}).Info("Email history retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    history,
+		"count":   len(history),
+		"limit":   limit,
+		"offset":  offset,
+		"message": "Email history retrieved successfully",
+	})
+}
+
+// writeEmailChangeError responde los errores del cambio de email
+func writeEmailChangeError(w http.ResponseWriter, r *http.Request, err error) {
+	log := logger.FromContext(r.Context())
+	switch {
+	case errors.Is(err, services.ErrEmailChangeDisabled):
+		log.Warn("Email change requested but token signing is not configured")
+		http.Error(w, "Email change is not available", http.StatusServiceUnavailable)
+	case errors.Is(err, services.ErrEmailUnchanged):
+		http.Error(w, "The new email is the current email", http.StatusBadRequest)
+	case errors.Is(err, services.ErrEmailInUse):
+		http.Error(w, "Email is already in use", http.StatusConflict)
+	case errors.Is(err, services.ErrEmailChangeNotActive):
+		http.Error(w, "The user status does not allow email changes", http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidEmailToken):
+		log.Warn("Invalid email change token")
+		http.Error(w, "Invalid token", http.StatusBadRequest)
+	case errors.Is(err, services.ErrEmailTokenExpired):
+		http.Error(w, "Token expired", http.StatusGone)
+	case errors.Is(err, services.ErrEmailTokenConsumed):
+		http.Error(w, "Token already used", http.StatusGone)
+	default:
+		if writeVersionConflict(w, r, err) {
+			return
+		}
+		log.WithError(err).Error("Failed to process email change")
+		http.Error(w, "Error processing email change", http.StatusInternalServerError)
+	}
+}
+
+// isTrustedCaller indica si el request viene de un admin o de una API key (los requests
+// anónimos no lo son). Solo ellos pueden cambiar email_verified directamente
+func isTrustedCaller(r *http.Request) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return !auth.IsAnonymous(r.Context())
+	}
+	return principal.HasRole(auth.RoleAdmin) || principal.IsAPIKey()
+}
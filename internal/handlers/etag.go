@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"it-user-service/internal/logger"
+	"it-user-service/internal/repositories"
+)
+
+// etag retorna el ETag (fuerte) de la versión de un recurso
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setETag publica la versión del recurso en el header ETag
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// notModified responde 304 si If-None-Match incluye la versión actual del recurso
+func notModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" || !etagListMatches(header, etag(version), true) {
+		return false
+	}
+	setETag(w, version)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// checkIfMatch responde 412 si el request trae If-Match y no incluye la versión actual del
+// recurso. exists indica si el recurso existe: If-Match: * solo se cumple si existe
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int, exists bool) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	if exists && etagListMatches(header, etag(version), false) {
+		return true
+	}
+	logger.FromContext(r.Context()).WithField("if_match", header).Warn("Precondition failed")
+	if exists {
+		setETag(w, version)
+	}
+	http.Error(w, "Precondition failed: the resource was modified", http.StatusPreconditionFailed)
+	return false
+}
+
+// etagListMatches compara current con una lista de ETags (o *). If-Match usa comparación
+// fuerte (un ETag débil nunca coincide) e If-None-Match usa comparación débil
+func etagListMatches(header, current string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == current {
+			return true
+		}
+	}
+	return false
+}
+
+// writeVersionConflict responde a un ErrVersionConflict del repositorio: 412 si el cliente
+// envió If-Match (su versión ya no es la actual), 409 si la modificación ocurrió entre la
+// lectura y la escritura de este mismo request. Retorna false para otros errores
+func writeVersionConflict(w http.ResponseWriter, r *http.Request, err error) bool {
+	if !errors.Is(err, repositories.ErrVersionConflict) {
+		return false
+	}
+	logger.FromContext(r.Context()).WithError(err).Warn("Concurrent modification detected")
+	if r.Header.Get("If-Match") != "" {
+		http.Error(w, "Precondition failed: the resource was modified", http.StatusPreconditionFailed)
+		return true
+	}
+	http.Error(w, "The resource was modified concurrently, retry the request", http.StatusConflict)
+	return true
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotModified(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+	req.Header.Set("If-None-Match", `"2", W/"3"`)
+
+	rec := httptest.NewRecorder()
+	assert.True(t, notModified(rec, req, 3))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+	assert.False(t, notModified(httptest.NewRecorder(), req, 4))
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	request := func(ifMatch string) *http.Request {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/users/1", nil)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		return req
+	}
+
+	assert.True(t, checkIfMatch(httptest.NewRecorder(), request(""), 3, true))
+	assert.True(t, checkIfMatch(httptest.NewRecorder(), request(`"3"`), 3, true))
+	assert.True(t, checkIfMatch(httptest.NewRecorder(), request("*"), 3, true))
+
+	rec := httptest.NewRecorder()
+	assert.False(t, checkIfMatch(rec, request(`"2"`), 3, true))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+	// If-Match usa comparación fuerte y * no se cumple si el recurso no existe
+	assert.False(t, checkIfMatch(httptest.NewRecorder(), request(`W/"3"`), 3, true))
+	assert.False(t, checkIfMatch(httptest.NewRecorder(), request("*"), 0, false))
+}
@@ -4,81 +4,187 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/events"
+	"it-user-service/internal/middleware"
+	"it-user-service/internal/openapi"
 	"it-user-service/internal/repositories"
-	
+	"it-user-service/internal/services"
 )
 
+// Dependencies agrupa los repositorios, servicios y middlewares que usan los handlers
+type Dependencies struct {
+	UserRepo    repositories.UserRepositoryInterface
+	ProfileRepo repositories.ProfileRepositoryInterface
+	RoleRepo    repositories.RoleRepositoryInterface
+	LoginRepo   repositories.LoginEventRepositoryInterface
+
+	LoginService   *services.LoginService
+	StatusService  *services.UserStatusService
+	EmailService   *services.EmailChangeService
+	SessionService *services.SessionService
+	TokenService   *services.TokenService
+	APIKeyService  *services.APIKeyService
+	HealthService  *services.HealthService
+	Notifications  *services.NotificationService
+	PublicProfiles *services.PublicProfileService
+
+	// Publisher publica los eventos de dominio emitidos por los handlers
+	Publisher events.Publisher
+
+	Auth          middleware.AuthConfig
+	RateLimit     middleware.RateLimitConfig
+	Idempotency   middleware.IdempotencyConfig
+	QueryDeadline middleware.QueryDeadlineConfig
+	CORS          middleware.CORSConfig
+	KeyRing       *auth.KeyRing
+
+	// MaxBodyBytes limita el tamaño del cuerpo de los requests (0 = sin límite)
+	MaxBodyBytes int64
+
+	// ValidateRequests valida los requests de la API contra el documento OpenAPI
+	ValidateRequests bool
+}
+
 // SetupRoutes configura todas las rutas del servicio
-func SetupRoutes(userRepo repositories.UserRepositoryInterface, profileRepo repositories.ProfileRepositoryInterface, roleRepo repositories.RoleRepositoryInterface) *mux.Router {
+func SetupRoutes(deps Dependencies) (*mux.Router, error) {
 	router := mux.NewRouter()
 
-	// CORS middleware - DEBE IR PRIMERO
-	router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-			
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-			
-			next.ServeHTTP(w, r)
-		})
-	})
+	// CORS: responde los preflight con los métodos de cada ruta - DEBE IR PRIMERO
+	cors := deps.CORS
+	cors.Router = router
+	corsMiddleware, err := middleware.CORS(cors)
+	if err != nil {
+		return nil, err
+	}
+	router.Use(corsMiddleware)
+
+	// Request id, traza y logger del request; access log con estado y latencia
+	router.Use(middleware.RequestID())
+	router.Use(middleware.AccessLog(deps.Auth.TrustedProxies))
+
+	// Límite de tamaño del cuerpo de los requests
+	router.Use(middleware.MaxBodySize(deps.MaxBodyBytes))
 
 	// Crear handlers
-	userHandler := NewUserHandler(userRepo)
-	profileHandler := NewProfileHandler(profileRepo)
-	roleHandler := NewRoleHandler(roleRepo)
+	userHandler := NewUserHandler(deps.UserRepo, deps.Publisher)
+	profileHandler := NewProfileHandler(deps.ProfileRepo)
+	documentHandler := NewDocumentHandler(deps.ProfileRepo)
+	publicProfileHandler := NewPublicProfileHandler(deps.PublicProfiles, deps.Auth.TrustedProxies)
+	roleHandler := NewRoleHandler(deps.RoleRepo, deps.Publisher)
+	statusHandler := NewUserStatusHandler(deps.UserRepo, deps.StatusService)
+	emailHandler := NewEmailHandler(deps.UserRepo, deps.EmailService)
+	notificationHandler := NewNotificationHandler(deps.Notifications)
+	loginHandler := NewLoginHandler(deps.UserRepo, deps.LoginRepo, deps.LoginService)
+	sessionHandler := NewSessionHandler(deps.SessionService)
+	authHandler := NewAuthHandler(deps.TokenService, deps.Auth.TrustedProxies)
+	jwksHandler := NewJWKSHandler(deps.KeyRing)
+	apiKeyHandler := NewAPIKeyHandler(deps.APIKeyService)
+	healthHandler := NewHealthHandler(deps.HealthService)
+
+	// Llaves públicas para validar los tokens emitidos por el servicio
+	router.HandleFunc("/.well-known/jwks.json", jwksHandler.GetJWKS).Methods("GET")
+
+	// Métricas de Prometheus (consultas a la base de datos)
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
 
 	// API v1 routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 
-	// Aplicar CORS también al subrouter
-	api.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-			
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-			
-			next.ServeHTTP(w, r)
-		})
-	})
+	// Autenticación: valida tokens bearer o API keys y rechaza sesiones revocadas
+	if deps.Auth.Enabled() {
+		authConfig := deps.Auth
+		authConfig.RequiredScope = requiredScope
+		api.Use(middleware.Authenticate(authConfig))
+	}
+
+	// Rate limiting por usuario, API key o IP (después de autenticar)
+	if deps.RateLimit.Store != nil {
+		api.Use(middleware.RateLimit(deps.RateLimit))
+	}
 
-	// OPTIONS handler para CORS preflight
-	api.HandleFunc("/{path:.*}", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.WriteHeader(http.StatusOK)
-	}).Methods("OPTIONS")
+	// Idempotency-Key en los POST: replays con la respuesta guardada. Las rutas de tokens no
+	// la usan: guardaría los tokens emitidos y un replay esquivaría la detección de reutilización
+	if deps.Idempotency.Store != nil {
+		idempotencyConfig := deps.Idempotency
+		idempotencyConfig.ExcludedPaths = append(idempotencyConfig.ExcludedPaths, "/api/v1/auth/")
+		api.Use(middleware.Idempotency(idempotencyConfig))
+	}
+
+	// Deadline de las consultas a la base de datos por ruta
+	api.Use(middleware.QueryDeadline(deps.QueryDeadline))
 
 	// Health check routes
-	api.HandleFunc("/health", userHandler.HealthCheck).Methods("GET")
+	api.HandleFunc("/health/live", healthHandler.Liveness).Methods("GET")
+	api.HandleFunc("/health/ready", healthHandler.Readiness).Methods("GET")
+	api.HandleFunc("/health/startup", healthHandler.Startup).Methods("GET")
+	api.HandleFunc("/health", healthHandler.Liveness).Methods("GET")
+	api.HandleFunc("/ready", healthHandler.Readiness).Methods("GET")
+
+	// Token routes (públicas: autentican con el ID token de Firebase o el refresh token)
+	api.HandleFunc("/auth/token", authHandler.IssueToken).Methods("POST")
+	api.HandleFunc("/auth/refresh", authHandler.RefreshToken).Methods("POST")
+	api.HandleFunc("/auth/revoke", authHandler.RevokeToken).Methods("POST")
+	api.HandleFunc("/auth/email/confirm", emailHandler.ConfirmEmailChange).Methods("POST")
 
-	// User routes
+	// User routes (las rutas fijas van antes de /users/{id}, que también las capturaría)
 	api.HandleFunc("/users", userHandler.GetAllUsers).Methods("GET")
-	api.HandleFunc("/users/{id}", userHandler.GetUserByID).Methods("GET")
+	api.HandleFunc("/users/search", userHandler.SearchUsers).Methods("GET")
+	api.HandleFunc("/users/firebase/{firebase_id}", userHandler.GetUserByFirebaseID).Methods("GET")
 	api.HandleFunc("/users/create", userHandler.CreateUser).Methods("POST")
+	api.HandleFunc("/users/{id}", userHandler.GetUserByID).Methods("GET")
 	api.HandleFunc("/users/{id}", userHandler.UpdateUser).Methods("PUT")
+	api.HandleFunc("/users/{id}", userHandler.PatchUser).Methods("PATCH")
 	api.HandleFunc("/users/{id}", userHandler.DeleteUser).Methods("DELETE")
-	api.HandleFunc("/users/firebase/{firebase_id}", userHandler.GetUserByFirebaseID).Methods("GET")
-	api.HandleFunc("/users/search", userHandler.SearchUsers).Methods("GET")
+
+	// User status routes (ciclo de vida)
+	api.HandleFunc("/users/{id}/status", statusHandler.ChangeUserStatus).Methods("POST")
+	api.HandleFunc("/users/{id}/status/history", statusHandler.GetUserStatusHistory).Methods("GET")
+
+	// Email change routes (el nuevo email se confirma con POST /auth/email/confirm)
+	api.HandleFunc("/users/{id}/email/change", emailHandler.RequestEmailChange).Methods("POST")
+	api.HandleFunc("/users/{id}/email/history", emailHandler.GetEmailHistory).Methods("GET")
+
+	// Notification routes (registro de entregas)
+	api.HandleFunc("/users/{id}/notifications", notificationHandler.GetNotificationDeliveries).Methods("GET")
 
 	// Profile routes
 	api.HandleFunc("/users/{id}/profile", profileHandler.GetUserProfile).Methods("GET")
 	api.HandleFunc("/users/{id}/profile", profileHandler.UpdateUserProfile).Methods("PUT")
+	api.HandleFunc("/users/{id}/profile", profileHandler.PatchUserProfile).Methods("PATCH")
 	api.HandleFunc("/users/{id}/settings", profileHandler.GetUserSettings).Methods("GET")
 	api.HandleFunc("/users/{id}/settings", profileHandler.UpdateUserSettings).Methods("PUT")
+	api.HandleFunc("/users/{id}/settings", profileHandler.PatchUserSettings).Methods("PATCH")
 	api.HandleFunc("/users/{id}/stats", profileHandler.GetUserStats).Methods("GET")
 
+	// Profile and settings document routes (una clave de preferences, privacy, notifications o security)
+	api.HandleFunc("/users/{id}/profile/{document}/{key}", documentHandler.GetProfileDocumentKey).Methods("GET")
+	api.HandleFunc("/users/{id}/profile/{document}/{key}", documentHandler.PutProfileDocumentKey).Methods("PUT")
+	api.HandleFunc("/users/{id}/profile/{document}/{key}", documentHandler.DeleteProfileDocumentKey).Methods("DELETE")
+	api.HandleFunc("/users/{id}/settings/{document}/{key}", documentHandler.GetSettingsDocumentKey).Methods("GET")
+	api.HandleFunc("/users/{id}/settings/{document}/{key}", documentHandler.PutSettingsDocumentKey).Methods("PUT")
+	api.HandleFunc("/users/{id}/settings/{document}/{key}", documentHandler.DeleteSettingsDocumentKey).Methods("DELETE")
+	api.HandleFunc("/schemas/{document}", documentHandler.GetDocumentSchema).Methods("GET")
+
+	// Public profile routes (por ID o username, con los campos que el visitante puede ver)
+	api.HandleFunc("/profiles/{user}", publicProfileHandler.GetPublicProfile).Methods("GET")
+
+	// Login history routes
+	api.HandleFunc("/users/{id}/login", loginHandler.RecordLogin).Methods("POST")
+	api.HandleFunc("/users/{id}/logins", loginHandler.GetUserLogins).Methods("GET")
+	api.HandleFunc("/users/{id}/logins/suspicious", loginHandler.GetUserSuspiciousLogins).Methods("GET")
+	api.HandleFunc("/logins/suspicious", loginHandler.GetSuspiciousLogins).Methods("GET")
+
+	// Session and device routes
+	api.HandleFunc("/users/{id}/sessions", sessionHandler.GetUserSessions).Methods("GET")
+	api.HandleFunc("/users/{id}/sessions/{sid}", sessionHandler.RevokeUserSession).Methods("DELETE")
+
+	// API key administration routes
+	api.HandleFunc("/admin/api-keys", apiKeyHandler.GetAPIKeys).Methods("GET")
+	api.HandleFunc("/admin/api-keys", apiKeyHandler.CreateAPIKey).Methods("POST")
+	api.HandleFunc("/admin/api-keys/{id}", apiKeyHandler.RevokeAPIKey).Methods("DELETE")
+
 	// Role routes
 	api.HandleFunc("/roles", roleHandler.GetAllRoles).Methods("GET")
 	api.HandleFunc("/roles/{id}", roleHandler.GetRoleByID).Methods("GET")
@@ -91,5 +197,28 @@ func SetupRoutes(userRepo repositories.UserRepositoryInterface, profileRepo repo
 	api.HandleFunc("/users/{user_id}/roles/{role_name}", roleHandler.RemoveRoleFromUser).Methods("DELETE")
 	api.HandleFunc("/users/{user_id}/roles", roleHandler.GetUserRoles).Methods("GET")
 
-	return router
+	// Documento OpenAPI generado a partir de las rutas (incluida la suya) y Swagger UI
+	specRoute := router.Path(openAPIPath).Methods("GET")
+	router.HandleFunc(docsPath, openapi.DocsHandler(apiSpec.Title, openAPIPath)).Methods("GET")
+	spec, err := apiSpec.Generate(router)
+	if err != nil {
+		return nil, err
+	}
+	specHandler, err := openapi.Handler(spec)
+	if err != nil {
+		return nil, err
+	}
+	specRoute.HandlerFunc(specHandler)
+
+	// Validación de los requests contra el documento (después de autenticar)
+	if deps.ValidateRequests {
+		api.Use(openapi.ValidateRequests(spec))
+	}
+
+	// Ruta OPTIONS para cualquier path, así los preflight llegan al middleware de CORS
+	router.Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return router, nil
 }
\ No newline at end of file
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/middleware"
+	"it-user-service/internal/models"
+	"it-user-service/internal/ratelimit"
+	"it-user-service/internal/repositories"
+)
+
+// recordingLimitStore registra las keys de los buckets y rechaza todos los requests
+type recordingLimitStore struct {
+	keys []string
+}
+
+func (s *recordingLimitStore) Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	s.keys = append(s.keys, key)
+	return ratelimit.Result{Limit: limit.Burst}, nil
+}
+
+func TestSetupRoutes_SearchUsesRouteRateLimit(t *testing.T) {
+	routes, err := ratelimit.ParseRouteLimits("GET /api/v1/users/search=5:10")
+	require.NoError(t, err)
+	store := &recordingLimitStore{}
+	router, err := SetupRoutes(Dependencies{RateLimit: middleware.RateLimitConfig{
+		Store:   store,
+		Default: ratelimit.Limit{Rate: 100, Burst: 200},
+		Routes:  routes,
+	}})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/search?q=ana", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get("RateLimit-Limit"))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/6f1c3a52-8a3e-4d4e-9f43-2b7f1c0d9a11", nil))
+	assert.Equal(t, "200", rec.Header().Get("RateLimit-Limit"))
+
+	require.Len(t, store.keys, 2)
+	assert.Contains(t, store.keys[0], "GET /api/v1/users/search:")
+	assert.Contains(t, store.keys[1], "default:")
+}
+
+// deadlineUserRepo registra el deadline del contexto con el que se consulta
+type deadlineUserRepo struct {
+	repositories.UserRepositoryInterface
+	remaining time.Duration
+}
+
+func (r *deadlineUserRepo) SearchUsers(ctx context.Context, query string, limit, offset int) ([]models.User, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		r.remaining = time.Until(deadline)
+	}
+	return nil, nil
+}
+
+func TestSetupRoutes_SearchUsesRouteQueryDeadline(t *testing.T) {
+	routes, err := middleware.ParseRouteTimeouts("GET /api/v1/users/search=2s")
+	require.NoError(t, err)
+	repo := &deadlineUserRepo{}
+	router, err := SetupRoutes(Dependencies{
+		UserRepo:      repo,
+		QueryDeadline: middleware.QueryDeadlineConfig{Default: time.Minute, Routes: routes},
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/search?q=ana", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Greater(t, repo.remaining, time.Duration(0))
+	assert.LessOrEqual(t, repo.remaining, 2*time.Second)
+}
+
+func TestSetupRoutes_UserWritesRequireAccess(t *testing.T) {
+	router, err := SetupRoutes(Dependencies{})
+	require.NoError(t, err)
+
+	principal := &auth.Principal{UserID: "6f1c3a52-8a3e-4d4e-9f43-2b7f1c0d9a11"}
+	other := "/api/v1/users/2b7c1a52-7f1e-4d8e-9a55-0d6f1f3e2a10"
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPut, other},
+		{http.MethodPatch, other},
+		{http.MethodDelete, other},
+		{http.MethodPut, other + "/profile"},
+		{http.MethodPatch, other + "/profile"},
+		{http.MethodPut, other + "/settings"},
+		{http.MethodPatch, other + "/settings"},
+	}
+	for _, tt := range requests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"first_name": "Mallory"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code, "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestSetupRoutes_RejectsAnonymousAdminRequests(t *testing.T) {
+	router, err := SetupRoutes(Dependencies{
+		Auth: middleware.AuthConfig{Validator: auth.NewJWTManager("secret", "it-user-service")},
+	})
+	require.NoError(t, err)
+
+	userID := "6f1c3a52-8a3e-4d4e-9f43-2b7f1c0d9a11"
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/api/v1/admin/api-keys", ""},
+		{http.MethodPost, "/api/v1/admin/api-keys", `{"name": "pwned", "scopes": ["users:write", "roles:write"]}`},
+		{http.MethodDelete, "/api/v1/admin/api-keys/1", ""},
+		{http.MethodPost, "/api/v1/roles", `{"name": "owner"}`},
+		{http.MethodPost, "/api/v1/users/" + userID + "/roles", `{"user_id": "` + userID + `", "role_name": "admin"}`},
+		{http.MethodDelete, "/api/v1/users/" + userID + "/roles/admin", ""},
+	}
+	for _, tt := range requests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code, "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestSetupRoutes_RejectsAnonymousUserRequests(t *testing.T) {
+	router, err := SetupRoutes(Dependencies{
+		Auth: middleware.AuthConfig{Validator: auth.NewJWTManager("secret", "it-user-service")},
+	})
+	require.NoError(t, err)
+
+	user := "/api/v1/users/6f1c3a52-8a3e-4d4e-9f43-2b7f1c0d9a11"
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, user + "/sessions"},
+		{http.MethodDelete, user + "/sessions/9d3f0f5e-1c2b-4a7d-8e6f-5a4b3c2d1e0f"},
+		{http.MethodGet, user + "/logins"},
+		{http.MethodPost, user + "/login"},
+		{http.MethodPost, user + "/status"},
+		{http.MethodGet, user + "/status/history"},
+		{http.MethodPut, user},
+		{http.MethodPatch, user},
+		{http.MethodDelete, user},
+		{http.MethodPatch, user + "/profile"},
+		{http.MethodPatch, user + "/settings"},
+	}
+	for _, tt := range requests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"first_name": "Mallory", "status": "active", "reason": "admin_action"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code, "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestSetupRoutes_AnonymousCannotVerifyEmail(t *testing.T) {
+	router, err := SetupRoutes(Dependencies{
+		Auth: middleware.AuthConfig{Validator: auth.NewJWTManager("secret", "it-user-service")},
+	})
+	require.NoError(t, err)
+
+	body := `{"firebase_id": "fb-1", "email": "mallory@example.com", "username": "mallory", "email_verified": true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/create", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+// singleUserRepo retorna siempre el mismo usuario y acepta sus actualizaciones
+type singleUserRepo struct {
+	repositories.UserRepositoryInterface
+	user *models.User
+}
+
+func (r *singleUserRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return r.user, nil
+}
+
+func (r *singleUserRepo) Update(ctx context.Context, user *models.User) error {
+	return nil
+}
+
+func TestSetupRoutes_UserWritesCannotChangeDisabled(t *testing.T) {
+	repo := &singleUserRepo{user: &models.User{ID: "6f1c3a52-8a3e-4d4e-9f43-2b7f1c0d9a11", Username: "ana", Status: models.StatusActive, Version: 1}}
+	router, err := SetupRoutes(Dependencies{UserRepo: repo})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/"+repo.user.ID, strings.NewReader(`{"disabled": true}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/users/"+repo.user.ID, strings.NewReader(`{"disabled": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, repo.user.Disabled)
+}
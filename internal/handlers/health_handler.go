@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"it-user-service/internal/services"
+)
+
+type HealthHandler struct {
+	health *services.HealthService
+}
+
+func NewHealthHandler(health *services.HealthService) *HealthHandler {
+	return &HealthHandler{
+		health: health,
+	}
+}
+
+// Liveness maneja GET /health/live
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	h.writeReport(w, r, services.ProbeLiveness)
+}
+
+// Readiness maneja GET /health/ready: responde 503 si falla un check crítico o mientras
+// el servicio se está apagando, para que el balanceador deje de enviarle tráfico
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	h.writeReport(w, r, services.ProbeReadiness)
+}
+
+// Startup maneja GET /health/startup
+func (h *HealthHandler) Startup(w http.ResponseWriter, r *http.Request) {
+	h.writeReport(w, r, services.ProbeStartup)
+}
+
+func (h *HealthHandler) writeReport(w http.ResponseWriter, r *http.Request, probe services.Probe) {
+	report := h.health.Check(r.Context(), probe)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == services.HealthFail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
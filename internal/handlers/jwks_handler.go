@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"it-user-service/internal/auth"
+)
+
+type JWKSHandler struct {
+	keyRing *auth.KeyRing
+}
+
+func NewJWKSHandler(keyRing *auth.KeyRing) *JWKSHandler {
+	return &JWKSHandler{
+		keyRing: keyRing,
+	}
+}
+
+// GetJWKS maneja GET /.well-known/jwks.json: publica las llaves públicas activa,
+// siguiente y retiradas para que otros servicios validen los tokens por kid
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	jwks := auth.JWKS{Keys: []auth.JWK{}}
+	if h.keyRing != nil {
+		jwks = h.keyRing.JWKS()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(jwks)
+}
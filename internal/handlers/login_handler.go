@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+	"it-user-service/internal/services"
+	"it-user-service/internal/validator"
+)
+
+type LoginHandler struct {
+	userRepo     repositories.UserRepositoryInterface
+	loginRepo    repositories.LoginEventRepositoryInterface
+	loginService *services.LoginService
+}
+
+func NewLoginHandler(userRepo repositories.UserRepositoryInterface, loginRepo repositories.LoginEventRepositoryInterface, loginService *services.LoginService) *LoginHandler {
+	return &LoginHandler{
+		userRepo:     userRepo,
+		loginRepo:    loginRepo,
+		loginService: loginService,
+	}
+}
+
+// RecordLogin maneja POST /users/{id}/login
+func (h *LoginHandler) RecordLogin(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided for login update")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// Solo los admins y las API keys registran logins: un evento marcado puede dejar
+	// al usuario en pending
+	if !isTrustedCaller(r) {
+		log.WithField("user_id", id).Warn("Forbidden login recording")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req models.RecordLoginRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("Failed to read request body")
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.WithError(err).Error("Failed to unmarshal JSON")
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	// Validar estructura
+	if err := validator.ValidateStruct(&req); err != nil {
+		log.WithError(err).Warn("Validation failed for login info update")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Verificar que el usuario existe
+	if _, err := h.userRepo.GetByID(r.Context(), id); err != nil {
+		log.WithError(err).WithField("user_id", id).Error("User not found for login update")
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	event := &models.LoginEvent{
+		UserID:    id,
+		IP:        req.LoginIP,
+		UserAgent: req.UserAgent,
+		Device:    req.LoginDevice,
+		Provider:  req.Provider,
+		Success:   req.Success == nil || *req.Success,
+	}
+	if event.UserAgent == "" {
+		event.UserAgent = r.UserAgent()
+	}
+	session := &models.Session{
+		DeviceID:  req.DeviceID,
+		Platform:  req.Platform,
+		PushToken: req.PushToken,
+	}
+
+	// Registrar el login en el historial evaluando su riesgo
+	if err := h.loginService.RecordLogin(r.Context(), event, session); err != nil {
+		log.WithError(err).WithField("user_id", id).Error("Failed to update login info")
+		http.Error(w, "Error updating login info", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id":    id,
+		"success":    event.Success,
+		"risk_score": event.RiskScore,
+		"flagged":    event.Flagged,
+	}).Info("Login info updated successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]interface{}{
+		"data":    event,
+		"message": "Login info updated successfully",
+	}
+	if event.SessionID != "" {
+		response["session"] = session
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetUserLogins maneja GET /users/{id}/logins
+func (h *LoginHandler) GetUserLogins(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden access to login history")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	limit, offset := loginPagination(r)
+
+	events, err := h.loginRepo.GetByUserID(r.Context(), id, limit, offset)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("Failed to fetch login history")
+		http.Error(w, "Error fetching login history", http.StatusInternalServerError)
+		return
+	}
+
+	total, err := h.loginRepo.CountByUserID(r.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("Failed to count login history")
+		http.Error(w, "Error fetching login history", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": id,
+		"count":   len(events),
+	}).Info("Login history retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    events,
+		"count":   len(events),
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+		"message": "Login history retrieved successfully",
+	})
+}
+
+// GetUserSuspiciousLogins maneja GET /users/{id}/logins/suspicious
+func (h *LoginHandler) GetUserSuspiciousLogins(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		logger.FromContext(r.Context()).Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		logger.FromContext(r.Context()).WithField("user_id", id).Warn("Forbidden access to suspicious logins")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.writeSuspiciousLogins(w, r, id)
+}
+
+// GetSuspiciousLogins maneja GET /logins/suspicious. Lista los logins de todos los
+// usuarios, por lo que solo lo usan los administradores
+func (h *LoginHandler) GetSuspiciousLogins(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		logger.FromContext(r.Context()).Warn("Forbidden access to suspicious logins")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.writeSuspiciousLogins(w, r, r.URL.Query().Get("user_id"))
+}
+
+func (h *LoginHandler) writeSuspiciousLogins(w http.ResponseWriter, r *http.Request, userID string) {
+	log := logger.FromContext(r.Context())
+	limit, offset := loginPagination(r)
+
+	events, err := h.loginRepo.GetFlagged(r.Context(), userID, limit, offset)
+	if err != nil {
+		log.WithError(err).WithField("user_id", userID).Error("Failed to fetch suspicious logins")
+		http.Error(w, "Error fetching suspicious logins", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": userID,
+		"count":   len(events),
+	}).Info("Suspicious logins retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    events,
+		"count":   len(events),
+		"limit":   limit,
+		"offset":  offset,
+		"message": "Suspicious logins retrieved successfully",
+	})
+}
+
+// loginPagination lee los parámetros limit/offset de los listados de logins
+func loginPagination(r *http.Request) (int, int) {
+	limit := 20 // default
+	offset := 0 // default
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	return limit, offset
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+type fakeLoginEventRepo struct {
+	repositories.LoginEventRepositoryInterface
+}
+
+func (r *fakeLoginEventRepo) GetByUserID(_ context.Context, userID string, limit, offset int) ([]models.LoginEvent, error) {
+	return []models.LoginEvent{{UserID: userID, Success: true}}, nil
+}
+
+func (r *fakeLoginEventRepo) CountByUserID(_ context.Context, userID string) (int64, error) {
+	return 1, nil
+}
+
+func (r *fakeLoginEventRepo) GetFlagged(_ context.Context, userID string, limit, offset int) ([]models.LoginEvent, error) {
+	return []models.LoginEvent{{UserID: "u1", Flagged: true}}, nil
+}
+
+func TestLoginHandler_RequiresAccess(t *testing.T) {
+	handler := NewLoginHandler(nil, &fakeLoginEventRepo{}, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}/login", handler.RecordLogin).Methods("POST")
+	router.HandleFunc("/users/{id}/logins", handler.GetUserLogins).Methods("GET")
+	router.HandleFunc("/users/{id}/logins/suspicious", handler.GetUserSuspiciousLogins).Methods("GET")
+	router.HandleFunc("/logins/suspicious", handler.GetSuspiciousLogins).Methods("GET")
+
+	serve := func(principal *auth.Principal, method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	user := &auth.Principal{UserID: "u1"}
+	admin := &auth.Principal{UserID: "a1", Roles: []string{auth.RoleAdmin}}
+
+	assert.Equal(t, http.StatusOK, serve(user, http.MethodGet, "/users/u1/logins"))
+	assert.Equal(t, http.StatusForbidden, serve(user, http.MethodGet, "/users/u2/logins"))
+	assert.Equal(t, http.StatusForbidden, serve(user, http.MethodGet, "/users/u2/logins/suspicious"))
+	assert.Equal(t, http.StatusForbidden, serve(user, http.MethodPost, "/users/u1/login"))
+
+	// El listado de todos los usuarios es solo para administradores
+	assert.Equal(t, http.StatusForbidden, serve(user, http.MethodGet, "/logins/suspicious"))
+	assert.Equal(t, http.StatusForbidden, serve(&auth.Principal{APIKeyID: "k1", Scopes: []string{models.ScopeSessionsRead}}, http.MethodGet, "/logins/suspicious"))
+	assert.Equal(t, http.StatusOK, serve(admin, http.MethodGet, "/logins/suspicious"))
+	assert.Equal(t, http.StatusOK, serve(admin, http.MethodGet, "/users/u2/logins"))
+}
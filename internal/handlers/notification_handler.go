@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/services"
+)
+
+type NotificationHandler struct {
+	notifications *services.NotificationService
+}
+
+func NewNotificationHandler(notifications *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notifications: notifications,
+	}
+}
+
+// GetNotificationDeliveries maneja GET /users/{id}/notifications
+func (h *NotificationHandler) GetNotificationDeliveries(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden access to user notifications")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if h.notifications == nil {
+		http.Error(w, "Notifications are disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit, offset := loginPagination(r)
+
+	deliveries, err := h.notifications.Deliveries(r.Context(), id, limit, offset)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("Failed to fetch notification deliveries")
+		http.Error(w, "Error fetching notifications", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": id,
+		"count":   len(deliveries),
+	}).Info("Notification deliveries retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    deliveries,
+		"count":   len(deliveries),
+		"limit":   limit,
+		"offset":  offset,
+		"message": "Notifications retrieved successfully",
+	})
+}
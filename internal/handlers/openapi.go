@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"it-user-service/internal/auth"
+	"it-user-service/internal/models"
+	"it-user-service/internal/openapi"
+	"it-user-service/internal/services"
+)
+
+// Rutas del documento OpenAPI y de Swagger UI
+const (
+	openAPIPath = "/openapi.json"
+	docsPath    = "/docs"
+)
+
+var pagination = []openapi.Parameter{
+	{Name: "limit", Type: "integer", Description: "Maximum number of results"},
+	{Name: "offset", Type: "integer", Description: "Number of results to skip"},
+}
+
+// apiSpec describe cada ruta registrada en SetupRoutes. SetupRoutes falla si una ruta no
+// tiene operación o una operación no tiene ruta
+var apiSpec = openapi.Spec{
+	Title:   "IT User Service API",
+	Version: "1.0.0",
+	Operations: map[string]openapi.Operation{
+		// Documentación, llaves públicas y métricas
+		"GET " + openAPIPath:         {Summary: "OpenAPI document", Tags: []string{"docs"}, Raw: true, Public: true},
+		"GET " + docsPath:            {Summary: "Swagger UI", Tags: []string{"docs"}, Raw: true, Public: true},
+		"GET /.well-known/jwks.json": {Summary: "Public keys that sign the access tokens", Tags: []string{"auth"}, Response: auth.JWKS{}, Raw: true, Public: true},
+		"GET /metrics":               {Summary: "Prometheus metrics", Tags: []string{"health"}, Raw: true, Public: true},
+
+		// Health checks
+		"GET /api/v1/health/live":    {Summary: "Liveness probe", Tags: []string{"health"}, Response: services.HealthReport{}, Raw: true, Public: true},
+		"GET /api/v1/health/ready":   {Summary: "Readiness probe", Tags: []string{"health"}, Response: services.HealthReport{}, Raw: true, Public: true},
+		"GET /api/v1/health/startup": {Summary: "Startup probe", Tags: []string{"health"}, Response: services.HealthReport{}, Raw: true, Public: true},
+		"GET /api/v1/health":         {Summary: "Liveness probe (alias)", Tags: []string{"health"}, Response: services.HealthReport{}, Raw: true, Public: true},
+		"GET /api/v1/ready":          {Summary: "Readiness probe (alias)", Tags: []string{"health"}, Response: services.HealthReport{}, Raw: true, Public: true},
+
+		// Tokens
+		"POST /api/v1/auth/token":   {Summary: "Exchange a Firebase ID token for an access and refresh token", Tags: []string{"auth"}, Request: models.TokenRequest{}, Response: models.TokenResponse{}, Public: true},
+		"POST /api/v1/auth/refresh": {Summary: "Rotate a refresh token", Tags: []string{"auth"}, Request: models.RefreshTokenRequest{}, Response: models.TokenResponse{}, Public: true},
+		"POST /api/v1/auth/revoke":  {Summary: "Revoke a refresh token and its session", Tags: []string{"auth"}, Request: models.RefreshTokenRequest{}, Public: true},
+
+		// Cambio de email
+		"POST /api/v1/users/{id}/email/change": {Summary: "Request an email change (sends a confirmation token to the new email)", Tags: []string{"users"}, Request: models.RequestEmailChangeRequest{}, Response: models.EmailChange{}, Status: 202},
+		"POST /api/v1/auth/email/confirm":      {Summary: "Confirm an email change with the emailed token", Tags: []string{"users"}, Request: models.ConfirmEmailChangeRequest{}, Response: models.User{}, Public: true},
+		"GET /api/v1/users/{id}/email/history": {Summary: "List a user's previous emails", Tags: []string{"users"}, Response: models.EmailHistory{}, List: true, Query: pagination},
+
+		// Notificaciones
+		"GET /api/v1/users/{id}/notifications": {Summary: "List the notifications delivered to a user and their delivery status", Tags: []string{"users"}, Response: models.NotificationDelivery{}, List: true, Query: pagination},
+
+		// Usuarios
+		"GET /api/v1/users":                        {Summary: "List users", Tags: []string{"users"}, Response: models.User{}, List: true, Query: pagination},
+		"GET /api/v1/users/{id}":                   {Summary: "Get a user", Tags: []string{"users"}, Response: models.User{}},
+		"POST /api/v1/users/create":                {Summary: "Create a user", Tags: []string{"users"}, Request: models.CreateUserRequest{}, Response: models.User{}, Status: 201},
+		"PUT /api/v1/users/{id}":                   {Summary: "Update a user", Tags: []string{"users"}, Request: models.UpdateUserRequest{}, Response: models.User{}},
+		"PATCH /api/v1/users/{id}":                 {Summary: "Patch a user (null clears a field)", Tags: []string{"users"}, Patch: models.UserPatch{}, Response: models.User{}},
+		"DELETE /api/v1/users/{id}":                {Summary: "Delete a user", Tags: []string{"users"}},
+		"GET /api/v1/users/firebase/{firebase_id}": {Summary: "Get a user by Firebase ID", Tags: []string{"users"}, Response: models.User{}},
+		"GET /api/v1/users/search": {
+			Summary: "Search users by email, username or name", Tags: []string{"users"}, Response: models.User{}, List: true,
+			Query: append([]openapi.Parameter{{Name: "q", Description: "Search term", Required: true}}, pagination...),
+		},
+
+		// Perfil, configuración y estadísticas
+		"GET /api/v1/users/{id}/profile":    {Summary: "Get a user's profile", Tags: []string{"profiles"}, Response: models.UserProfile{}},
+		"PUT /api/v1/users/{id}/profile":    {Summary: "Update a user's profile", Tags: []string{"profiles"}, Request: models.UpdateProfileRequest{}, Response: models.UserProfile{}},
+		"PATCH /api/v1/users/{id}/profile":  {Summary: "Patch a user's profile (null clears a field)", Tags: []string{"profiles"}, Patch: models.ProfilePatch{}, Response: models.UserProfile{}},
+		"GET /api/v1/users/{id}/settings":   {Summary: "Get a user's settings", Tags: []string{"profiles"}, Response: models.UserSettings{}},
+		"PUT /api/v1/users/{id}/settings":   {Summary: "Update a user's settings", Tags: []string{"profiles"}, Request: models.UpdateSettingsRequest{}, Response: models.UserSettings{}},
+		"PATCH /api/v1/users/{id}/settings": {Summary: "Patch a user's settings", Tags: []string{"profiles"}, Patch: models.SettingsPatch{}, Response: models.UserSettings{}},
+		"GET /api/v1/users/{id}/stats":      {Summary: "Get a user's stats", Tags: []string{"profiles"}, Response: models.UserStats{}},
+
+		// Claves de los documentos del perfil y de la configuración, y sus JSON Schemas
+		"GET /api/v1/users/{id}/profile/{document}/{key}":     {Summary: "Get a profile preference or privacy key (with its default)", Tags: []string{"profiles"}, Response: models.DocumentValue{}},
+		"PUT /api/v1/users/{id}/profile/{document}/{key}":     {Summary: "Set a profile preference or privacy key (body is the JSON value)", Tags: []string{"profiles"}, Request: json.RawMessage(nil), Response: models.DocumentValue{}},
+		"DELETE /api/v1/users/{id}/profile/{document}/{key}":  {Summary: "Reset a profile preference or privacy key to its default", Tags: []string{"profiles"}, Response: models.DocumentValue{}},
+		"GET /api/v1/users/{id}/settings/{document}/{key}":    {Summary: "Get a notifications, privacy or security setting (with its default)", Tags: []string{"profiles"}, Response: models.DocumentValue{}},
+		"PUT /api/v1/users/{id}/settings/{document}/{key}":    {Summary: "Set a notifications, privacy or security setting (body is the JSON value)", Tags: []string{"profiles"}, Request: json.RawMessage(nil), Response: models.DocumentValue{}},
+		"DELETE /api/v1/users/{id}/settings/{document}/{key}": {Summary: "Reset a notifications, privacy or security setting to its default", Tags: []string{"profiles"}, Response: models.DocumentValue{}},
+		"GET /api/v1/schemas/{document}":                      {Summary: "JSON Schema of a profile or settings document, e.g. settings.notifications", Tags: []string{"docs"}, Raw: true, Public: true},
+
+		// Perfil público
+		"GET /api/v1/profiles/{user}": {Summary: "Get a user's public profile by ID or username (fields filtered by their visibility)", Tags: []string{"profiles"}, Response: models.PublicProfile{}, Public: true},
+
+		// Ciclo de vida de los usuarios
+		"POST /api/v1/users/{id}/status":        {Summary: "Change a user's status", Tags: []string{"users"}, Request: models.ChangeUserStatusRequest{}, Response: models.User{}},
+		"GET /api/v1/users/{id}/status/history": {Summary: "List a user's status changes", Tags: []string{"users"}, Response: models.UserStatusChange{}, List: true, Query: pagination},
+
+		// Historial de logins
+		"POST /api/v1/users/{id}/login":            {Summary: "Record a login", Tags: []string{"logins"}, Request: models.RecordLoginRequest{}, Response: models.LoginEvent{}, Status: 201},
+		"GET /api/v1/users/{id}/logins":            {Summary: "List a user's logins", Tags: []string{"logins"}, Response: models.LoginEvent{}, List: true, Query: pagination},
+		"GET /api/v1/users/{id}/logins/suspicious": {Summary: "List a user's flagged logins", Tags: []string{"logins"}, Response: models.LoginEvent{}, List: true, Query: pagination},
+		"GET /api/v1/logins/suspicious": {
+			Summary: "List flagged logins", Tags: []string{"logins"}, Response: models.LoginEvent{}, List: true,
+			Query: append([]openapi.Parameter{{Name: "user_id", Description: "Only logins of this user"}}, pagination...),
+		},
+
+		// Sesiones
+		"GET /api/v1/users/{id}/sessions": {
+			Summary: "List a user's sessions", Tags: []string{"sessions"}, Response: models.Session{}, List: true,
+			Query: []openapi.Parameter{{Name: "include_revoked", Type: "boolean", Description: "Include revoked sessions"}},
+		},
+		"DELETE /api/v1/users/{id}/sessions/{sid}": {Summary: "Revoke a session", Tags: []string{"sessions"}},
+
+		// API keys
+		"GET /api/v1/admin/api-keys": {
+			Summary: "List API keys", Tags: []string{"api-keys"}, Response: models.APIKey{}, List: true,
+			Query: []openapi.Parameter{{Name: "include_revoked", Type: "boolean", Description: "Include revoked keys"}},
+		},
+		"POST /api/v1/admin/api-keys":        {Summary: "Create an API key", Tags: []string{"api-keys"}, Request: models.CreateAPIKeyRequest{}, Response: models.CreateAPIKeyResponse{}, Status: 201},
+		"DELETE /api/v1/admin/api-keys/{id}": {Summary: "Revoke an API key", Tags: []string{"api-keys"}},
+
+		// Roles
+		"GET /api/v1/roles":         {Summary: "List roles", Tags: []string{"roles"}, Response: models.Role{}, List: true},
+		"GET /api/v1/roles/{id}":    {Summary: "Get a role", Tags: []string{"roles"}, Response: models.Role{}},
+		"POST /api/v1/roles":        {Summary: "Create a role", Tags: []string{"roles"}, Request: models.CreateRoleRequest{}, Response: models.Role{}, Status: 201},
+		"PUT /api/v1/roles/{id}":    {Summary: "Update a role", Tags: []string{"roles"}, Request: models.UpdateRoleRequest{}, Response: models.Role{}},
+		"DELETE /api/v1/roles/{id}": {Summary: "Delete a role", Tags: []string{"roles"}},
+
+		// Roles de usuario
+		"POST /api/v1/users/{user_id}/roles":               {Summary: "Assign a role to a user", Tags: []string{"roles"}, Request: models.AssignRoleRequest{}},
+		"DELETE /api/v1/users/{user_id}/roles/{role_name}": {Summary: "Remove a role from a user", Tags: []string{"roles"}},
+		"GET /api/v1/users/{user_id}/roles":                {Summary: "List a user's roles", Tags: []string{"roles"}, Response: models.UserRole{}, List: true},
+	},
+}
+
+// APIDocument genera el documento OpenAPI de las rutas que registra SetupRoutes, sin
+// conectarse a la base de datos ni a Redis
+func APIDocument() (*openapi.Document, error) {
+	router, err := SetupRoutes(Dependencies{})
+	if err != nil {
+		return nil, err
+	}
+	return apiSpec.Generate(router)
+}
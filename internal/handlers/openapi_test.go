@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPIDocument_MatchesRoutes falla si se agrega una ruta sin describirla en apiSpec o
+// si apiSpec describe una ruta que ya no existe
+func TestAPIDocument_MatchesRoutes(t *testing.T) {
+	doc, err := APIDocument()
+	require.NoError(t, err)
+
+	for path, operations := range doc.Paths {
+		for method, op := range operations {
+			assert.NotEmpty(t, op.Summary, "%s %s has no summary", method, path)
+		}
+	}
+	assert.Contains(t, doc.Components.Schemas, "CreateUserRequest")
+}
+
+func TestSetupRoutes_ServesOpenAPIDocument(t *testing.T) {
+	router, err := SetupRoutes(Dependencies{})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	assert.Contains(t, doc["paths"], "/api/v1/users/{id}")
+}
+
+func TestSetupRoutes_ValidatesRequestsAgainstDocument(t *testing.T) {
+	router, err := SetupRoutes(Dependencies{ValidateRequests: true})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/create", strings.NewReader(`{"email":"not-an-email"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "body.firebase_id is required")
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"it-user-service/internal/logger"
+	"it-user-service/internal/patch"
+	"it-user-service/internal/validator"
+)
+
+// errPatchResultInvalid indica que el recurso resultante de aplicar el patch no es válido
+var errPatchResultInvalid = errors.New("patched resource is invalid")
+
+// applyPatch aplica el patch del request (JSON Merge Patch o JSON Patch según el
+// Content-Type) sobre current, un puntero al modelo de patch con los valores actuales. El
+// resultado se valida completo y se retornan los campos que cambiaron
+func applyPatch(r *http.Request, current interface{}) ([]string, error) {
+	before, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not read body", patch.ErrInvalidPatch)
+	}
+
+	patched, err := patch.Apply(r.Header.Get("Content-Type"), before, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los miembros eliminados por el patch quedan con el valor cero del campo
+	target := reflect.ValueOf(current).Elem()
+	target.Set(reflect.Zero(target.Type()))
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(current); err != nil {
+		return nil, fmt.Errorf("%w: %v", patch.ErrInvalidPatch, err)
+	}
+	if err := validator.ValidateStruct(current); err != nil {
+		return nil, fmt.Errorf("%w: %v", errPatchResultInvalid, err)
+	}
+
+	after, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	return patch.ChangedFields(before, after)
+}
+
+func writePatchError(w http.ResponseWriter, r *http.Request, err error) {
+	log := logger.FromContext(r.Context())
+	log.WithError(err).Warn("Patch request rejected")
+
+	switch {
+	case errors.Is(err, patch.ErrUnsupportedContentType):
+		http.Error(w, "Unsupported patch content type, use "+patch.MergePatchContentType+" or "+patch.JSONPatchContentType, http.StatusUnsupportedMediaType)
+	case errors.Is(err, patch.ErrTestFailed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, patch.ErrInvalidPatch), errors.Is(err, errPatchResultInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.WithError(err).Error("Failed to apply patch")
+		http.Error(w, "Error applying patch", http.StatusInternalServerError)
+	}
+}
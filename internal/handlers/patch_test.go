@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"it-user-service/internal/models"
+)
+
+func TestApplyPatch_MergePatchClearsFieldsAndReportsChanges(t *testing.T) {
+	fields := models.NewProfilePatch(&models.UserProfile{Bio: "hello", Phone: "555-1234", Location: "Lima"})
+	req := httptest.NewRequest("PATCH", "/api/v1/users/1/profile", strings.NewReader(`{"bio":null,"phone":"","location":"Lima","website":"https://example.com"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	changed, err := applyPatch(req, &fields)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bio", "phone", "website"}, changed)
+	assert.Empty(t, fields.Bio)
+	assert.Empty(t, fields.Phone)
+	assert.Equal(t, "https://example.com", fields.Website)
+}
+
+func TestApplyPatch_ValidatesMergedResult(t *testing.T) {
+	fields := models.NewUserPatch(&models.User{Username: "alice", Status: "active"})
+
+	req := httptest.NewRequest("PATCH", "/api/v1/users/1", strings.NewReader(`{"username":null}`))
+	_, err := applyPatch(req, &fields)
+	assert.True(t, errors.Is(err, errPatchResultInvalid))
+
+	req = httptest.NewRequest("PATCH", "/api/v1/users/1", strings.NewReader(`{"id":"other"}`))
+	_, err = applyPatch(req, &fields)
+	assert.Error(t, err)
+}
@@ -9,6 +9,7 @@ import (
 	"it-user-service/internal/logger"
 	"it-user-service/internal/models"
 	"it-user-service/internal/repositories"
+	"it-user-service/internal/schemas"
 	"it-user-service/internal/validator"
 )
 
@@ -24,7 +25,7 @@ func NewProfileHandler(profileRepo repositories.ProfileRepositoryInterface) *Pro
 
 // GetUserProfile maneja GET /users/{id}/profile
 func (h *ProfileHandler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	id := vars["id"]
 	
@@ -35,25 +36,36 @@ func (h *ProfileHandler) GetUserProfile(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	profile, err := h.profileRepo.GetByUserID(id)
+	// Otros usuarios ven el perfil público (GET /profiles/{user})
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden access to user profile")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	profile, err := h.profileRepo.GetByUserID(r.Context(), id)
 	if err != nil {
 		log.WithError(err).WithField("user_id", id).Error("Failed to fetch user profile")
 		http.Error(w, "Profile not found", http.StatusNotFound)
 		return
 	}
+	if notModified(w, r, profile.Version) {
+		return
+	}
 
 	log.WithField("user_id", id).Info("User profile retrieved successfully")
 	
+	setETag(w, profile.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"data":    profile,
+		"data":    schemas.ProfileWithDefaults(profile),
 		"message": "Profile retrieved successfully",
 	})
 }
 
 // UpdateUserProfile maneja PUT /users/{id}/profile
 func (h *ProfileHandler) UpdateUserProfile(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	id := vars["id"]
 	
@@ -64,6 +76,12 @@ func (h *ProfileHandler) UpdateUserProfile(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden profile update")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	var req models.UpdateProfileRequest
 
 	body, err := io.ReadAll(r.Body)
@@ -87,13 +105,16 @@ func (h *ProfileHandler) UpdateUserProfile(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Obtener perfil existente o crear uno nuevo
-	profile, err := h.profileRepo.GetByUserID(id)
+	profile, err := h.profileRepo.GetByUserID(r.Context(), id)
 	if err != nil {
 		// Si no existe, crear uno nuevo
 		profile = &models.UserProfile{
 			UserID: id,
 		}
 	}
+	if !checkIfMatch(w, r, profile.Version, profile.ID != 0) {
+		return
+	}
 
 	// Actualizar campos
 	if req.Avatar != "" {
@@ -117,21 +138,31 @@ func (h *ProfileHandler) UpdateUserProfile(w http.ResponseWriter, r *http.Reques
 	if req.Phone != "" {
 		profile.Phone = req.Phone
 	}
-	if req.Preferences != "" {
+	if req.Preferences != nil {
 		profile.Preferences = req.Preferences
 	}
-	if req.Privacy != "" {
+	if req.Privacy != nil {
 		profile.Privacy = req.Privacy
 	}
 
+	// Validar los documentos contra sus schemas
+	if err := schemas.PrepareProfile(profile); err != nil {
+		log.WithError(err).Warn("Profile documents do not match their schemas")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Guardar cambios
 	if profile.ID == 0 {
-		err = h.profileRepo.Create(profile)
+		err = h.profileRepo.Create(r.Context(), profile)
 	} else {
-		err = h.profileRepo.Update(profile)
+		err = h.profileRepo.Update(r.Context(), profile)
 	}
 
 	if err != nil {
+		if writeVersionConflict(w, r, err) {
+			return
+		}
 		log.WithError(err).WithField("user_id", id).Error("Failed to update profile")
 		http.Error(w, "Error updating profile", http.StatusInternalServerError)
 		return
@@ -139,16 +170,89 @@ func (h *ProfileHandler) UpdateUserProfile(w http.ResponseWriter, r *http.Reques
 
 	log.WithField("user_id", id).Info("Profile updated successfully")
 	
+	setETag(w, profile.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"data":    profile,
+		"data":    schemas.ProfileWithDefaults(profile),
 		"message": "Profile updated successfully",
 	})
 }
 
+// PatchUserProfile maneja PATCH /users/{id}/profile con JSON Merge Patch o JSON Patch
+func (h *ProfileHandler) PatchUserProfile(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden profile update")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Obtener perfil existente o crear uno nuevo
+	profile, err := h.profileRepo.GetByUserID(r.Context(), id)
+	if err != nil {
+		profile = &models.UserProfile{
+			UserID: id,
+		}
+	}
+	if !checkIfMatch(w, r, profile.Version, profile.ID != 0) {
+		return
+	}
+
+	// Aplicar el patch sobre los valores actuales y validar el resultado
+	fields := models.NewProfilePatch(profile)
+	changed, err := applyPatch(r, &fields)
+	if err != nil {
+		writePatchError(w, r, err)
+		return
+	}
+
+	// Guardar cambios solo si el patch modificó algún campo
+	if len(changed) > 0 {
+		fields.ApplyTo(profile)
+		if err := schemas.PrepareProfile(profile); err != nil {
+			log.WithError(err).Warn("Profile documents do not match their schemas")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if profile.ID == 0 {
+			err = h.profileRepo.Create(r.Context(), profile)
+		} else {
+			err = h.profileRepo.Update(r.Context(), profile)
+		}
+		if err != nil {
+			if writeVersionConflict(w, r, err) {
+				return
+			}
+			log.WithError(err).WithField("user_id", id).Error("Failed to patch profile")
+			http.Error(w, "Error updating profile", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	log.WithField("user_id", id).WithField("changed_fields", changed).Info("Profile patched successfully")
+
+	setETag(w, profile.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":           schemas.ProfileWithDefaults(profile),
+		"changed_fields": changed,
+		"message":        "Profile updated successfully",
+	})
+}
+
 // GetUserSettings maneja GET /users/{id}/settings
 func (h *ProfileHandler) GetUserSettings(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	id := vars["id"]
 	
@@ -159,25 +263,35 @@ func (h *ProfileHandler) GetUserSettings(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	settings, err := h.profileRepo.GetSettingsByUserID(id)
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden access to user settings")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	settings, err := h.profileRepo.GetSettingsByUserID(r.Context(), id)
 	if err != nil {
 		log.WithError(err).WithField("user_id", id).Error("Failed to fetch user settings")
 		http.Error(w, "Settings not found", http.StatusNotFound)
 		return
 	}
+	if notModified(w, r, settings.Version) {
+		return
+	}
 
 	log.WithField("user_id", id).Info("User settings retrieved successfully")
 	
+	setETag(w, settings.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"data":    settings,
+		"data":    schemas.SettingsWithDefaults(settings),
 		"message": "Settings retrieved successfully",
 	})
 }
 
 // UpdateUserSettings maneja PUT /users/{id}/settings
 func (h *ProfileHandler) UpdateUserSettings(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	id := vars["id"]
 	
@@ -188,6 +302,12 @@ func (h *ProfileHandler) UpdateUserSettings(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden settings update")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	var req models.UpdateSettingsRequest
 
 	body, err := io.ReadAll(r.Body)
@@ -211,7 +331,7 @@ func (h *ProfileHandler) UpdateUserSettings(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Obtener configuraciones existentes o crear nuevas
-	settings, err := h.profileRepo.GetSettingsByUserID(id)
+	settings, err := h.profileRepo.GetSettingsByUserID(r.Context(), id)
 	if err != nil {
 		// Si no existe, crear nuevas
 		settings = &models.UserSettings{
@@ -221,6 +341,9 @@ func (h *ProfileHandler) UpdateUserSettings(w http.ResponseWriter, r *http.Reque
 			Theme:    "light",
 		}
 	}
+	if !checkIfMatch(w, r, settings.Version, settings.ID != 0) {
+		return
+	}
 
 	// Actualizar campos
 	if req.Language != "" {
@@ -232,24 +355,34 @@ func (h *ProfileHandler) UpdateUserSettings(w http.ResponseWriter, r *http.Reque
 	if req.Theme != "" {
 		settings.Theme = req.Theme
 	}
-	if req.Notifications != "" {
+	if req.Notifications != nil {
 		settings.Notifications = req.Notifications
 	}
-	if req.Privacy != "" {
+	if req.Privacy != nil {
 		settings.Privacy = req.Privacy
 	}
-	if req.Security != "" {
+	if req.Security != nil {
 		settings.Security = req.Security
 	}
 
+	// Validar los documentos contra sus schemas
+	if err := schemas.PrepareSettings(settings); err != nil {
+		log.WithError(err).Warn("Settings documents do not match their schemas")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Guardar cambios
 	if settings.ID == 0 {
-		err = h.profileRepo.CreateSettings(settings)
+		err = h.profileRepo.CreateSettings(r.Context(), settings)
 	} else {
-		err = h.profileRepo.UpdateSettings(settings)
+		err = h.profileRepo.UpdateSettings(r.Context(), settings)
 	}
 
 	if err != nil {
+		if writeVersionConflict(w, r, err) {
+			return
+		}
 		log.WithError(err).WithField("user_id", id).Error("Failed to update settings")
 		http.Error(w, "Error updating settings", http.StatusInternalServerError)
 		return
@@ -257,16 +390,92 @@ func (h *ProfileHandler) UpdateUserSettings(w http.ResponseWriter, r *http.Reque
 
 	log.WithField("user_id", id).Info("Settings updated successfully")
 	
+	setETag(w, settings.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"data":    settings,
+		"data":    schemas.SettingsWithDefaults(settings),
 		"message": "Settings updated successfully",
 	})
 }
 
+// PatchUserSettings maneja PATCH /users/{id}/settings con JSON Merge Patch o JSON Patch
+func (h *ProfileHandler) PatchUserSettings(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden settings update")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Obtener configuraciones existentes o crear nuevas
+	settings, err := h.profileRepo.GetSettingsByUserID(r.Context(), id)
+	if err != nil {
+		settings = &models.UserSettings{
+			UserID:   id,
+			Language: "en",
+			Timezone: "UTC",
+			Theme:    "light",
+		}
+	}
+	if !checkIfMatch(w, r, settings.Version, settings.ID != 0) {
+		return
+	}
+
+	// Aplicar el patch sobre los valores actuales y validar el resultado
+	fields := models.NewSettingsPatch(settings)
+	changed, err := applyPatch(r, &fields)
+	if err != nil {
+		writePatchError(w, r, err)
+		return
+	}
+
+	// Guardar cambios solo si el patch modificó algún campo
+	if len(changed) > 0 {
+		fields.ApplyTo(settings)
+		if err := schemas.PrepareSettings(settings); err != nil {
+			log.WithError(err).Warn("Settings documents do not match their schemas")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if settings.ID == 0 {
+			err = h.profileRepo.CreateSettings(r.Context(), settings)
+		} else {
+			err = h.profileRepo.UpdateSettings(r.Context(), settings)
+		}
+		if err != nil {
+			if writeVersionConflict(w, r, err) {
+				return
+			}
+			log.WithError(err).WithField("user_id", id).Error("Failed to patch settings")
+			http.Error(w, "Error updating settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	log.WithField("user_id", id).WithField("changed_fields", changed).Info("Settings patched successfully")
+
+	setETag(w, settings.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":           schemas.SettingsWithDefaults(settings),
+		"changed_fields": changed,
+		"message":        "Settings updated successfully",
+	})
+}
+
 // GetUserStats maneja GET /users/{id}/stats
 func (h *ProfileHandler) GetUserStats(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	id := vars["id"]
 	
@@ -277,7 +486,7 @@ func (h *ProfileHandler) GetUserStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats, err := h.profileRepo.GetStatsByUserID(id)
+	stats, err := h.profileRepo.GetStatsByUserID(r.Context(), id)
 	if err != nil {
 		log.WithError(err).WithField("user_id", id).Error("Failed to fetch user stats")
 		http.Error(w, "Stats not found", http.StatusNotFound)
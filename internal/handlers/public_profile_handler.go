@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/middleware"
+	"it-user-service/internal/services"
+)
+
+type PublicProfileHandler struct {
+	publicProfiles *services.PublicProfileService
+	trustedProxies []*net.IPNet
+}
+
+func NewPublicProfileHandler(publicProfiles *services.PublicProfileService, trustedProxies []*net.IPNet) *PublicProfileHandler {
+	return &PublicProfileHandler{
+		publicProfiles: publicProfiles,
+		trustedProxies: trustedProxies,
+	}
+}
+
+// GetPublicProfile maneja GET /profiles/{user}, donde user es el ID o el username
+func (h *PublicProfileHandler) GetPublicProfile(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	ref := mux.Vars(r)["user"]
+
+	if h.publicProfiles == nil {
+		http.Error(w, "Public profiles are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Los anónimos se identifican por IP para contar sus vistas
+	viewer := services.Viewer{Key: "ip:" + middleware.ClientIP(r, h.trustedProxies)}
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		viewer.UserID = principal.UserID
+		viewer.Privileged = principal.HasRole(auth.RoleAdmin) || principal.IsAPIKey()
+		viewer.Key = principal.Subject()
+	}
+
+	profile, err := h.publicProfiles.Get(r.Context(), ref, viewer)
+	if err != nil {
+		if errors.Is(err, services.ErrProfileNotFound) {
+			http.Error(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).WithField("user", ref).Error("Failed to fetch public profile")
+		http.Error(w, "Error fetching profile", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id":      profile.UserID,
+		"relationship": profile.Relationship,
+	}).Info("Public profile retrieved successfully")
+
+	// Los campos visibles dependen de quién pide el perfil
+	w.Header().Set("Vary", "Authorization, "+middleware.APIKeyHeader)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    profile,
+		"message": "Profile retrieved successfully",
+	})
+}
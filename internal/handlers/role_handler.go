@@ -7,6 +7,8 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/events"
 	"it-user-service/internal/logger"
 	"it-user-service/internal/models"
 	"it-user-service/internal/repositories"
@@ -14,20 +16,22 @@ import (
 )
 
 type RoleHandler struct {
-	roleRepo repositories.RoleRepositoryInterface
+	roleRepo  repositories.RoleRepositoryInterface
+	publisher events.Publisher
 }
 
-func NewRoleHandler(roleRepo repositories.RoleRepositoryInterface) *RoleHandler {
+func NewRoleHandler(roleRepo repositories.RoleRepositoryInterface, publisher events.Publisher) *RoleHandler {
 	return &RoleHandler{
-		roleRepo: roleRepo,
+		roleRepo:  roleRepo,
+		publisher: publisher,
 	}
 }
 
 // GetAllRoles maneja GET /roles
 func (h *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	
-	roles, err := h.roleRepo.GetAllRoles()
+	roles, err := h.roleRepo.GetAllRoles(r.Context())
 	if err != nil {
 		log.WithError(err).Error("Failed to fetch roles")
 		http.Error(w, "Error fetching roles", http.StatusInternalServerError)
@@ -46,7 +50,7 @@ func (h *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
 
 // GetRoleByID maneja GET /roles/{id}
 func (h *RoleHandler) GetRoleByID(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := strconv.Atoi(idStr)
@@ -56,15 +60,19 @@ func (h *RoleHandler) GetRoleByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	role, err := h.roleRepo.GetRoleByID(uint(id))
+	role, err := h.roleRepo.GetRoleByID(r.Context(), uint(id))
 	if err != nil {
 		log.WithError(err).WithField("role_id", id).Error("Failed to fetch role")
 		http.Error(w, "Role not found", http.StatusNotFound)
 		return
 	}
+	if notModified(w, r, role.Version) {
+		return
+	}
 
 	log.WithField("role_id", id).Info("Role retrieved successfully")
 	
+	setETag(w, role.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    role,
@@ -74,7 +82,14 @@ func (h *RoleHandler) GetRoleByID(w http.ResponseWriter, r *http.Request) {
 
 // CreateRole maneja POST /roles
 func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
+
+	if !canManageRoles(r) {
+		log.Warn("Forbidden role creation")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	var req models.CreateRoleRequest
 
 	body, err := io.ReadAll(r.Body)
@@ -104,7 +119,7 @@ func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 		Active:      true,
 	}
 
-	if err := h.roleRepo.CreateRole(role); err != nil {
+	if err := h.roleRepo.CreateRole(r.Context(), role); err != nil {
 		log.WithError(err).Error("Failed to create role")
 		http.Error(w, "Error creating role", http.StatusInternalServerError)
 		return
@@ -112,6 +127,7 @@ func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 
 	log.WithField("role_id", role.ID).Info("Role created successfully")
 	
+	setETag(w, role.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -122,7 +138,7 @@ func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 
 // UpdateRole maneja PUT /roles/{id}
 func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := strconv.Atoi(idStr)
@@ -132,6 +148,12 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !canManageRoles(r) {
+		log.WithField("role_id", id).Warn("Forbidden role update")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	var req models.UpdateRoleRequest
 
 	body, err := io.ReadAll(r.Body)
@@ -155,12 +177,15 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Obtener rol existente
-	role, err := h.roleRepo.GetRoleByID(uint(id))
+	role, err := h.roleRepo.GetRoleByID(r.Context(), uint(id))
 	if err != nil {
 		log.WithError(err).WithField("role_id", id).Error("Role not found for update")
 		http.Error(w, "Role not found", http.StatusNotFound)
 		return
 	}
+	if !checkIfMatch(w, r, role.Version, true) {
+		return
+	}
 
 	// Actualizar campos
 	if req.Name != "" {
@@ -174,7 +199,10 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Guardar cambios
-	if err := h.roleRepo.UpdateRole(role); err != nil {
+	if err := h.roleRepo.UpdateRole(r.Context(), role); err != nil {
+		if writeVersionConflict(w, r, err) {
+			return
+		}
 		log.WithError(err).WithField("role_id", id).Error("Failed to update role")
 		http.Error(w, "Error updating role", http.StatusInternalServerError)
 		return
@@ -182,6 +210,7 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 
 	log.WithField("role_id", id).Info("Role updated successfully")
 	
+	setETag(w, role.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    role,
@@ -191,7 +220,7 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 
 // DeleteRole maneja DELETE /roles/{id}
 func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := strconv.Atoi(idStr)
@@ -201,16 +230,25 @@ func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !canManageRoles(r) {
+		log.WithField("role_id", id).Warn("Forbidden role deletion")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	// Verificar que el rol existe
-	_, err = h.roleRepo.GetRoleByID(uint(id))
+	role, err := h.roleRepo.GetRoleByID(r.Context(), uint(id))
 	if err != nil {
 		log.WithError(err).WithField("role_id", id).Error("Role not found for deletion")
 		http.Error(w, "Role not found", http.StatusNotFound)
 		return
 	}
+	if !checkIfMatch(w, r, role.Version, true) {
+		return
+	}
 
 	// Eliminar rol
-	if err := h.roleRepo.DeleteRole(uint(id)); err != nil {
+	if err := h.roleRepo.DeleteRole(r.Context(), uint(id)); err != nil {
 		log.WithError(err).WithField("role_id", id).Error("Failed to delete role")
 		http.Error(w, "Error deleting role", http.StatusInternalServerError)
 		return
@@ -226,7 +264,7 @@ func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
 
 // AssignRoleToUser maneja POST /users/{user_id}/roles
 func (h *RoleHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	userID := vars["user_id"]
 	
@@ -237,6 +275,12 @@ func (h *RoleHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !canManageRoles(r) {
+		log.WithField("user_id", userID).Warn("Forbidden role assignment")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	var req models.AssignRoleRequest
 
 	body, err := io.ReadAll(r.Body)
@@ -260,7 +304,7 @@ func (h *RoleHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Asignar rol al usuario
-	if err := h.roleRepo.AssignRoleToUser(userID, req.RoleName); err != nil {
+	if err := h.roleRepo.AssignRoleToUser(r.Context(), userID, req.RoleName); err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"user_id": userID,
 			"role":    req.RoleName,
@@ -273,6 +317,9 @@ func (h *RoleHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
 		"user_id": userID,
 		"role":    req.RoleName,
 	}).Info("Role assigned to user successfully")
+	publishEvent(r, h.publisher, events.New(events.TypeUserRoleGranted, userID, map[string]interface{}{
+		"role": req.RoleName,
+	}))
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -282,7 +329,7 @@ func (h *RoleHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
 
 // RemoveRoleFromUser maneja DELETE /users/{user_id}/roles/{role_name}
 func (h *RoleHandler) RemoveRoleFromUser(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	userID := vars["user_id"]
 	roleName := vars["role_name"]
@@ -300,8 +347,14 @@ func (h *RoleHandler) RemoveRoleFromUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !canManageRoles(r) {
+		log.WithField("user_id", userID).Warn("Forbidden role removal")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	// Remover rol del usuario
-	if err := h.roleRepo.RemoveRoleFromUser(userID, roleName); err != nil {
+	if err := h.roleRepo.RemoveRoleFromUser(r.Context(), userID, roleName); err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"user_id": userID,
 			"role":    roleName,
@@ -323,7 +376,7 @@ func (h *RoleHandler) RemoveRoleFromUser(w http.ResponseWriter, r *http.Request)
 
 // GetUserRoles maneja GET /users/{user_id}/roles
 func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	userID := vars["user_id"]
 	
@@ -334,7 +387,7 @@ func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	roles, err := h.roleRepo.GetUserRoles(userID)
+	roles, err := h.roleRepo.GetUserRoles(r.Context(), userID)
 	if err != nil {
 		log.WithError(err).WithField("user_id", userID).Error("Failed to fetch user roles")
 		http.Error(w, "Error fetching user roles", http.StatusInternalServerError)
@@ -354,3 +407,16 @@ func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// canManageRoles indica si el principal puede crear, modificar y asignar roles: un
+// administrador o una API key con el scope roles:write (los requests anónimos se rechazan).
+// Los roles se copian a los tokens, así que nadie se los asigna a sí mismo
+func canManageRoles(r *http.Request) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return !auth.IsAnonymous(r.Context())
+	}
+	if principal.IsAPIKey() {
+		return principal.HasScope(models.ScopeRolesWrite)
+	}
+	return principal.HasRole(auth.RoleAdmin)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+type fakeRoleRepo struct {
+	repositories.RoleRepositoryInterface
+	assigned map[string]string
+}
+
+func (r *fakeRoleRepo) GetRoleByID(ctx context.Context, id uint) (*models.Role, error) {
+	return &models.Role{ID: id, Name: "editor", Version: 1}, nil
+}
+
+func (r *fakeRoleRepo) CreateRole(ctx context.Context, role *models.Role) error {
+	return nil
+}
+
+func (r *fakeRoleRepo) UpdateRole(ctx context.Context, role *models.Role) error {
+	return nil
+}
+
+func (r *fakeRoleRepo) DeleteRole(ctx context.Context, id uint) error {
+	return nil
+}
+
+func (r *fakeRoleRepo) AssignRoleToUser(ctx context.Context, userID string, roleName string) error {
+	r.assigned[userID] = roleName
+	return nil
+}
+
+func (r *fakeRoleRepo) RemoveRoleFromUser(ctx context.Context, userID string, roleName string) error {
+	delete(r.assigned, userID)
+	return nil
+}
+
+func TestRoleHandler_RequiresAdminToManageRoles(t *testing.T) {
+	repo := &fakeRoleRepo{assigned: map[string]string{}}
+	handler := NewRoleHandler(repo, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/roles", handler.CreateRole).Methods("POST")
+	router.HandleFunc("/roles/{id}", handler.UpdateRole).Methods("PUT")
+	router.HandleFunc("/roles/{id}", handler.DeleteRole).Methods("DELETE")
+	router.HandleFunc("/users/{user_id}/roles", handler.AssignRoleToUser).Methods("POST")
+	router.HandleFunc("/users/{user_id}/roles/{role_name}", handler.RemoveRoleFromUser).Methods("DELETE")
+
+	serve := func(principal *auth.Principal, method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Un usuario no puede darse el rol admin a sí mismo ni administrar roles
+	user := &auth.Principal{UserID: "u1"}
+	assert.Equal(t, http.StatusForbidden, serve(user, http.MethodPost, "/users/u1/roles", `{"role_name": "admin"}`))
+	assert.Equal(t, http.StatusForbidden, serve(user, http.MethodDelete, "/users/u2/roles/admin", ""))
+	assert.Equal(t, http.StatusForbidden, serve(user, http.MethodPost, "/roles", `{"name": "superuser"}`))
+	assert.Equal(t, http.StatusForbidden, serve(user, http.MethodPut, "/roles/1", `{"name": "admin"}`))
+	assert.Equal(t, http.StatusForbidden, serve(user, http.MethodDelete, "/roles/1", ""))
+	assert.Empty(t, repo.assigned)
+
+	readOnlyKey := &auth.Principal{APIKeyID: "k1", Scopes: []string{models.ScopeRolesRead}}
+	assert.Equal(t, http.StatusForbidden, serve(readOnlyKey, http.MethodPost, "/users/u1/roles", `{"role_name": "admin"}`))
+
+	userID := "6f1c3a52-8a3e-4d4e-9f43-2b7f1c0d9a11"
+	admin := &auth.Principal{UserID: "a1", Roles: []string{auth.RoleAdmin}}
+	assert.Equal(t, http.StatusOK, serve(admin, http.MethodPost, "/users/"+userID+"/roles", `{"user_id": "`+userID+`", "role_name": "admin"}`))
+	assert.Equal(t, "admin", repo.assigned[userID])
+
+	writeKey := &auth.Principal{APIKeyID: "k2", Scopes: []string{models.ScopeRolesWrite}}
+	assert.Equal(t, http.StatusOK, serve(writeKey, http.MethodDelete, "/users/"+userID+"/roles/admin", ""))
+	assert.Empty(t, repo.assigned)
+}
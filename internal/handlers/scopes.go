@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"it-user-service/internal/models"
+)
+
+// requiredScope determina el scope que necesita una API key según la ruta del request:
+// lectura para GET y escritura para el resto. Las rutas /admin y /auth no son
+// accesibles con API keys
+func requiredScope(r *http.Request) string {
+	path := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			path = template
+		}
+	}
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	switch {
+	case strings.Contains(path, "/admin/"), strings.Contains(path, "/auth/"):
+		return ""
+	case strings.Contains(path, "/roles"):
+		return pickScope(read, models.ScopeRolesRead, models.ScopeRolesWrite)
+	case strings.Contains(path, "/sessions"), strings.Contains(path, "/login"):
+		return pickScope(read, models.ScopeSessionsRead, models.ScopeSessionsWrite)
+	case strings.Contains(path, "/users"):
+		return pickScope(read, models.ScopeUsersRead, models.ScopeUsersWrite)
+	default:
+		return ""
+	}
+}
+
+func pickScope(read bool, readScope, writeScope string) string {
+	if read {
+		return readScope
+	}
+	return writeScope
+}
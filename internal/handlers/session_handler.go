@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/services"
+)
+
+type SessionHandler struct {
+	sessionService *services.SessionService
+}
+
+func NewSessionHandler(sessionService *services.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// GetUserSessions maneja GET /users/{id}/sessions
+func (h *SessionHandler) GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden access to user sessions")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	includeRevoked := r.URL.Query().Get("include_revoked") == "true"
+	sessions, err := h.sessionService.ListSessions(r.Context(), id, includeRevoked)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("Failed to fetch user sessions")
+		http.Error(w, "Error fetching sessions", http.StatusInternalServerError)
+		return
+	}
+
+	currentSessionID := ""
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		currentSessionID = principal.SessionID
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": id,
+		"count":   len(sessions),
+	}).Info("User sessions retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":               sessions,
+		"count":              len(sessions),
+		"current_session_id": currentSessionID,
+		"message":            "Sessions retrieved successfully",
+	})
+}
+
+// RevokeUserSession maneja DELETE /users/{id}/sessions/{sid}
+func (h *SessionHandler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+	sessionID := vars["sid"]
+
+	// Validar que los IDs no estén vacíos
+	if id == "" || sessionID == "" {
+		log.Warn("Empty user or session ID provided")
+		http.Error(w, "Invalid user or session ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden session revocation")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(r.Context(), id, sessionID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.WithFields(map[string]interface{}{
+				"user_id":    id,
+				"session_id": sessionID,
+			}).Warn("Session not found for revocation")
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).WithField("session_id", sessionID).Error("Failed to revoke session")
+		http.Error(w, "Error revoking session", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id":    id,
+		"session_id": sessionID,
+	}).Info("Session revoked successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Session revoked successfully",
+	})
+}
+
+// canAccessUser verifica que el principal autenticado pueda operar sobre userID (los
+// requests anónimos se rechazan)
+func canAccessUser(r *http.Request, userID string) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return !auth.IsAnonymous(r.Context())
+	}
+	return principal.CanAccessUser(userID)
+}
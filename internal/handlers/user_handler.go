@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"it-user-service/internal/events"
 	"it-user-service/internal/logger"
 	"it-user-service/internal/models"
 	"it-user-service/internal/repositories"
@@ -14,29 +15,20 @@ import (
 )
 
 type UserHandler struct {
-	userRepo repositories.UserRepositoryInterface
+	userRepo  repositories.UserRepositoryInterface
+	publisher events.Publisher
 }
 
-func NewUserHandler(userRepo repositories.UserRepositoryInterface) *UserHandler {
+func NewUserHandler(userRepo repositories.UserRepositoryInterface, publisher events.Publisher) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		publisher: publisher,
 	}
 }
 
-// HealthCheck maneja GET /health
-func (h *UserHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
-	
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "ok", "service": "user-service"}`))
-	
-	log.Info("Health check requested")
-}
-
 // GetAllUsers maneja GET /users
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	
 	// Parámetros de paginación
 	limit := 50 // default
@@ -54,7 +46,7 @@ func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
-	users, err := h.userRepo.GetAll(limit, offset)
+	users, err := h.userRepo.GetAll(r.Context(), limit, offset)
 	if err != nil {
 		log.WithError(err).Error("Failed to fetch users")
 		http.Error(w, "Error fetching users", http.StatusInternalServerError)
@@ -75,7 +67,7 @@ func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 
 // GetUserByID maneja GET /users/{id}
 func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	id := vars["id"]
 	
@@ -86,15 +78,19 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userRepo.GetByID(id)
+	user, err := h.userRepo.GetByID(r.Context(), id)
 	if err != nil {
 		log.WithError(err).WithField("user_id", id).Error("Failed to fetch user")
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
+	if notModified(w, r, user.Version) {
+		return
+	}
 
 	log.WithField("user_id", id).Info("User retrieved successfully")
 	
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    user,
@@ -104,7 +100,7 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 
 // CreateUser maneja POST /users/create
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	var req models.CreateUserRequest
 
 	body, err := io.ReadAll(r.Body)
@@ -120,6 +116,13 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// email_verified solo lo pueden establecer los admins y las API keys
+	if req.EmailVerified && !isTrustedCaller(r) {
+		log.Warn("Untrusted caller tried to create a user with a verified email")
+		http.Error(w, "Forbidden: email_verified can only be set by an admin", http.StatusForbidden)
+		return
+	}
+
 	// Establecer valores por defecto
 	if req.Status == "" {
 		req.Status = "active"
@@ -145,14 +148,19 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		Status:        req.Status,
 	}
 
-	if err := h.userRepo.Create(user); err != nil {
+	if err := h.userRepo.Create(r.Context(), user); err != nil {
 		log.WithError(err).Error("Failed to create user")
 		http.Error(w, "Error creating user", http.StatusInternalServerError)
 		return
 	}
 
 	log.WithField("user_id", user.ID).Info("User created successfully")
+	publishEvent(r, h.publisher, events.New(events.TypeUserCreated, user.ID, map[string]interface{}{
+		"status":   user.Status,
+		"provider": user.Provider,
+	}))
 	
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -163,7 +171,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 // UpdateUser maneja PUT /users/{id}
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	id := vars["id"]
 	
@@ -174,6 +182,12 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden user update")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	var req models.UpdateUserRequest
 
 	body, err := io.ReadAll(r.Body)
@@ -197,12 +211,20 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Obtener usuario existente
-	user, err := h.userRepo.GetByID(id)
+	user, err := h.userRepo.GetByID(r.Context(), id)
 	if err != nil {
 		log.WithError(err).WithField("user_id", id).Error("User not found for update")
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
+	if !checkIfMatch(w, r, user.Version, true) {
+		return
+	}
+	if req.EmailVerified != nil && *req.EmailVerified != user.EmailVerified && !isTrustedCaller(r) {
+		log.WithField("user_id", id).Warn("Untrusted caller tried to change email_verified")
+		http.Error(w, "Forbidden: email_verified can only be changed by an admin or by confirming an email change", http.StatusForbidden)
+		return
+	}
 
 	// Actualizar campos
 	if req.Username != "" {
@@ -214,18 +236,15 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	if req.LastName != "" {
 		user.LastName = req.LastName
 	}
-	if req.Status != "" {
-		user.Status = req.Status
-	}
 	if req.EmailVerified != nil {
 		user.EmailVerified = *req.EmailVerified
 	}
-	if req.Disabled != nil {
-		user.Disabled = *req.Disabled
-	}
 
 	// Guardar cambios
-	if err := h.userRepo.Update(user); err != nil {
+	if err := h.userRepo.Update(r.Context(), user); err != nil {
+		if writeVersionConflict(w, r, err) {
+			return
+		}
 		log.WithError(err).WithField("user_id", id).Error("Failed to update user")
 		http.Error(w, "Error updating user", http.StatusInternalServerError)
 		return
@@ -233,6 +252,7 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	log.WithField("user_id", id).Info("User updated successfully")
 	
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    user,
@@ -240,9 +260,77 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// PatchUser maneja PATCH /users/{id} con JSON Merge Patch (null vacía el campo) o JSON Patch
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden user update")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Obtener usuario existente
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("User not found for patch")
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, user.Version, true) {
+		return
+	}
+
+	// Aplicar el patch sobre los valores actuales y validar el resultado
+	fields := models.NewUserPatch(user)
+	changed, err := applyPatch(r, &fields)
+	if err != nil {
+		writePatchError(w, r, err)
+		return
+	}
+
+	if fields.EmailVerified != user.EmailVerified && !isTrustedCaller(r) {
+		log.WithField("user_id", id).Warn("Untrusted caller tried to change email_verified")
+		http.Error(w, "Forbidden: email_verified can only be changed by an admin or by confirming an email change", http.StatusForbidden)
+		return
+	}
+
+	// Guardar cambios solo si el patch modificó algún campo
+	if len(changed) > 0 {
+		fields.ApplyTo(user)
+		if err := h.userRepo.Update(r.Context(), user); err != nil {
+			if writeVersionConflict(w, r, err) {
+				return
+			}
+			log.WithError(err).WithField("user_id", id).Error("Failed to patch user")
+			http.Error(w, "Error updating user", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	log.WithField("user_id", id).WithField("changed_fields", changed).Info("User patched successfully")
+
+	setETag(w, user.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":           user,
+		"changed_fields": changed,
+		"message":        "User updated successfully",
+	})
+}
+
 // DeleteUser maneja DELETE /users/{id}
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	id := vars["id"]
 	
@@ -253,16 +341,25 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden user deletion")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	// Verificar que el usuario existe antes de eliminarlo
-	_, err := h.userRepo.GetByID(id)
+	user, err := h.userRepo.GetByID(r.Context(), id)
 	if err != nil {
 		log.WithError(err).WithField("user_id", id).Error("User not found for deletion")
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
+	if !checkIfMatch(w, r, user.Version, true) {
+		return
+	}
 
 	// Eliminar usuario
-	if err := h.userRepo.Delete(id); err != nil {
+	if err := h.userRepo.Delete(r.Context(), id); err != nil {
 		log.WithError(err).WithField("user_id", id).Error("Failed to delete user")
 		http.Error(w, "Error deleting user", http.StatusInternalServerError)
 		return
@@ -278,7 +375,7 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 // GetUserByFirebaseID maneja GET /users/firebase/{firebase_id}
 func (h *UserHandler) GetUserByFirebaseID(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	firebaseID := vars["firebase_id"]
 	if firebaseID == "" {
@@ -287,15 +384,19 @@ func (h *UserHandler) GetUserByFirebaseID(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	user, err := h.userRepo.GetByFirebaseID(firebaseID)
+	user, err := h.userRepo.GetByFirebaseID(r.Context(), firebaseID)
 	if err != nil {
 		log.WithError(err).WithField("firebase_id", firebaseID).Error("Failed to fetch user by Firebase ID")
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
+	if notModified(w, r, user.Version) {
+		return
+	}
 
 	log.WithField("firebase_id", firebaseID).Info("User retrieved successfully by Firebase ID")
 	
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    user,
@@ -305,7 +406,7 @@ func (h *UserHandler) GetUserByFirebaseID(w http.ResponseWriter, r *http.Request
 
 // GetUserByUsername maneja GET /users/username/{username}
 func (h *UserHandler) GetUserByUsername(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	username := vars["username"]
 	if username == "" {
@@ -314,7 +415,7 @@ func (h *UserHandler) GetUserByUsername(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user, err := h.userRepo.GetByUsername(username)
+	user, err := h.userRepo.GetByUsername(r.Context(), username)
 	if err != nil {
 		log.WithError(err).WithField("username", username).Error("Failed to fetch user by username")
 		http.Error(w, "User not found", http.StatusNotFound)
@@ -332,7 +433,7 @@ func (h *UserHandler) GetUserByUsername(w http.ResponseWriter, r *http.Request)
 
 // GetUserByEmail maneja GET /users/email/{email}
 func (h *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	email := vars["email"]
 	if email == "" {
@@ -341,7 +442,7 @@ func (h *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userRepo.GetByEmail(email)
+	user, err := h.userRepo.GetByEmail(r.Context(), email)
 	if err != nil {
 		log.WithError(err).WithField("email", email).Error("Failed to fetch user by email")
 		http.Error(w, "User not found", http.StatusNotFound)
@@ -359,7 +460,7 @@ func (h *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 
 // SearchUsers maneja GET /users/search
 func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	query := r.URL.Query().Get("q")
 	if query == "" {
 		log.Warn("Search query is required")
@@ -383,7 +484,7 @@ func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	users, err := h.userRepo.SearchUsers(query, limit, offset)
+	users, err := h.userRepo.SearchUsers(r.Context(), query, limit, offset)
 	if err != nil {
 		log.WithError(err).WithField("query", query).Error("Failed to search users")
 		http.Error(w, "Error searching users", http.StatusInternalServerError)
@@ -408,9 +509,9 @@ func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 
 // CountUsers maneja GET /users/count
 func (h *UserHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	
-	count, err := h.userRepo.CountUsers()
+	count, err := h.userRepo.CountUsers(r.Context())
 	if err != nil {
 		log.WithError(err).Error("Failed to count users")
 		http.Error(w, "Error counting users", http.StatusInternalServerError)
@@ -428,9 +529,9 @@ func (h *UserHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
 
 // GetActiveUsers maneja GET /users/active
 func (h *UserHandler) GetActiveUsers(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	
-	users, err := h.userRepo.GetActiveUsers()
+	users, err := h.userRepo.GetActiveUsers(r.Context())
 	if err != nil {
 		log.WithError(err).Error("Failed to fetch active users")
 		http.Error(w, "Error fetching active users", http.StatusInternalServerError)
@@ -447,62 +548,9 @@ func (h *UserHandler) GetActiveUsers(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// UpdateLoginInfo maneja POST /users/{id}/login
-func (h *UserHandler) UpdateLoginInfo(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
-	vars := mux.Vars(r)
-	id := vars["id"]
-	
-	// Validar que el ID no esté vacío
-	if id == "" {
-		log.Warn("Empty user ID provided for login update")
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
-		return
-	}
-
-	var req struct {
-		LoginIP     string `json:"login_ip" validate:"omitempty,ip"`
-		LoginDevice string `json:"login_device" validate:"max=255"`
-	}
-
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
-		return
-	}
-
-	if err := json.Unmarshal(body, &req); err != nil {
-		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
-		return
-	}
-
-	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
-		log.WithError(err).Warn("Validation failed for login info update")
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Actualizar información de login
-	if err := h.userRepo.UpdateLoginInfo(id, req.LoginIP, req.LoginDevice); err != nil {
-		log.WithError(err).WithField("user_id", id).Error("Failed to update login info")
-		http.Error(w, "Error updating login info", http.StatusInternalServerError)
-		return
-	}
-
-	log.WithField("user_id", id).Info("Login info updated successfully")
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Login info updated successfully",
-	})
-}
-
 // GetUserProfile maneja GET /users/{id}/profile - Placeholder
 func (h *UserHandler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	
@@ -517,7 +565,7 @@ func (h *UserHandler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
 
 // GetUserSettings maneja GET /users/{id}/settings - Placeholder
 func (h *UserHandler) GetUserSettings(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	
@@ -532,7 +580,7 @@ func (h *UserHandler) GetUserSettings(w http.ResponseWriter, r *http.Request) {
 
 // GetUserStats maneja GET /users/{id}/stats - Placeholder
 func (h *UserHandler) GetUserStats(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
+	log := logger.FromContext(r.Context())
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	
@@ -545,4 +593,15 @@ func (h *UserHandler) GetUserStats(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-
+// publishEvent publica un evento de dominio; un fallo solo se registra en el log
+func publishEvent(r *http.Request, publisher events.Publisher, event events.Event) {
+	if publisher == nil {
+		return
+	}
+	if err := publisher.Publish(r.Context(), event); err != nil {
+		logger.FromContext(r.Context()).WithError(err).WithFields(map[string]interface{}{
+			"user_id":    event.UserID,
+			"event_type": event.Type,
+		}).Error("Failed to emit domain event")
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+	"it-user-service/internal/services"
+	"it-user-service/internal/validator"
+)
+
+type UserStatusHandler struct {
+	userRepo      repositories.UserRepositoryInterface
+	statusService *services.UserStatusService
+}
+
+func NewUserStatusHandler(userRepo repositories.UserRepositoryInterface, statusService *services.UserStatusService) *UserStatusHandler {
+	return &UserStatusHandler{
+		userRepo:      userRepo,
+		statusService: statusService,
+	}
+}
+
+// ChangeUserStatus maneja POST /users/{id}/status
+func (h *UserStatusHandler) ChangeUserStatus(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ChangeUserStatusRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("Failed to read request body")
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.WithError(err).Error("Failed to unmarshal JSON")
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	// Validar estructura
+	if err := validator.ValidateStruct(&req); err != nil {
+		log.WithError(err).Warn("Validation failed for change status request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !canChangeStatus(r, id, req) {
+		log.WithField("user_id", id).Warn("Forbidden user status change")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Obtener usuario existente
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("User not found for status change")
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, user.Version, true) {
+		return
+	}
+
+	actor := ""
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		actor = principal.Subject()
+	}
+	from := user.Status
+	change, err := h.statusService.Transition(r.Context(), user, services.StatusTransition{
+		Status: req.Status,
+		Reason: req.Reason,
+		Note:   req.Note,
+		Until:  req.Until,
+		Actor:  actor,
+	})
+	if err != nil {
+		if writeVersionConflict(w, r, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrStatusTransitionNotAllowed):
+			log.WithFields(map[string]interface{}{
+				"user_id": id,
+				"from":    from,
+				"to":      req.Status,
+			}).Warn("Status transition not allowed")
+			http.Error(w, "Status transition from "+from+" to "+req.Status+" is not allowed", http.StatusConflict)
+		case errors.Is(err, services.ErrInvalidSuspensionUntil):
+			http.Error(w, "until is only allowed for suspended and must be in the future", http.StatusBadRequest)
+		default:
+			log.WithError(err).WithField("user_id", id).Error("Failed to change user status")
+			http.Error(w, "Error changing user status", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": id,
+		"from":    change.FromStatus,
+		"to":      change.ToStatus,
+		"reason":  change.Reason,
+	}).Info("User status changed successfully")
+
+	setETag(w, user.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    user,
+		"message": "User status changed successfully",
+	})
+}
+
+// GetUserStatusHistory maneja GET /users/{id}/status/history
+func (h *UserStatusHandler) GetUserStatusHistory(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Validar que el ID no esté vacío
+	if id == "" {
+		log.Warn("Empty user ID provided")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !canAccessUser(r, id) {
+		log.WithField("user_id", id).Warn("Forbidden access to user status history")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	limit, offset := loginPagination(r)
+
+	changes, err := h.statusService.History(r.Context(), id, limit, offset)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("Failed to fetch status history")
+		http.Error(w, "Error fetching status history", http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": id,
+		"count":   len(changes),
+	}).Info("Status history retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    changes,
+		"count":   len(changes),
+		"limit":   limit,
+		"offset":  offset,
+		"message": "Status history retrieved successfully",
+	})
+}
+
+// canChangeStatus indica si el principal puede aplicar el cambio de estado: los admins y las
+// API keys aplican cualquier cambio; un usuario solo puede desactivar o eliminar su propia
+// cuenta con el motivo user_request
+func canChangeStatus(r *http.Request, userID string, req models.ChangeUserStatusRequest) bool {
+	if isTrustedCaller(r) {
+		return true
+	}
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	return ok && principal.UserID == userID &&
+		req.Reason == models.StatusReasonUserRequest &&
+		(req.Status == models.StatusDeactivated || req.Status == models.StatusDeleted)
+}
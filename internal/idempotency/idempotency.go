@@ -0,0 +1,45 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound indica que la key no existe (o ya expiró)
+var ErrNotFound = errors.New("idempotency key not found")
+
+// Record es el estado de una Idempotency-Key: reservada mientras el request original se
+// procesa y con la respuesta guardada cuando termina
+type Record struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Store guarda las keys. MemoryStore sirve para una réplica; RedisStore comparte las keys
+// entre réplicas
+type Store interface {
+	// Reserve reserva key para el request con fingerprint. Si la key ya existe no la modifica
+	// y retorna el registro existente con reserved = false
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (existing *Record, reserved bool, err error)
+	// Get retorna el registro de key o ErrNotFound
+	Get(ctx context.Context, key string) (*Record, error)
+	// Complete guarda la respuesta del request que reservó key
+	Complete(ctx context.Context, key string, record Record, ttl time.Duration) error
+	// Release libera la reserva para que el request se pueda reintentar
+	Release(ctx context.Context, key string) error
+}
+
+// Fingerprint identifica el contenido de un request: método, path, query y cuerpo
+func Fingerprint(method, uri string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + uri + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
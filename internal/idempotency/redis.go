@@ -0,0 +1,74 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore comparte las keys entre réplicas usando Redis. La reserva es un SET NX, así
+// que solo una réplica procesa cada key
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Reserve reserva key si no existe
+func (s *RedisStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	data, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, false, err
+	}
+	reserved, err := s.client.SetNX(ctx, s.prefix+key, data, ttl).Result()
+	if err != nil || reserved {
+		return nil, reserved, err
+	}
+
+	existing, err := s.Get(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		// La key expiró entre el SET NX y el GET: se reintenta la reserva
+		return s.Reserve(ctx, key, fingerprint, ttl)
+	}
+	return existing, false, err
+}
+
+// Get retorna el registro de key
+func (s *RedisStore) Get(ctx context.Context, key string) (*Record, error) {
+	data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Complete guarda la respuesta de key
+func (s *RedisStore) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	record.Completed = true
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+key, data, ttl).Err()
+}
+
+// Release elimina la reserva de key
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
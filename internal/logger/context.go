@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type requestIDKey struct{}
+
+type scopeKey struct{}
+
+// scope guarda los campos de log de un request. Es mutable para que los middlewares
+// internos (por ejemplo la autenticación) agreguen campos que también ve el access log
+type scope struct {
+	mu     sync.RWMutex
+	fields logrus.Fields
+}
+
+// ContextWithRequestID agrega el request id al contexto y a sus campos de log
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return NewContext(ctx, logrus.Fields{"request_id": requestID})
+}
+
+// RequestIDFromContext retorna el request id del contexto o "" si no tiene
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// NewContext retorna un contexto cuyo logger (ver FromContext) incluye fields además de
+// los campos del contexto padre
+func NewContext(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := make(logrus.Fields, len(fields))
+	if parent, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		parent.mu.RLock()
+		for k, v := range parent.fields {
+			merged[k] = v
+		}
+		parent.mu.RUnlock()
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, scopeKey{}, &scope{fields: merged})
+}
+
+// AddField agrega un campo al logger del contexto. A diferencia de NewContext, el campo
+// también aparece en los logs de quienes crearon el contexto (el access log)
+func AddField(ctx context.Context, key string, value interface{}) {
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		s.mu.Lock()
+		s.fields[key] = value
+		s.mu.Unlock()
+	}
+}
+
+// FromContext retorna el logger del request con request_id, trace_id, route y subject.
+// Sin campos en el contexto retorna el logger global
+func FromContext(ctx context.Context) *logrus.Entry {
+	log := logrus.NewEntry(GetLogger())
+	if ctx == nil {
+		return log
+	}
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return log
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return log.WithFields(s.fields)
+}
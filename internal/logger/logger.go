@@ -15,19 +15,7 @@ func Init() {
 	Log.SetFormatter(&logrus.JSONFormatter{})
 	
 	// Configurar nivel de log desde variable de entorno
-	level := os.Getenv("LOG_LEVEL")
-	switch level {
-	case "debug":
-		Log.SetLevel(logrus.DebugLevel)
-	case "info":
-		Log.SetLevel(logrus.InfoLevel)
-	case "warn":
-		Log.SetLevel(logrus.WarnLevel)
-	case "error":
-		Log.SetLevel(logrus.ErrorLevel)
-	default:
-		Log.SetLevel(logrus.InfoLevel)
-	}
+	SetLevel(os.Getenv("LOG_LEVEL"))
 	
 	Log.SetOutput(os.Stdout)
 }
@@ -37,4 +25,21 @@ func GetLogger() *logrus.Logger {
 		Init()
 	}
 	return Log
-}
\ No newline at end of file
+}
+
+// SetLevel cambia el nivel de log (debug, info, warn o error; por defecto info)
+func SetLevel(level string) {
+	log := GetLogger()
+	switch level {
+	case "debug":
+		log.SetLevel(logrus.DebugLevel)
+	case "info":
+		log.SetLevel(logrus.InfoLevel)
+	case "warn":
+		log.SetLevel(logrus.WarnLevel)
+	case "error":
+		log.SetLevel(logrus.ErrorLevel)
+	default:
+		log.SetLevel(logrus.InfoLevel)
+	}
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log := GetLogger()
+	log.SetOutput(&buf)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stdout)
+		SetRedaction(RedactionPolicy{Mode: RedactNone})
+	})
+	return &buf
+}
+
+func decodeEntry(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	return entry
+}
+
+func TestFromContext_IncludesRequestFields(t *testing.T) {
+	buf := captureLog(t)
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	ctx = NewContext(ctx, logrus.Fields{"route": "/api/v1/users/{id}"})
+	AddField(ctx, "subject", "user:42")
+	FromContext(ctx).Info("Updated user")
+
+	entry := decodeEntry(t, buf)
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "/api/v1/users/{id}", entry["route"])
+	assert.Equal(t, "user:42", entry["subject"])
+	assert.Equal(t, "req-1", RequestIDFromContext(ctx))
+}
+
+func TestSetRedaction(t *testing.T) {
+	buf := captureLog(t)
+	fields := logrus.Fields{"email": "ana@example.com", "ip": "203.0.113.7", "user_id": "42"}
+
+	SetRedaction(RedactionPolicy{Mode: RedactMask, Fields: []string{"email", "ip"}})
+	GetLogger().WithFields(fields).Info("Login")
+	entry := decodeEntry(t, buf)
+	assert.Equal(t, "a***@example.com", entry["email"])
+	assert.Equal(t, "203.0.113.0", entry["ip"])
+	assert.Equal(t, "42", entry["user_id"])
+
+	buf.Reset()
+	SetRedaction(RedactionPolicy{Mode: RedactHash, Fields: []string{"email"}})
+	GetLogger().WithFields(fields).Info("Login")
+	entry = decodeEntry(t, buf)
+	assert.Equal(t, Redact(RedactHash, "ana@example.com"), entry["email"])
+	assert.NotContains(t, entry["email"], "ana")
+
+	buf.Reset()
+	SetRedaction(RedactionPolicy{Mode: RedactDrop, Fields: []string{"email", "ip"}})
+	GetLogger().WithFields(fields).Info("Login")
+	entry = decodeEntry(t, buf)
+	assert.NotContains(t, entry, "email")
+	assert.NotContains(t, entry, "ip")
+}
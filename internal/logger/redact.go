@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Modos de redacción de los campos con datos personales
+const (
+	// RedactNone deja los valores sin cambios
+	RedactNone = "none"
+	// RedactMask oculta parte del valor: a***@example.com, 203.0.113.0
+	RedactMask = "mask"
+	// RedactHash reemplaza el valor por un hash que permite correlacionar sin exponerlo
+	RedactHash = "hash"
+	// RedactDrop elimina el campo
+	RedactDrop = "drop"
+)
+
+// RedactionPolicy define qué campos de log tienen datos personales y cómo se ocultan
+type RedactionPolicy struct {
+	Mode   string
+	Fields []string
+}
+
+// SetRedaction aplica policy a todos los logs, reemplazando la política anterior
+func SetRedaction(policy RedactionPolicy) {
+	log := GetLogger()
+	hooks := make(logrus.LevelHooks)
+	if policy.Mode != "" && policy.Mode != RedactNone && len(policy.Fields) > 0 {
+		fields := make(map[string]bool, len(policy.Fields))
+		for _, field := range policy.Fields {
+			fields[strings.TrimSpace(field)] = true
+		}
+		hooks.Add(&redactionHook{mode: policy.Mode, fields: fields})
+	}
+	log.ReplaceHooks(hooks)
+}
+
+// redactionHook oculta los campos configurados antes de escribir cada entrada
+type redactionHook struct {
+	mode   string
+	fields map[string]bool
+}
+
+func (h *redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactionHook) Fire(entry *logrus.Entry) error {
+	for key, value := range entry.Data {
+		if !h.fields[key] {
+			continue
+		}
+		if h.mode == RedactDrop {
+			delete(entry.Data, key)
+			continue
+		}
+		entry.Data[key] = Redact(h.mode, fmt.Sprint(value))
+	}
+	return nil
+}
+
+// Redact oculta value según mode
+func Redact(mode, value string) string {
+	if value == "" {
+		return value
+	}
+	switch mode {
+	case RedactNone:
+		return value
+	case RedactHash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	case RedactMask:
+		return mask(value)
+	default:
+		return ""
+	}
+}
+
+// mask conserva el dominio de los emails y la red de las IPs (/24 en IPv4, /48 en IPv6)
+func mask(value string) string {
+	if local, domain, ok := strings.Cut(value, "@"); ok {
+		return local[:min(1, len(local))] + "***@" + domain
+	}
+	if ip := net.ParseIP(value); ip != nil {
+		if v4 := ip.To4(); v4 != nil {
+			return v4.Mask(net.CIDRMask(24, 32)).String()
+		}
+		return ip.Mask(net.CIDRMask(48, 128)).String()
+	}
+	return value[:1] + "***"
+}
@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"it-user-service/internal/logger"
+)
+
+// ErrInvalidHeader indica un destinatario o asunto con saltos de línea (inyección de headers)
+var ErrInvalidHeader = errors.New("mail: invalid header value")
+
+// Message es un email de texto plano
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+func (m Message) validate() error {
+	if strings.ContainsAny(m.To, "\r\n") || strings.ContainsAny(m.Subject, "\r\n") {
+		return ErrInvalidHeader
+	}
+	return nil
+}
+
+// Mailer envía emails. LogMailer sirve para desarrollo y tests; SMTPMailer envía por SMTP
+// (en desarrollo, a un mail-catcher local)
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer escribe los emails en el log en lugar de enviarlos
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Send registra el email en el log
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"email":   msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Body,
+	}).Info("Email not sent (log mailer)")
+	return nil
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"it-user-service/internal/logger"
+)
+
+// quietPaths son los endpoints consultados periódicamente por el orquestador y Prometheus;
+// su access log va en nivel debug
+var quietPaths = []string{"/api/v1/health", "/api/v1/ready", "/metrics"}
+
+// AccessLog loguea cada request con su estado, tamaño y latencia usando el logger del
+// request (ver RequestID). Los errores 5xx se loguean como error
+func AccessLog(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			entry := logger.FromContext(r.Context()).WithFields(map[string]interface{}{
+				"status":     recorder.status,
+				"bytes":      recorder.bytes,
+				"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+				"path":       r.URL.Path,
+				"ip":         ClientIP(r, trustedProxies),
+				"user_agent": r.UserAgent(),
+			})
+			switch {
+			case recorder.status >= http.StatusInternalServerError:
+				entry.Error("Request failed")
+			case isQuietPath(r.URL.Path):
+				entry.Debug("Request completed")
+			default:
+				entry.Info("Request completed")
+			}
+		})
+	}
+}
+
+func isQuietPath(path string) bool {
+	for _, quiet := range quietPaths {
+		if path == quiet || strings.HasPrefix(path, quiet+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// statusRecorder registra el estado y el tamaño de la respuesta
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap permite a http.ResponseController acceder al ResponseWriter original
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
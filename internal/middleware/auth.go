@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"it-user-service/internal/auth"
+	"it-user-service/internal/logger"
+)
+
+// TokenValidator valida un token bearer y retorna sus claims
+type TokenValidator interface {
+	ValidateToken(tokenString string) (*auth.Claims, error)
+}
+
+// APIKeyAuthenticator valida una API key de servicio presentada desde ip
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey, ip string) (*auth.Principal, error)
+}
+
+// APIKeyHeader es el header con el que los servicios envían su API key
+const APIKeyHeader = "X-API-Key"
+
+// AuthConfig configura el middleware de autenticación
+type AuthConfig struct {
+	Validator   TokenValidator
+	Revocations auth.RevocationList
+	APIKeys     APIKeyAuthenticator
+	// RequiredScope retorna el scope que necesita una API key para el request
+	// ("" = no accesible con API keys)
+	RequiredScope func(r *http.Request) string
+	// TrustedProxies son los proxies cuyo X-Forwarded-For se considera
+	TrustedProxies []*net.IPNet
+	// Required rechaza requests sin credenciales. Si es false, los requests sin
+	// header Authorization pasan sin principal (los tokens presentes siempre se validan)
+	Required bool
+	// PublicPaths no requieren credenciales aunque Required sea true
+	PublicPaths []string
+}
+
+// Enabled indica si hay algún mecanismo de credenciales configurado
+func (cfg AuthConfig) Enabled() bool {
+	return cfg.Validator != nil || cfg.APIKeys != nil
+}
+
+// Authenticate valida el token bearer o la API key del request, rechaza las sesiones
+// revocadas y agrega el principal autenticado al contexto
+func Authenticate(cfg AuthConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Los handlers rechazan los requests que sigan sin principal
+			r = r.WithContext(auth.WithAuthentication(r.Context()))
+
+			if rawKey := r.Header.Get(APIKeyHeader); rawKey != "" && cfg.APIKeys != nil {
+				authenticateAPIKey(cfg, rawKey, next, w, r)
+				return
+			}
+
+			// Sin validador configurado los tokens bearer se ignoran, como si no vinieran
+			header := r.Header.Get("Authorization")
+			if header == "" || cfg.Validator == nil {
+				if cfg.Required && !isPublicPath(r.URL.Path, cfg.PublicPaths) {
+					http.Error(w, "Authorization required", http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tokenString, err := auth.ExtractBearerToken(header)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := cfg.Validator.ValidateToken(tokenString)
+			if err != nil {
+				logger.FromContext(r.Context()).WithError(err).Warn("Invalid bearer token")
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			if cfg.Revocations != nil && cfg.Revocations.IsRevoked(r.Context(), claims.SessionID) {
+				logger.FromContext(r.Context()).WithField("session_id", claims.SessionID).Warn("Rejected token for revoked session")
+				http.Error(w, "Session revoked", http.StatusUnauthorized)
+				return
+			}
+
+			principal := &auth.Principal{
+				UserID:    claims.UserID,
+				Email:     claims.Email,
+				Roles:     claims.Roles,
+				SessionID: claims.SessionID,
+			}
+			next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// authenticateAPIKey valida la API key y que sus scopes permitan el request
+func authenticateAPIKey(cfg AuthConfig, rawKey string, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	ip := ClientIP(r, cfg.TrustedProxies)
+
+	principal, err := cfg.APIKeys.Authenticate(r.Context(), rawKey, ip)
+	if err != nil {
+		if errors.Is(err, auth.ErrRateLimited) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		log.WithError(err).WithField("ip", ip).Warn("Invalid API key")
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	if !isPublicPath(r.URL.Path, cfg.PublicPaths) {
+		scope := ""
+		if cfg.RequiredScope != nil {
+			scope = cfg.RequiredScope(r)
+		}
+		if scope == "" || !principal.HasScope(scope) {
+			log.WithFields(map[string]interface{}{
+				"api_key_id": principal.APIKeyID,
+				"scope":      scope,
+			}).Warn("API key missing required scope")
+			http.Error(w, "Insufficient scope", http.StatusForbidden)
+			return
+		}
+	}
+
+	next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), principal)))
+}
+
+// withPrincipal agrega el principal al contexto y su subject al logger del request
+func withPrincipal(ctx context.Context, principal *auth.Principal) context.Context {
+	logger.AddField(ctx, "subject", principal.Subject())
+	return auth.WithPrincipal(ctx, principal)
+}
+
+func isPublicPath(path string, publicPaths []string) bool {
+	for _, public := range publicPaths {
+		if path == public || (strings.HasSuffix(public, "/") && strings.HasPrefix(path, public)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"it-user-service/internal/auth"
+)
+
+func TestAuthenticateRejectsSessionRevokedByOtherReplica(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	jwtManager := auth.NewJWTManager("secret", "it-user-service")
+
+	// Cada réplica tiene su propia lista local; Redis es compartido
+	revokingReplica := auth.NewRedisRevocationList(auth.NewMemoryRevocationList(), client, "revoked_sessions:")
+	servingReplica := auth.NewRedisRevocationList(auth.NewMemoryRevocationList(), client, "revoked_sessions:")
+	handler := Authenticate(AuthConfig{Validator: jwtManager, Revocations: servingReplica})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+	token, _, err := jwtManager.GenerateSessionToken("u1", "u1@example.com", "s1", nil, time.Minute)
+	require.NoError(t, err)
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/u1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve())
+
+	revokingReplica.Revoke(context.Background(), "s1", time.Now().Add(time.Minute))
+	assert.Equal(t, http.StatusUnauthorized, serve())
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// MaxBodySize limita el tamaño del cuerpo de los requests. Los requests que declaran un
+// Content-Length mayor se rechazan con 413; el resto falla al leer más de limit bytes
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > limit {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"it-user-service/internal/auth"
+)
+
+// ClientIP obtiene la IP del cliente. X-Forwarded-For solo se considera cuando la
+// conexión viene de un proxy de confianza; se toma el último salto no confiable
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+	if len(trustedProxies) == 0 || !auth.IPInNetworks(remoteIP, trustedProxies) {
+		return remoteIP
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop != "" && !auth.IPInNetworks(hop, trustedProxies) {
+			return hop
+		}
+	}
+	return remoteIP
+}
@@ -1,82 +1,254 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
-	"os"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
 )
 
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-		environment := os.Getenv("ENVIRONMENT")
-		
-		// Configuración de CORS según el ambiente
-		if environment == "production" {
-			// En producción, solo permitir orígenes específicos
-			allowedOrigins := []string{
-				"https://innovatech.com",
-				"https://www.innovatech.com",
-				"https://app.innovatech.com",
-			}
-			
-			originAllowed := false
-			for _, allowed := range allowedOrigins {
-				if origin == allowed {
-					w.Header().Set("Access-Control-Allow-Origin", origin)
-					originAllowed = true
-					break
+// CORSConfig configura el middleware de CORS
+type CORSConfig struct {
+	// AllowedOrigins acepta orígenes exactos (https://app.example.com), subdominios con
+	// comodín (https://*.example.com), cualquier puerto (http://localhost:*) o * (todos)
+	AllowedOrigins []string
+	// AllowedMethods limita los métodos que se anuncian en el preflight; cada ruta
+	// anuncia solo los métodos que tiene registrados en Router
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders son los headers de la respuesta que el navegador deja leer
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge es el tiempo que el navegador puede cachear la respuesta del preflight
+	MaxAge time.Duration
+	// Router permite calcular los métodos de cada ruta; sin router se anuncian AllowedMethods
+	Router *mux.Router
+}
+
+// CORS responde los preflight y agrega los headers CORS a las respuestas de los orígenes
+// permitidos. Para los orígenes no permitidos no agrega headers y el navegador bloquea la
+// respuesta. Los preflight deben llegar al middleware: el router necesita una ruta OPTIONS
+// que acepte cualquier path
+func CORS(cfg CORSConfig) (func(http.Handler) http.Handler, error) {
+	origins := make([]originPattern, 0, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		origins = append(origins, pattern)
+	}
+	allowedMethods := make(map[string]bool, len(cfg.AllowedMethods))
+	for _, method := range cfg.AllowedMethods {
+		allowedMethods[strings.ToUpper(strings.TrimSpace(method))] = true
+	}
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	methods := &routeMethods{router: cfg.Router}
+
+	allowOrigin := func(origin string) (string, bool) {
+		for _, pattern := range origins {
+			if pattern.matches(origin) {
+				if pattern.any && !cfg.AllowCredentials {
+					return "*", true
 				}
+				return origin, true
 			}
-			
-			if !originAllowed && origin != "" {
-				// Origen no permitido en producción
-				w.WriteHeader(http.StatusForbidden)
+		}
+		return "", false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			requestMethod := r.Header.Get("Access-Control-Request-Method")
+			preflight := r.Method == http.MethodOptions && requestMethod != ""
+
+			if origin == "" {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
 				return
 			}
-		} else {
-			// En desarrollo/staging, permitir orígenes locales
-			if origin != "" {
-				if strings.HasPrefix(origin, "http://localhost:") || 
-				   strings.HasPrefix(origin, "http://127.0.0.1:") ||
-				   strings.HasPrefix(origin, "https://localhost:") || 
-				   strings.HasPrefix(origin, "https://127.0.0.1:") {
-					w.Header().Set("Access-Control-Allow-Origin", origin)
-				} else {
-					// Para otros orígenes en desarrollo, usar lista específica
-					devAllowedOrigins := []string{
-						"https://staging.innovatech.com",
-						"https://dev.innovatech.com",
+
+			w.Header().Add("Vary", "Origin")
+			allowed, ok := allowOrigin(origin)
+			if !preflight {
+				if ok {
+					w.Header().Set("Access-Control-Allow-Origin", allowed)
+					if cfg.AllowCredentials {
+						w.Header().Set("Access-Control-Allow-Credentials", "true")
 					}
-					
-					for _, allowed := range devAllowedOrigins {
-						if origin == allowed {
-							w.Header().Set("Access-Control-Allow-Origin", origin)
-							break
-						}
+					if exposedHeaders != "" {
+						w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
 					}
 				}
-			} else {
-				// Si no hay Origin header en desarrollo, permitir
-				w.Header().Set("Access-Control-Allow-Origin", "*")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !ok {
+				http.Error(w, "Origin not allowed", http.StatusForbidden)
+				return
+			}
+
+			var routeMethods []string
+			for _, method := range methods.forPath(r.URL.Path) {
+				if len(allowedMethods) == 0 || allowedMethods[method] {
+					routeMethods = append(routeMethods, method)
+				}
+			}
+			if len(routeMethods) == 0 {
+				http.Error(w, "Not found", http.StatusNotFound)
+				return
 			}
+
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(routeMethods, ", "))
+			if allowedHeaders != "" {
+				w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			}
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}, nil
+}
+
+// originPattern es un origen permitido, con comodines opcionales en el subdominio y el puerto
+type originPattern struct {
+	any        bool
+	scheme     string
+	host       string
+	port       string
+	subdomains bool
+	anyPort    bool
+}
+
+// ValidateOrigin valida un origen permitido: scheme://host[:port], con "*." como primer
+// label del host para aceptar subdominios, ":*" para aceptar cualquier puerto o "*" para
+// aceptar todos los orígenes
+func ValidateOrigin(value string) error {
+	_, err := parseOriginPattern(value)
+	return err
+}
+
+func parseOriginPattern(value string) (originPattern, error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return originPattern{any: true}, nil
+	}
+
+	scheme, rest, ok := strings.Cut(strings.TrimSuffix(strings.ToLower(value), "/"), "://")
+	if !ok || (scheme != "http" && scheme != "https") || rest == "" || strings.ContainsAny(rest, "/?#@") {
+		return originPattern{}, fmt.Errorf("invalid origin %q, expected scheme://host[:port]", value)
+	}
+
+	pattern := originPattern{scheme: scheme, host: rest}
+	if host, port, found := strings.Cut(rest, ":"); found {
+		pattern.host = host
+		if port == "*" {
+			pattern.anyPort = true
+		} else if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return originPattern{}, fmt.Errorf("invalid port in origin %q", value)
+		} else {
+			pattern.port = port
 		}
-		
-		// Headers CORS estándar
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH, HEAD")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Requested-With, Origin")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Max-Age", "86400")
-		
-		// Headers adicionales para evitar problemas
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Content-Type")
-
-		// Handle preflight requests (OPTIONS)
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+	}
+	if strings.HasPrefix(pattern.host, "*.") {
+		pattern.subdomains = true
+		pattern.host = pattern.host[1:]
+	}
+	if pattern.host == "" || pattern.host == "." || strings.Contains(pattern.host, "*") {
+		return originPattern{}, fmt.Errorf("invalid host in origin %q", value)
+	}
+	return pattern, nil
+}
+
+func (p originPattern) matches(origin string) bool {
+	if p.any {
+		return true
+	}
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Scheme != p.scheme {
+		return false
+	}
+	if !p.anyPort && u.Port() != p.port {
+		return false
+	}
+	host := u.Hostname()
+	if p.subdomains {
+		// *.example.com acepta a.example.com y a.b.example.com, pero no example.com
+		return strings.HasSuffix(host, p.host) && len(host) > len(p.host)
+	}
+	return host == p.host
+}
+
+// routeMethods calcula los métodos registrados para un path a partir de las rutas del
+// router. Las rutas se leen en el primer request, cuando ya están todas registradas
+type routeMethods struct {
+	router *mux.Router
+	once   sync.Once
+	routes []methodRoute
+}
+
+type methodRoute struct {
+	path    *regexp.Regexp
+	methods []string
+}
+
+func (m *routeMethods) forPath(path string) []string {
+	if m.router == nil {
+		return []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	m.once.Do(m.load)
+
+	var methods []string
+	seen := make(map[string]bool)
+	for _, route := range m.routes {
+		if !route.path.MatchString(path) {
+			continue
 		}
+		for _, method := range route.methods {
+			if !seen[method] && method != http.MethodOptions {
+				seen[method] = true
+				methods = append(methods, method)
+			}
+		}
+	}
+	return methods
+}
 
-		next.ServeHTTP(w, r)
+func (m *routeMethods) load() {
+	_ = m.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		pathRegexp, err := route.GetPathRegexp()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path, err := regexp.Compile(pathRegexp)
+		if err != nil {
+			return nil
+		}
+		m.routes = append(m.routes, methodRoute{path: path, methods: methods})
+		return nil
 	})
-}
\ No newline at end of file
+}
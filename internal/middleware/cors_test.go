@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCORSRouter(t *testing.T, cfg CORSConfig) *mux.Router {
+	t.Helper()
+	router := mux.NewRouter()
+	cfg.Router = router
+	cors, err := CORS(cfg)
+	require.NoError(t, err)
+	router.Use(cors)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/users/{id}", ok).Methods("GET")
+	api.HandleFunc("/users/{id}", ok).Methods("PUT")
+	api.HandleFunc("/users/{id}", ok).Methods("DELETE")
+	api.HandleFunc("/roles", ok).Methods("GET")
+	router.Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return router
+}
+
+func preflight(router http.Handler, origin, path, method string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func get(router http.Handler, origin, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Origin", origin)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORS_DevelopmentProfile(t *testing.T) {
+	router := newCORSRouter(t, CORSConfig{
+		AllowedOrigins: []string{"http://localhost:*", "http://127.0.0.1:*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		MaxAge:         10 * time.Minute,
+	})
+
+	rec := preflight(router, "http://localhost:3000", "/api/v1/users/42", "PUT")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "http://localhost:3000", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, PUT, DELETE", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Authorization", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	rec = preflight(router, "http://localhost:3000", "/api/v1/roles", "GET")
+	assert.Equal(t, "GET", rec.Header().Get("Access-Control-Allow-Methods"))
+
+	rec = preflight(router, "http://localhost:3000", "/api/v1/unknown", "GET")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = get(router, "https://evil.example.com", "/api/v1/roles")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_StagingProfileWithoutOrigins(t *testing.T) {
+	router := newCORSRouter(t, CORSConfig{AllowedMethods: []string{"GET"}})
+
+	rec := preflight(router, "http://localhost:3000", "/api/v1/roles", "GET")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	// Los requests sin Origin (servidor a servidor) no se ven afectados
+	rec = get(router, "", "/api/v1/roles")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestCORS_ProductionProfile(t *testing.T) {
+	router := newCORSRouter(t, CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"GET", "PUT"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	})
+
+	rec := preflight(router, "https://admin.eu.example.org", "/api/v1/users/42", "PUT")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://admin.eu.example.org", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, PUT", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "3600", rec.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+
+	rec = get(router, "https://app.example.com", "/api/v1/roles")
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-ID", rec.Header().Get("Access-Control-Expose-Headers"))
+
+	for _, origin := range []string{"https://example.org", "http://app.example.com", "https://app.example.com.evil.io"} {
+		rec = preflight(router, origin, "/api/v1/roles", "GET")
+		assert.Equal(t, http.StatusForbidden, rec.Code, origin)
+	}
+}
+
+func TestValidateOrigin(t *testing.T) {
+	for _, origin := range []string{"*", "https://app.example.com", "https://*.example.com", "http://localhost:*", "http://localhost:3000/"} {
+		assert.NoError(t, ValidateOrigin(origin), origin)
+	}
+	for _, origin := range []string{"app.example.com", "ftp://example.com", "https://example.com/path", "https://*", "https://a.*.example.com", "http://localhost:99999"} {
+		assert.Error(t, ValidateOrigin(origin), origin)
+	}
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"it-user-service/internal/auth"
+	"it-user-service/internal/idempotency"
+	"it-user-service/internal/logger"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+	idempotencyPollInterval = 50 * time.Millisecond
+)
+
+// replayedHeaders son los headers de la respuesta original que se repiten en los replays
+var replayedHeaders = []string{"Content-Type", "ETag", "Location", "Cache-Control"}
+
+// IdempotencyConfig configura el middleware de Idempotency-Key
+type IdempotencyConfig struct {
+	Store idempotency.Store
+	// TTL es el tiempo que se guarda la respuesta de cada key
+	TTL time.Duration
+	// LockTimeout es cuánto espera un request duplicado a que termine el original
+	LockTimeout time.Duration
+	// ExcludedPaths no usan idempotencia (rutas cuyas respuestas traen credenciales).
+	// Las que terminan en "/" excluyen todo lo que empieza con ellas
+	ExcludedPaths []string
+}
+
+// Idempotency hace idempotentes los POST que traen el header Idempotency-Key. La primera
+// vez se procesa el request y se guarda la respuesta; los reintentos con la misma key y el
+// mismo contenido reciben la respuesta guardada, y con otro contenido reciben 422. Un
+// duplicado que llega mientras el original se procesa espera a que termine. Las respuestas
+// 5xx no se guardan para que el cliente pueda reintentar. Debe ir después de Authenticate:
+// las keys son por usuario o API key, y los requests anónimos se procesan sin idempotencia
+func Idempotency(cfg IdempotencyConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			subject := idempotencySubject(r)
+			if r.Method != http.MethodPost || key == "" || subject == "" || isPublicPath(r.URL.Path, cfg.ExcludedPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			log := logger.FromContext(ctx).WithField("idempotency_key", key)
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(w, "Error reading request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := subject + ":" + r.Method + " " + routeTemplate(r) + ":" + key
+			fingerprint := idempotency.Fingerprint(r.Method, r.URL.RequestURI(), body)
+
+			deadline := time.Now().Add(cfg.LockTimeout)
+			for {
+				record, reserved, err := cfg.Store.Reserve(ctx, storeKey, fingerprint, cfg.TTL)
+				if err != nil {
+					// Si el store no responde se procesa el request sin idempotencia
+					log.WithError(err).Error("Idempotency store unavailable")
+					next.ServeHTTP(w, r)
+					return
+				}
+				if reserved {
+					break
+				}
+
+				switch {
+				case record.Fingerprint != fingerprint:
+					log.Warn("Idempotency-Key reused with a different request")
+					http.Error(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+					return
+				case record.Completed:
+					log.Info("Replaying stored response for Idempotency-Key")
+					replay(w, record)
+					return
+				case time.Now().After(deadline):
+					log.Warn("Request with the same Idempotency-Key still in progress")
+					w.Header().Set("Retry-After", "1")
+					http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+					return
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(idempotencyPollInterval):
+				}
+			}
+
+			cfg.process(w, r, next, storeKey, fingerprint)
+		})
+	}
+}
+
+// process atiende el request que reservó la key y guarda su respuesta. Si el handler falla
+// (5xx o panic) la reserva se libera
+func (cfg IdempotencyConfig) process(w http.ResponseWriter, r *http.Request, next http.Handler, storeKey, fingerprint string) {
+	ctx := context.WithoutCancel(r.Context())
+	completed := false
+	defer func() {
+		if !completed {
+			if err := cfg.Store.Release(ctx, storeKey); err != nil {
+				logger.FromContext(ctx).WithError(err).Error("Failed to release Idempotency-Key")
+			}
+		}
+	}()
+
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(recorder, r)
+	if recorder.status >= http.StatusInternalServerError {
+		return
+	}
+
+	header := make(http.Header)
+	for _, name := range replayedHeaders {
+		if values := recorder.Header().Values(name); len(values) > 0 {
+			header[name] = values
+		}
+	}
+	record := idempotency.Record{
+		Fingerprint: fingerprint,
+		Status:      recorder.status,
+		Header:      header,
+		Body:        recorder.body.Bytes(),
+	}
+	if err := cfg.Store.Complete(ctx, storeKey, record, cfg.TTL); err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Failed to store response for Idempotency-Key")
+		return
+	}
+	completed = true
+}
+
+func replay(w http.ResponseWriter, record *idempotency.Record) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
+
+// idempotencySubject separa las keys de cada usuario o API key ("" si el request es anónimo)
+func idempotencySubject(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return principal.Subject()
+	}
+	return ""
+}
+
+// responseRecorder copia la respuesta mientras la escribe al cliente
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap permite a http.ResponseController acceder al ResponseWriter original
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
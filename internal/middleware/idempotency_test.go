@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"it-user-service/internal/auth"
+	"it-user-service/internal/idempotency"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newIdempotencyRouter(handler http.HandlerFunc) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(Idempotency(IdempotencyConfig{
+		Store:         idempotency.NewMemoryStore(),
+		TTL:           time.Hour,
+		LockTimeout:   time.Second,
+		ExcludedPaths: []string{"/auth/"},
+	}))
+	router.HandleFunc("/users/create", handler).Methods("POST")
+	router.HandleFunc("/auth/refresh", handler).Methods("POST")
+	return router
+}
+
+func post(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	return postAs(router, "/users/create", &auth.Principal{UserID: "u1"}, key, body)
+}
+
+func postAs(router http.Handler, path string, principal *auth.Principal, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	if principal != nil {
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	var calls atomic.Int32
+	router := newIdempotencyRouter(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"id":1}}`))
+	})
+
+	first := post(router, "key-1", `{"email":"a@example.com"}`)
+	second := post(router, "key-1", `{"email":"a@example.com"}`)
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotencyRejectsDifferentPayload(t *testing.T) {
+	router := newIdempotencyRouter(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	post(router, "key-1", `{"email":"a@example.com"}`)
+	rec := post(router, "key-1", `{"email":"b@example.com"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	router := newIdempotencyRouter(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, post(router, "key-1", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, post(router, "key-1", `{}`).Code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencySerializesConcurrentDuplicates(t *testing.T) {
+	var calls atomic.Int32
+	router := newIdempotencyRouter(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = post(router, "key-1", `{}`).Code
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, code := range codes {
+		assert.Equal(t, http.StatusCreated, code)
+	}
+}
+
+func TestIdempotencySkipsExcludedPathsAndAnonymousRequests(t *testing.T) {
+	var calls atomic.Int32
+	router := newIdempotencyRouter(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Las respuestas con tokens no se guardan ni se repiten
+	for i := 0; i < 2; i++ {
+		rec := postAs(router, "/auth/refresh", &auth.Principal{UserID: "u1"}, "key-1", `{"refresh_token":"rt"}`)
+		assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+	}
+	// Los requests anónimos no comparten keys entre clientes
+	for i := 0; i < 2; i++ {
+		rec := postAs(router, "/users/create", nil, "key-1", `{}`)
+		assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+	}
+	assert.Equal(t, int32(4), calls.Load())
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteTimeout sobrescribe el deadline por defecto para una ruta (template de mux)
+type RouteTimeout struct {
+	Method  string
+	Path    string
+	Timeout time.Duration
+}
+
+// ParseRouteTimeouts lee overrides con el formato "METHOD /path=duración,..."
+func ParseRouteTimeouts(value string) ([]RouteTimeout, error) {
+	var routes []RouteTimeout
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, timeout, ok := strings.Cut(entry, "=")
+		method, path, okRoute := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !okRoute {
+			return nil, fmt.Errorf("invalid route timeout %q, expected \"METHOD /path=duration\"", entry)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(timeout))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration in route timeout %q: %w", entry, err)
+		}
+		if duration < 0 {
+			return nil, fmt.Errorf("negative duration in route timeout %q", entry)
+		}
+
+		routes = append(routes, RouteTimeout{
+			Method:  strings.ToUpper(strings.TrimSpace(method)),
+			Path:    strings.TrimSpace(path),
+			Timeout: duration,
+		})
+	}
+	return routes, nil
+}
+
+// QueryDeadlineConfig configura el deadline de las consultas hechas durante un request
+type QueryDeadlineConfig struct {
+	// Default aplica a todas las rutas sin override (0 = sin deadline)
+	Default time.Duration
+	Routes  []RouteTimeout
+}
+
+// QueryDeadline limita la duración del contexto del request. Los repositorios usan ese
+// contexto, de modo que las consultas se cancelan al vencer el deadline o al cortarse
+// la conexión del cliente
+func QueryDeadline(cfg QueryDeadlineConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := cfg.timeoutFor(r)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// timeoutFor retorna el deadline aplicable al request
+func (cfg QueryDeadlineConfig) timeoutFor(r *http.Request) time.Duration {
+	path := routeTemplate(r)
+	for _, route := range cfg.Routes {
+		if route.Method == r.Method && route.Path == path {
+			return route.Timeout
+		}
+	}
+	return cfg.Default
+}
+
+// routeTemplate retorna el template de mux de la ruta del request o, si no hay ruta, el path
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
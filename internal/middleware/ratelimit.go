@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"it-user-service/internal/auth"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/ratelimit"
+)
+
+// RateLimitConfig configura el middleware de rate limiting
+type RateLimitConfig struct {
+	Store   ratelimit.Store
+	Default ratelimit.Limit
+	// Routes sobrescribe el límite para rutas específicas (método + template de mux).
+	// Cada override usa buckets propios
+	Routes []ratelimit.RouteLimit
+	// TrustedProxies son los proxies cuyo X-Forwarded-For se considera
+	TrustedProxies []*net.IPNet
+}
+
+// RateLimit limita los requests por cliente: usuario autenticado, API key o IP.
+// Debe ir después de Authenticate para conocer el principal
+func RateLimit(cfg RateLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limit, bucket := cfg.limitFor(r)
+			if !limit.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			subject := rateLimitSubject(r, cfg.TrustedProxies)
+			result, err := cfg.Store.Allow(r.Context(), bucket+":"+subject, limit)
+			if err != nil {
+				// Si el store no responde se deja pasar el request
+				logger.FromContext(r.Context()).WithError(err).Error("Rate limit store unavailable")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+			if !result.Allowed {
+				logger.FromContext(r.Context()).WithFields(map[string]interface{}{
+					"subject": subject,
+					"bucket":  bucket,
+				}).Warn("Rate limit exceeded")
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitFor retorna el límite aplicable al request y el nombre de su bucket
+func (cfg RateLimitConfig) limitFor(r *http.Request) (ratelimit.Limit, string) {
+	path := routeTemplate(r)
+	for _, route := range cfg.Routes {
+		if route.Method == r.Method && route.Path == path {
+			return route.Limit, route.Method + " " + route.Path
+		}
+	}
+	return cfg.Default, "default"
+}
+
+// rateLimitSubject identifica al cliente: usuario, API key o IP
+func rateLimitSubject(r *http.Request, trustedProxies []*net.IPNet) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok && principal.Subject() != "" {
+		return principal.Subject()
+	}
+	return "ip:" + ClientIP(r, trustedProxies)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"it-user-service/internal/logger"
+)
+
+// RequestIDHeader es el header con el que se correlacionan los requests
+const RequestIDHeader = "X-Request-ID"
+
+// TraceparentHeader es el header de W3C Trace Context
+const TraceparentHeader = "traceparent"
+
+// maxRequestIDLength descarta ids recibidos demasiado largos
+const maxRequestIDLength = 128
+
+// RequestID usa el X-Request-ID y el traceparent recibidos o genera nuevos, los devuelve
+// en la respuesta y agrega al contexto el request id, la traza remota (padre de los spans
+// del request) y un logger con request_id, trace_id, method y route (ver logger.FromContext)
+func RequestID() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" || len(requestID) > maxRequestIDLength {
+				requestID = uuid.NewString()
+			}
+			spanContext, ok := parseTraceparent(r.Header.Get(TraceparentHeader))
+			if !ok {
+				spanContext = newSpanContext()
+				r.Header.Set(TraceparentHeader, formatTraceparent(spanContext))
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+			w.Header().Set(TraceparentHeader, formatTraceparent(spanContext))
+
+			ctx := trace.ContextWithRemoteSpanContext(r.Context(), spanContext)
+			ctx = logger.ContextWithRequestID(ctx, requestID)
+			ctx = logger.NewContext(ctx, logrus.Fields{
+				"trace_id": spanContext.TraceID().String(),
+				"method":   r.Method,
+				"route":    routeTemplate(r),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// parseTraceparent lee un header "00-<trace id>-<parent id>-<flags>"
+func parseTraceparent(value string) (trace.SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return trace.SpanContext{}, false
+	}
+	traceID, err := trace.TraceIDFromHex(parts[1])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(parts[2])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.TraceFlags(flags[0]),
+		Remote:     true,
+	}), true
+}
+
+// newSpanContext crea una traza nueva para los requests sin traceparent
+func newSpanContext() trace.SpanContext {
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	_, _ = rand.Read(traceID[:])
+	_, _ = rand.Read(spanID[:])
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+}
+
+func formatTraceparent(sc trace.SpanContext) string {
+	return "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-" + sc.TraceFlags().String()
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// Scopes que puede tener una API key
+const (
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeRolesRead     = "roles:read"
+	ScopeRolesWrite    = "roles:write"
+	ScopeSessionsRead  = "sessions:read"
+	ScopeSessionsWrite = "sessions:write"
+)
+
+// APIKey es una credencial de servicio a servicio. Solo se guarda el hash de la llave;
+// Prefix permite identificarla en logs y en la consola sin exponerla
+type APIKey struct {
+	ID           string     `json:"id" gorm:"primaryKey;type:uuid"`
+	Name         string     `json:"name" gorm:"size:100;not null"`
+	Prefix       string     `json:"prefix" gorm:"size:16;not null"`
+	KeyHash      string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Scopes       []string   `json:"scopes" gorm:"type:jsonb;serializer:json"`
+	AllowedIPs   []string   `json:"allowed_ips,omitempty" gorm:"type:jsonb;serializer:json"`
+	RateLimitRPS int        `json:"rate_limit_rps"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP   string     `json:"last_used_ip,omitempty" gorm:"size:45"`
+	CreatedBy    string     `json:"created_by,omitempty" gorm:"size:36"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Active indica si la API key no está revocada ni expirada
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// CreateAPIKeyRequest es el request de POST /admin/api-keys
+type CreateAPIKeyRequest struct {
+	Name         string     `json:"name" validate:"required,min=3,max=100"`
+	Scopes       []string   `json:"scopes" validate:"required,min=1,dive,oneof=users:read users:write roles:read roles:write sessions:read sessions:write"`
+	AllowedIPs   []string   `json:"allowed_ips" validate:"omitempty,dive,cidr|ip"`
+	RateLimitRPS int        `json:"rate_limit_rps" validate:"min=0,max=10000"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse incluye la llave en claro, que solo se entrega al crearla
+type CreateAPIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
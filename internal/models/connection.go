@@ -1,37 +1,159 @@
 package models
 
 import (
+	"fmt"
 	"log"
+	"slices"
 	"gorm.io/gorm"
+	"it-user-service/internal/config"
 	"it-user-service/internal/database"
 )
 
 // ConnectDB establece la conexión con la base de datos PostgreSQL
-func ConnectDB() {
-	database.ConnectDB()
+func ConnectDB(cfg config.DatabaseConfig) error {
+	return database.ConnectDB(cfg, nil)
 }
 
-// MigrateDB ejecuta las migraciones automáticas
-func MigrateDB() {
-	db := database.GetDB()
-	
-	// Habilitar extensión UUID si no existe
-	db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"")
-	
-	err := db.AutoMigrate(
+// AllModels retorna los modelos persistidos, en orden de migración
+func AllModels() []interface{} {
+	return []interface{}{
 		&User{},
+		&UserStatusChange{},
+		&EmailChange{},
+		&EmailHistory{},
 		&UserProfile{},
 		&UserSettings{},
 		&UserStats{},
 		&Role{},
 		&UserRole{},
-	)
+		&LoginEvent{},
+		&Session{},
+		&RefreshToken{},
+		&APIKey{},
+		&NotificationDelivery{},
+		&SchemaMigration{},
+		&DocumentArchive{},
+	}
+}
+
+// PendingMigrations retorna las tablas y columnas de los modelos que todavía no existen
+// en la base de datos y los cambios de esquema pendientes (vacío si el esquema está al día)
+func PendingMigrations(db *gorm.DB) ([]string, error) {
+	migrator := db.Migrator()
+	var pending []string
+	for _, model := range AllModels() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		if !migrator.HasTable(model) {
+			pending = append(pending, stmt.Table)
+			continue
+		}
+
+		columnTypes, err := migrator.ColumnTypes(model)
+		if err != nil {
+			return nil, err
+		}
+		columns := make(map[string]bool, len(columnTypes))
+		for _, column := range columnTypes {
+			columns[column.Name()] = true
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !columns[field.DBName] {
+				pending = append(pending, stmt.Table+"."+field.DBName)
+			}
+		}
+	}
+
+	// El check anterior de status rechaza los estados del ciclo de vida
+	if migrator.HasTable(&User{}) && migrator.HasConstraint(&User{}, "chk_users_status") {
+		pending = append(pending, "users.chk_users_status")
+	}
+	// Pasos de datos que todavía no se ejecutaron
+	if migrator.HasTable(&SchemaMigration{}) {
+		var applied []string
+		if err := db.Model(&SchemaMigration{}).Pluck("id", &applied).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range DataMigrations {
+			if !slices.Contains(applied, id) {
+				pending = append(pending, "schema_migrations."+id)
+			}
+		}
+	}
+	// Los usuarios con el indicador disabled anterior todavía no pasaron a deactivated
+	if migrator.HasColumn(&User{}, "disabled") {
+		var disabled int64
+		if err := db.Model(&User{}).Where("disabled").Count(&disabled).Error; err != nil {
+			return nil, err
+		}
+		if disabled > 0 {
+			pending = append(pending, "users.disabled")
+		}
+	}
+	return pending, nil
+}
+
+// MigrateDB crea o actualiza las tablas de todos los modelos
+func MigrateDB(db *gorm.DB) error {
+	// Habilitar extensión UUID si no existe
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"").Error; err != nil {
+		return err
+	}
+	
+	if err := migrateUserStatuses(db); err != nil {
+		return fmt.Errorf("error migrating user statuses: %w", err)
+	}
+	
+	if err := db.AutoMigrate(AllModels()...); err != nil {
+		return fmt.Errorf("error running migrations: %w", err)
+	}
 	
-	if err != nil {
-		log.Fatalf("Error al ejecutar migraciones: %v", err)
+	if err := migrateDisabledUsers(db); err != nil {
+		return fmt.Errorf("error migrating disabled users: %w", err)
 	}
 	
 	log.Println("Migraciones ejecutadas exitosamente")
+	return nil
+}
+
+// migrateUserStatuses reemplaza el estado inactive por deactivated: elimina el check
+// anterior de status (chk_users_status, que no admite los estados nuevos) y migra los
+// usuarios inactivos en una transacción. AutoMigrate crea después chk_users_lifecycle_status
+func migrateUserStatuses(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&User{}) {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if tx.Migrator().HasConstraint(&User{}, "chk_users_status") {
+			if err := tx.Migrator().DropConstraint(&User{}, "chk_users_status"); err != nil {
+				return err
+			}
+		}
+		return tx.Exec("UPDATE users SET status = ? WHERE status = ?", StatusDeactivated, "inactive").Error
+	})
+}
+
+// migrateDisabledUsers reemplaza el indicador disabled por el estado deactivated: los
+// usuarios deshabilitados que podían iniciar sesión pasan a deactivated con su cambio en el
+// historial, y el indicador se limpia en todos
+func migrateDisabledUsers(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		signInStatuses := []string{StatusPending, StatusActive, StatusSuspended}
+		err := tx.Exec(`INSERT INTO user_status_changes (user_id, from_status, to_status, reason, note, actor, created_at)
+			SELECT id, status, ?, ?, ?, ?, NOW() FROM users WHERE disabled AND status IN ?`,
+			StatusDeactivated, StatusReasonAdminAction, "migrated from the disabled flag", "system", signInStatuses).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec("UPDATE users SET status = ?, suspended_until = NULL WHERE disabled AND status IN ?",
+			StatusDeactivated, signInStatuses).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE users SET disabled = false, version = version + 1 WHERE disabled").Error
+	})
 }
 
 // GetDB retorna la instancia de la base de datos
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotJSONObject indica un documento que no es un objeto JSON
+var ErrNotJSONObject = errors.New("document must be a JSON object")
+
+// JSONDocument es un objeto JSON guardado en una columna jsonb (preferencias, privacidad,
+// notificaciones y seguridad). En la API es un objeto; por compatibilidad también acepta el
+// objeto serializado como string. Los números se conservan como json.Number
+type JSONDocument map[string]interface{}
+
+// Value guarda el documento como JSON (NULL si está vacío)
+func (d JSONDocument) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]interface{}(d))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan lee el documento de la columna jsonb
+func (d *JSONDocument) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		return d.decode(v)
+	case string:
+		return d.decode([]byte(v))
+	default:
+		return fmt.Errorf("models: cannot scan %T into JSONDocument", src)
+	}
+}
+
+// UnmarshalJSON acepta un objeto, null o un string con el objeto serializado
+func (d *JSONDocument) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err == nil {
+		if encoded == "" {
+			*d = nil
+			return nil
+		}
+		data = []byte(encoded)
+	}
+	return d.decode(data)
+}
+
+func (d *JSONDocument) decode(data []byte) error {
+	var doc map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return ErrNotJSONObject
+	}
+	*d = doc
+	return nil
+}
+
+// DocumentValue es el valor de una clave de un documento del perfil o de las configuraciones,
+// con el default del schema si el usuario no la escribió
+type DocumentValue struct {
+	Document string      `json:"document"`
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// EmailChange es un cambio de email pendiente de confirmar. El token de confirmación se
+// firma con el ID, el nuevo email y la expiración, así que no se guarda
+type EmailChange struct {
+	ID          string     `json:"id" gorm:"primaryKey;type:uuid"`
+	UserID      string     `json:"user_id" gorm:"not null;type:uuid;index"`
+	NewEmail    string     `json:"new_email" gorm:"size:255;not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// CanceledAt se completa cuando un pedido posterior reemplaza a este
+	CanceledAt *time.Time `json:"canceled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Pending indica si el cambio todavía se puede confirmar
+func (c *EmailChange) Pending(now time.Time) bool {
+	return c.ConfirmedAt == nil && c.CanceledAt == nil && now.Before(c.ExpiresAt)
+}
+
+// EmailHistory registra los emails anteriores de un usuario
+type EmailHistory struct {
+	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID        string    `json:"user_id" gorm:"not null;type:uuid;index:idx_email_histories_user_changed,priority:1"`
+	PreviousEmail string    `json:"previous_email" gorm:"size:255;not null"`
+	NewEmail      string    `json:"new_email" gorm:"size:255;not null"`
+	EmailChangeID string    `json:"email_change_id,omitempty" gorm:"type:uuid"`
+	ChangedAt     time.Time `json:"changed_at" gorm:"autoCreateTime;index:idx_email_histories_user_changed,priority:2"`
+}
+
+// RequestEmailChangeRequest es el request para pedir un cambio de email
+// (POST /users/{id}/email/change)
+type RequestEmailChangeRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email,max=255"`
+}
+
+// ConfirmEmailChangeRequest es el request para confirmar un cambio de email con el token
+// recibido en el nuevo email (POST /auth/email/confirm)
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required,max=512"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginEvent registra cada intento de login de un usuario (historial de logins)
+type LoginEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    string    `json:"user_id" gorm:"not null;type:uuid;index:idx_login_events_user_created,priority:1"`
+	IP        string    `json:"ip,omitempty" gorm:"size:45"`
+	UserAgent string    `json:"user_agent,omitempty" gorm:"size:512"`
+	Device    string    `json:"device,omitempty" gorm:"size:255"`
+	Provider  string    `json:"provider,omitempty" gorm:"size:50"`
+	Success   bool      `json:"success" gorm:"not null"`
+	SessionID string    `json:"session_id,omitempty" gorm:"size:36"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index:idx_login_events_user_created,priority:2"`
+
+	// Geolocalización y evaluación de riesgo (detección de logins sospechosos)
+	Country     string   `json:"country,omitempty" gorm:"size:2"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	RiskScore   int      `json:"risk_score" gorm:"not null;default:0"`
+	RiskReasons []string `json:"risk_reasons,omitempty" gorm:"type:jsonb;serializer:json"`
+	Flagged     bool     `json:"flagged" gorm:"not null;default:false;index"`
+}
+
+// Motivos de riesgo de un login
+const (
+	RiskReasonNewDevice        = "new_device"
+	RiskReasonNewCountry       = "new_country"
+	RiskReasonImpossibleTravel = "impossible_travel"
+	RiskReasonLoginBurst       = "login_burst"
+)
+
+// RecordLoginRequest es el request para registrar un login (POST /users/{id}/login)
+type RecordLoginRequest struct {
+	LoginIP     string `json:"login_ip" validate:"omitempty,ip"`
+	LoginDevice string `json:"login_device" validate:"max=255"`
+	UserAgent   string `json:"user_agent" validate:"max=512"`
+	Provider    string `json:"provider" validate:"max=50"`
+	Success     *bool  `json:"success"`
+
+	// Datos del dispositivo para el registro de sesiones
+	DeviceID  string `json:"device_id" validate:"max=128"`
+	Platform  string `json:"platform" validate:"omitempty,oneof=ios android web desktop other"`
+	PushToken string `json:"push_token" validate:"max=512"`
+}
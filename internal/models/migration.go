@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MigrationRepairDocuments corrige los documentos JSON de perfiles y configuraciones
+// guardados antes de validarlos contra sus schemas
+const MigrationRepairDocuments = "2026_10_repair_profile_documents"
+
+// DataMigrations son los pasos de datos que el comando migrate ejecuta una sola vez
+var DataMigrations = []string{MigrationRepairDocuments}
+
+// SchemaMigration registra un paso de datos ya aplicado
+type SchemaMigration struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:100"`
+	AppliedAt time.Time `json:"applied_at" gorm:"not null"`
+}
+
+// DocumentArchive guarda el valor original de un documento JSON que una migración modificó
+type DocumentArchive struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Migration   string    `json:"migration" gorm:"size:100;not null;index"`
+	SourceTable string    `json:"source_table" gorm:"size:50;not null"`
+	RowID       uint      `json:"row_id" gorm:"not null"`
+	ColumnName  string    `json:"column_name" gorm:"size:50;not null"`
+	Value       string    `json:"value" gorm:"type:jsonb"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RunOnce ejecuta el paso id en una transacción junto con su registro en schema_migrations.
+// Si ya se aplicó no hace nada; si otra réplica lo está aplicando espera a que termine.
+// Retorna si lo ejecutó
+func RunOnce(db *gorm.DB, id string, step func(tx *gorm.DB) error) (bool, error) {
+	applied := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("INSERT INTO schema_migrations (id, applied_at) VALUES (?, ?) ON CONFLICT (id) DO NOTHING", id, time.Now())
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := step(tx); err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Estados de la entrega de una notificación
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// NotificationDelivery registra la entrega de una notificación por un canal. Guarda el
+// contenido renderizado para que los reintentos envíen exactamente lo mismo
+type NotificationDelivery struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:uuid"`
+	UserID        string     `json:"user_id" gorm:"not null;type:uuid;index:idx_notification_deliveries_user_created,priority:1"`
+	EventID       string     `json:"event_id" gorm:"size:64;not null;uniqueIndex:idx_notification_deliveries_event_channel,priority:1"`
+	Kind          string     `json:"kind" gorm:"size:50;not null"`
+	Channel       string     `json:"channel" gorm:"size:20;not null;uniqueIndex:idx_notification_deliveries_event_channel,priority:2"`
+	Recipient     string     `json:"recipient,omitempty" gorm:"size:255"`
+	Language      string     `json:"language" gorm:"size:10"`
+	Subject       string     `json:"subject" gorm:"size:255"`
+	Body          string     `json:"body" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"size:20;not null;default:'pending';index:idx_notification_deliveries_due,priority:1"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	LastError     string     `json:"last_error,omitempty" gorm:"size:1000"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" gorm:"index:idx_notification_deliveries_due,priority:2"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime;index:idx_notification_deliveries_user_created,priority:2"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
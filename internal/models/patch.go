@@ -0,0 +1,113 @@
+package models
+
+import "time"
+
+// Modelos de PATCH: los campos modificables de cada recurso. El patch del request se aplica
+// sobre los valores actuales y el resultado se valida completo, así que un campo que el
+// patch pone en null queda vacío y debe cumplir las mismas reglas que el resto
+
+// UserPatch son los campos de un usuario modificables con PATCH /users/{id}. El estado se
+// cambia con POST /users/{id}/status
+type UserPatch struct {
+	Username      string `json:"username" validate:"required,min=3,max=50,alphanum"`
+	FirstName     string `json:"first_name" validate:"max=100"`
+	LastName      string `json:"last_name" validate:"max=100"`
+	Provider      string `json:"provider" validate:"max=50"`
+	ProviderID    string `json:"provider_id" validate:"max=128"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// NewUserPatch retorna los valores actuales del usuario
+func NewUserPatch(user *User) UserPatch {
+	return UserPatch{
+		Username:      user.Username,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Provider:      user.Provider,
+		ProviderID:    user.ProviderID,
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+// ApplyTo copia los valores del patch al usuario
+func (p UserPatch) ApplyTo(user *User) {
+	user.Username = p.Username
+	user.FirstName = p.FirstName
+	user.LastName = p.LastName
+	user.Provider = p.Provider
+	user.ProviderID = p.ProviderID
+	user.EmailVerified = p.EmailVerified
+}
+
+// ProfilePatch son los campos del perfil modificables con PATCH /users/{id}/profile
+type ProfilePatch struct {
+	Avatar      string       `json:"avatar" validate:"omitempty,url,max=500"`
+	Bio         string       `json:"bio" validate:"max=1000"`
+	Website     string       `json:"website" validate:"omitempty,url,max=255"`
+	Location    string       `json:"location" validate:"max=100"`
+	Birthday    *time.Time   `json:"birthday"`
+	Gender      string       `json:"gender" validate:"omitempty,oneof=male female other prefer_not_to_say"`
+	Phone       string       `json:"phone" validate:"max=20"`
+	Preferences JSONDocument `json:"preferences"`
+	Privacy     JSONDocument `json:"privacy"`
+}
+
+// NewProfilePatch retorna los valores actuales del perfil
+func NewProfilePatch(profile *UserProfile) ProfilePatch {
+	return ProfilePatch{
+		Avatar:      profile.Avatar,
+		Bio:         profile.Bio,
+		Website:     profile.Website,
+		Location:    profile.Location,
+		Birthday:    profile.Birthday,
+		Gender:      profile.Gender,
+		Phone:       profile.Phone,
+		Preferences: profile.Preferences,
+		Privacy:     profile.Privacy,
+	}
+}
+
+// ApplyTo copia los valores del patch al perfil
+func (p ProfilePatch) ApplyTo(profile *UserProfile) {
+	profile.Avatar = p.Avatar
+	profile.Bio = p.Bio
+	profile.Website = p.Website
+	profile.Location = p.Location
+	profile.Birthday = p.Birthday
+	profile.Gender = p.Gender
+	profile.Phone = p.Phone
+	profile.Preferences = p.Preferences
+	profile.Privacy = p.Privacy
+}
+
+// SettingsPatch son los campos de la configuración modificables con PATCH /users/{id}/settings
+type SettingsPatch struct {
+	Language      string       `json:"language" validate:"required,min=2,max=10"`
+	Timezone      string       `json:"timezone" validate:"required,max=50"`
+	Theme         string       `json:"theme" validate:"required,oneof=light dark auto"`
+	Notifications JSONDocument `json:"notifications"`
+	Privacy       JSONDocument `json:"privacy"`
+	Security      JSONDocument `json:"security"`
+}
+
+// NewSettingsPatch retorna los valores actuales de la configuración
+func NewSettingsPatch(settings *UserSettings) SettingsPatch {
+	return SettingsPatch{
+		Language:      settings.Language,
+		Timezone:      settings.Timezone,
+		Theme:         settings.Theme,
+		Notifications: settings.Notifications,
+		Privacy:       settings.Privacy,
+		Security:      settings.Security,
+	}
+}
+
+// ApplyTo copia los valores del patch a la configuración
+func (p SettingsPatch) ApplyTo(settings *UserSettings) {
+	settings.Language = p.Language
+	settings.Timezone = p.Timezone
+	settings.Theme = p.Theme
+	settings.Notifications = p.Notifications
+	settings.Privacy = p.Privacy
+	settings.Security = p.Security
+}
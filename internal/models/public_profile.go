@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PublicProfile es la proyección del perfil de un usuario que ve otro usuario o un visitante
+// anónimo: solo incluye los campos cuya visibilidad permite verlos, el resto se omite
+type PublicProfile struct {
+	UserID    string     `json:"user_id"`
+	Username  string     `json:"username,omitempty"`
+	FirstName string     `json:"first_name,omitempty"`
+	LastName  string     `json:"last_name,omitempty"`
+	Avatar    string     `json:"avatar,omitempty"`
+	Bio       string     `json:"bio,omitempty"`
+	Website   string     `json:"website,omitempty"`
+	Location  string     `json:"location,omitempty"`
+	Birthday  *time.Time `json:"birthday,omitempty"`
+	Gender    string     `json:"gender,omitempty"`
+	Phone     string     `json:"phone,omitempty"`
+	// Relationship es la relación del visitante con el usuario: anonymous, authenticated,
+	// self o privileged (administradores y API keys)
+	Relationship string `json:"relationship"`
+}
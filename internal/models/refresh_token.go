@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken es un refresh token emitido por POST /auth/token. Solo se guarda el hash
+// del token; cada uso lo rota por uno nuevo de la misma familia (FamilyID)
+type RefreshToken struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:uuid"`
+	UserID        string     `json:"user_id" gorm:"not null;type:uuid;index"`
+	SessionID     string     `json:"session_id" gorm:"not null;type:uuid;index"`
+	FamilyID      string     `json:"family_id" gorm:"not null;type:uuid;index"`
+	ParentID      *string    `json:"parent_id,omitempty" gorm:"type:uuid"`
+	TokenHash     string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt        *time.Time `json:"used_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty" gorm:"size:50"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TokenRequest es el request de POST /auth/token
+type TokenRequest struct {
+	IDToken    string `json:"id_token" validate:"required"`
+	DeviceID   string `json:"device_id" validate:"max=128"`
+	DeviceName string `json:"device_name" validate:"max=255"`
+	Platform   string `json:"platform" validate:"omitempty,oneof=ios android web desktop other"`
+	PushToken  string `json:"push_token" validate:"max=512"`
+}
+
+// RefreshTokenRequest es el request de POST /auth/refresh y POST /auth/revoke
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse es la respuesta con el par de tokens emitido
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+	SessionID        string `json:"session_id"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Session representa un dispositivo con sesión iniciada por un usuario
+type Session struct {
+	ID          string     `json:"id" gorm:"primaryKey;type:uuid"`
+	UserID      string     `json:"user_id" gorm:"not null;type:uuid;index:idx_sessions_user_device,priority:1"`
+	DeviceID    string     `json:"device_id" gorm:"size:128;not null;index:idx_sessions_user_device,priority:2"`
+	DeviceName  string     `json:"device_name,omitempty" gorm:"size:255"`
+	Platform    string     `json:"platform,omitempty" gorm:"size:30"`
+	UserAgent   string     `json:"user_agent,omitempty" gorm:"size:512"`
+	PushToken   string     `json:"-" gorm:"size:512"`
+	IP          string     `json:"ip,omitempty" gorm:"size:45"`
+	FirstSeenAt time.Time  `json:"first_seen_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Active indica si la sesión no ha sido revocada
+func (s *Session) Active() bool {
+	return s.RevokedAt == nil
+}
@@ -20,8 +20,12 @@ type User struct {
 	LastLoginAt     *time.Time `json:"last_login_at"`
 	LastLoginIP     *string    `json:"last_login_ip" gorm:"size:45"`
 	LastLoginDevice *string    `json:"last_login_device" gorm:"size:255"`
+	// Disabled es el indicador anterior al ciclo de vida: migrate pasa esos usuarios a
+	// deactivated y lo limpia. La API ya no lo modifica; se deshabilita con el estado
 	Disabled        bool       `json:"disabled" gorm:"default:false"`
-	Status          string     `json:"status" gorm:"size:20;default:'active';check:status IN ('active','inactive','pending')"`
+	Status          string     `json:"status" gorm:"size:20;default:'active';check:chk_users_lifecycle_status,status IN ('pending','active','suspended','deactivated','deleted')"`
+	SuspendedUntil  *time.Time `json:"suspended_until,omitempty" gorm:"index"`
+	Version         int        `json:"version" gorm:"not null;default:1"`
 }
 
 type CreateUserRequest struct {
@@ -33,7 +37,7 @@ type CreateUserRequest struct {
 	LastName      string `json:"last_name" validate:"max=100"`
 	Provider      string `json:"provider" validate:"max=50"`
 	ProviderID    string `json:"provider_id" validate:"max=128"`
-	Status        string `json:"status" validate:"oneof=active inactive pending"`
+	Status        string `json:"status" validate:"oneof=active pending"`
 }
 
 type UpdateUserRequest struct {
@@ -45,43 +49,43 @@ type UpdateUserRequest struct {
 	ProviderID      string  `json:"provider_id" validate:"max=128"`
 	LastLoginIP     string  `json:"last_login_ip" validate:"omitempty,ip"`
 	LastLoginDevice string  `json:"last_login_device" validate:"max=255"`
-	Disabled        *bool   `json:"disabled"`
-	Status          string  `json:"status" validate:"omitempty,oneof=active inactive pending"`
 }
 
 // User Profile models - Modelos relacionados con el perfil del usuario
 type UserProfile struct {
-	ID          uint                   `json:"id" gorm:"primaryKey;autoIncrement"`
-	UserID      string                 `json:"user_id" gorm:"not null;uniqueIndex;type:uuid"`
-	Avatar      string                 `json:"avatar,omitempty" gorm:"size:500"`
-	Bio         string                 `json:"bio,omitempty" gorm:"type:text"`
-	Website     string                 `json:"website,omitempty" gorm:"size:255"`
-	Location    string                 `json:"location,omitempty" gorm:"size:100"`
-	Birthday    *time.Time             `json:"birthday,omitempty"`
-	Gender      string                 `json:"gender,omitempty" gorm:"size:20"`
-	Phone       string                 `json:"phone,omitempty" gorm:"size:20"`
-	Preferences string                 `json:"preferences,omitempty" gorm:"type:jsonb"` // JSON en PostgreSQL
-	Privacy     string                 `json:"privacy,omitempty" gorm:"type:jsonb"`     // JSON en PostgreSQL
-	CreatedAt   time.Time              `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time              `json:"updated_at" gorm:"autoUpdateTime"`
-	
+	ID          uint         `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      string       `json:"user_id" gorm:"not null;uniqueIndex;type:uuid"`
+	Avatar      string       `json:"avatar,omitempty" gorm:"size:500"`
+	Bio         string       `json:"bio,omitempty" gorm:"type:text"`
+	Website     string       `json:"website,omitempty" gorm:"size:255"`
+	Location    string       `json:"location,omitempty" gorm:"size:100"`
+	Birthday    *time.Time   `json:"birthday,omitempty"`
+	Gender      string       `json:"gender,omitempty" gorm:"size:20"`
+	Phone       string       `json:"phone,omitempty" gorm:"size:20"`
+	Preferences JSONDocument `json:"preferences,omitempty" gorm:"type:jsonb"` // JSON en PostgreSQL
+	Privacy     JSONDocument `json:"privacy,omitempty" gorm:"type:jsonb"`     // JSON en PostgreSQL
+	CreatedAt   time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+	Version     int          `json:"version" gorm:"not null;default:1"`
+
 	// Relación con User
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
 // User Settings models - Modelos relacionados con configuraciones del usuario
 type UserSettings struct {
-	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	UserID        string    `json:"user_id" gorm:"not null;uniqueIndex;type:uuid"`
-	Language      string    `json:"language" gorm:"size:10;default:'en'"`
-	Timezone      string    `json:"timezone" gorm:"size:50;default:'UTC'"`
-	Theme         string    `json:"theme" gorm:"size:20;default:'light'"`
-	Notifications string    `json:"notifications" gorm:"type:jsonb"` // JSON en PostgreSQL
-	Privacy       string    `json:"privacy" gorm:"type:jsonb"`       // JSON en PostgreSQL
-	Security      string    `json:"security" gorm:"type:jsonb"`      // JSON en PostgreSQL
-	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
-	
+	ID            uint         `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID        string       `json:"user_id" gorm:"not null;uniqueIndex;type:uuid"`
+	Language      string       `json:"language" gorm:"size:10;default:'en'"`
+	Timezone      string       `json:"timezone" gorm:"size:50;default:'UTC'"`
+	Theme         string       `json:"theme" gorm:"size:20;default:'light'"`
+	Notifications JSONDocument `json:"notifications" gorm:"type:jsonb"` // JSON en PostgreSQL
+	Privacy       JSONDocument `json:"privacy" gorm:"type:jsonb"`       // JSON en PostgreSQL
+	Security      JSONDocument `json:"security" gorm:"type:jsonb"`      // JSON en PostgreSQL
+	CreatedAt     time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+	Version       int          `json:"version" gorm:"not null;default:1"`
+
 	// Relación con User
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
@@ -110,6 +114,7 @@ type Role struct {
 	Active      bool      `json:"active" gorm:"default:true"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	Version     int       `json:"version" gorm:"not null;default:1"`
 }
 
 // UserRole models - Modelos relacionados con roles de usuario
@@ -138,45 +143,45 @@ type UserWithRoles struct {
 
 // Request models - Modelos para requests
 type CreateProfileRequest struct {
-	Avatar      string     `json:"avatar,omitempty" validate:"omitempty,url,max=500"`
-	Bio         string     `json:"bio,omitempty" validate:"max=1000"`
-	Website     string     `json:"website,omitempty" validate:"omitempty,url,max=255"`
-	Location    string     `json:"location,omitempty" validate:"max=100"`
-	Birthday    *time.Time `json:"birthday,omitempty"`
-	Gender      string     `json:"gender,omitempty" validate:"omitempty,oneof=male female other prefer_not_to_say"`
-	Phone       string     `json:"phone,omitempty" validate:"omitempty,max=20"`
-	Preferences string     `json:"preferences,omitempty"`
-	Privacy     string     `json:"privacy,omitempty"`
+	Avatar      string       `json:"avatar,omitempty" validate:"omitempty,url,max=500"`
+	Bio         string       `json:"bio,omitempty" validate:"max=1000"`
+	Website     string       `json:"website,omitempty" validate:"omitempty,url,max=255"`
+	Location    string       `json:"location,omitempty" validate:"max=100"`
+	Birthday    *time.Time   `json:"birthday,omitempty"`
+	Gender      string       `json:"gender,omitempty" validate:"omitempty,oneof=male female other prefer_not_to_say"`
+	Phone       string       `json:"phone,omitempty" validate:"omitempty,max=20"`
+	Preferences JSONDocument `json:"preferences,omitempty"`
+	Privacy     JSONDocument `json:"privacy,omitempty"`
 }
 
 type CreateSettingsRequest struct {
-	Language      string `json:"language,omitempty" validate:"omitempty,min=2,max=10"`
-	Timezone      string `json:"timezone,omitempty" validate:"max=50"`
-	Theme         string `json:"theme,omitempty" validate:"omitempty,oneof=light dark auto"`
-	Notifications string `json:"notifications,omitempty"`
-	Privacy       string `json:"privacy,omitempty"`
-	Security      string `json:"security,omitempty"`
+	Language      string       `json:"language,omitempty" validate:"omitempty,min=2,max=10"`
+	Timezone      string       `json:"timezone,omitempty" validate:"max=50"`
+	Theme         string       `json:"theme,omitempty" validate:"omitempty,oneof=light dark auto"`
+	Notifications JSONDocument `json:"notifications,omitempty"`
+	Privacy       JSONDocument `json:"privacy,omitempty"`
+	Security      JSONDocument `json:"security,omitempty"`
 }
 
 type UpdateProfileRequest struct {
-	Avatar      string     `json:"avatar,omitempty" validate:"omitempty,url,max=500"`
-	Bio         string     `json:"bio,omitempty" validate:"max=1000"`
-	Website     string     `json:"website,omitempty" validate:"omitempty,url,max=255"`
-	Location    string     `json:"location,omitempty" validate:"max=100"`
-	Birthday    *time.Time `json:"birthday,omitempty"`
-	Gender      string     `json:"gender,omitempty" validate:"omitempty,oneof=male female other prefer_not_to_say"`
-	Phone       string     `json:"phone,omitempty" validate:"omitempty,max=20"`
-	Preferences string     `json:"preferences,omitempty"`
-	Privacy     string     `json:"privacy,omitempty"`
+	Avatar      string       `json:"avatar,omitempty" validate:"omitempty,url,max=500"`
+	Bio         string       `json:"bio,omitempty" validate:"max=1000"`
+	Website     string       `json:"website,omitempty" validate:"omitempty,url,max=255"`
+	Location    string       `json:"location,omitempty" validate:"max=100"`
+	Birthday    *time.Time   `json:"birthday,omitempty"`
+	Gender      string       `json:"gender,omitempty" validate:"omitempty,oneof=male female other prefer_not_to_say"`
+	Phone       string       `json:"phone,omitempty" validate:"omitempty,max=20"`
+	Preferences JSONDocument `json:"preferences,omitempty"`
+	Privacy     JSONDocument `json:"privacy,omitempty"`
 }
 
 type UpdateSettingsRequest struct {
-	Language      string `json:"language,omitempty" validate:"omitempty,min=2,max=10"`
-	Timezone      string `json:"timezone,omitempty" validate:"max=50"`
-	Theme         string `json:"theme,omitempty" validate:"omitempty,oneof=light dark auto"`
-	Notifications string `json:"notifications,omitempty"`
-	Privacy       string `json:"privacy,omitempty"`
-	Security      string `json:"security,omitempty"`
+	Language      string       `json:"language,omitempty" validate:"omitempty,min=2,max=10"`
+	Timezone      string       `json:"timezone,omitempty" validate:"max=50"`
+	Theme         string       `json:"theme,omitempty" validate:"omitempty,oneof=light dark auto"`
+	Notifications JSONDocument `json:"notifications,omitempty"`
+	Privacy       JSONDocument `json:"privacy,omitempty"`
+	Security      JSONDocument `json:"security,omitempty"`
 }
 
 type CreateRoleRequest struct {
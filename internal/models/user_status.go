@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Estados del ciclo de vida de un usuario
+const (
+	StatusPending     = "pending"
+	StatusActive      = "active"
+	StatusSuspended   = "suspended"
+	StatusDeactivated = "deactivated"
+	StatusDeleted     = "deleted"
+)
+
+// statusTransitions son los estados a los que se puede pasar desde cada estado. deleted es final
+var statusTransitions = map[string][]string{
+	StatusPending:     {StatusActive, StatusDeactivated, StatusDeleted},
+	StatusActive:      {StatusPending, StatusSuspended, StatusDeactivated, StatusDeleted},
+	StatusSuspended:   {StatusActive, StatusDeactivated, StatusDeleted},
+	StatusDeactivated: {StatusActive, StatusDeleted},
+}
+
+// CanTransition indica si un usuario puede pasar del estado from al estado to
+func CanTransition(from, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Motivos de un cambio de estado
+const (
+	StatusReasonVerified           = "verified"
+	StatusReasonUserRequest        = "user_request"
+	StatusReasonAdminAction        = "admin_action"
+	StatusReasonPolicyViolation    = "policy_violation"
+	StatusReasonSuspiciousActivity = "suspicious_activity"
+	StatusReasonFraud              = "fraud"
+	StatusReasonInactivity         = "inactivity"
+	// StatusReasonSuspensionExpired lo usa solo la reactivación automática
+	StatusReasonSuspensionExpired = "suspension_expired"
+)
+
+// UserStatusChange registra cada cambio de estado de un usuario (historial de estados)
+type UserStatusChange struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID     string     `json:"user_id" gorm:"not null;type:uuid;index:idx_user_status_changes_user_created,priority:1"`
+	FromStatus string     `json:"from_status" gorm:"size:20;not null"`
+	ToStatus   string     `json:"to_status" gorm:"size:20;not null"`
+	Reason     string     `json:"reason" gorm:"size:50;not null"`
+	Note       string     `json:"note,omitempty" gorm:"size:500"`
+	Until      *time.Time `json:"until,omitempty"`
+	// Actor es el subject del principal que hizo el cambio ("system" para los automáticos)
+	Actor     string    `json:"actor,omitempty" gorm:"size:100"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index:idx_user_status_changes_user_created,priority:2"`
+}
+
+// ChangeUserStatusRequest es el request para cambiar el estado de un usuario
+// (POST /users/{id}/status). Until solo aplica a suspended: al vencer el usuario vuelve a active
+type ChangeUserStatusRequest struct {
+	Status string     `json:"status" validate:"required,oneof=pending active suspended deactivated deleted"`
+	Reason string     `json:"reason" validate:"required,oneof=verified user_request admin_action policy_violation suspicious_activity fraud inactivity"`
+	Note   string     `json:"note" validate:"max=500"`
+	Until  *time.Time `json:"until"`
+}
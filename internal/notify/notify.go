@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"it-user-service/internal/mail"
+)
+
+// Canales de entrega
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Notification es una notificación ya renderizada para un usuario
+type Notification struct {
+	// ID identifica la entrega; los receptores lo pueden usar para descartar reintentos duplicados
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	UserID    string    `json:"user_id"`
+	EventID   string    `json:"event_id"`
+	Language  string    `json:"language"`
+	Recipient string    `json:"-"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Channel entrega notificaciones por un medio (email, webhook)
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// EmailChannel entrega las notificaciones por email al Recipient
+type EmailChannel struct {
+	mailer mail.Mailer
+}
+
+func NewEmailChannel(mailer mail.Mailer) *EmailChannel {
+	return &EmailChannel{mailer: mailer}
+}
+
+func (c *EmailChannel) Name() string {
+	return ChannelEmail
+}
+
+// Send envía la notificación como email de texto plano
+func (c *EmailChannel) Send(ctx context.Context, n Notification) error {
+	return c.mailer.Send(ctx, mail.Message{To: n.Recipient, Subject: n.Subject, Body: n.Body})
+}
+
+// ChannelPreference es la preferencia de un usuario para un canal. Enabled nil usa el
+// default (habilitado); Kinds deshabilita (false) tipos de notificación puntuales
+type ChannelPreference struct {
+	Enabled *bool           `json:"enabled,omitempty"`
+	Kinds   map[string]bool `json:"kinds,omitempty"`
+}
+
+// Preferences son las preferencias de notificación por canal guardadas en
+// UserSettings.Notifications, por ejemplo:
+//
+//	{"email": {"enabled": true, "kinds": {"role_granted": false}}, "webhook": {"enabled": false}}
+type Preferences map[string]ChannelPreference
+
+// ParsePreferences lee las preferencias; vacío o null equivale a los defaults
+func ParsePreferences(raw []byte) (Preferences, error) {
+	prefs := Preferences{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return prefs, nil
+	}
+	if err := json.Unmarshal(raw, &prefs); err != nil {
+		return Preferences{}, err
+	}
+	return prefs, nil
+}
+
+// Allows indica si el usuario acepta notificaciones kind por channel
+func (p Preferences) Allows(channel, kind string) bool {
+	pref, ok := p[channel]
+	if !ok {
+		return true
+	}
+	if pref.Enabled != nil && !*pref.Enabled {
+		return false
+	}
+	if enabled, ok := pref.Kinds[kind]; ok {
+		return enabled
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferences_Allows(t *testing.T) {
+	prefs, err := ParsePreferences([]byte(`{"email": {"kinds": {"welcome": false}}, "webhook": {"enabled": false}}`))
+	require.NoError(t, err)
+
+	assert.False(t, prefs.Allows(ChannelEmail, KindWelcome))
+	assert.True(t, prefs.Allows(ChannelEmail, KindNewDeviceLogin))
+	assert.False(t, prefs.Allows(ChannelWebhook, KindNewDeviceLogin))
+
+	empty, err := ParsePreferences(nil)
+	require.NoError(t, err)
+	assert.True(t, empty.Allows(ChannelWebhook, KindWelcome))
+
+	_, err = ParsePreferences([]byte(`{"email": true}`))
+	assert.Error(t, err)
+}
+
+func TestRender_FallsBackToDefaultLanguage(t *testing.T) {
+	subject, body, err := Render(KindWelcome, "fr-FR", TemplateData{Name: "Zoé"})
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome, Zoé", subject)
+	assert.Contains(t, body, "Hi Zoé")
+
+	_, _, err = Render("unknown", "en", TemplateData{})
+	assert.ErrorIs(t, err, ErrUnknownKind)
+}
+
+func TestWebhookChannel_SignsBody(t *testing.T) {
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+	}))
+	defer server.Close()
+
+	channel := NewWebhookChannel(server.URL, func() string { return "secret" }, time.Second)
+	require.NoError(t, channel.Send(context.Background(), Notification{ID: "d1", Kind: KindWelcome, Recipient: "ana@example.com"}))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+	assert.NotContains(t, string(body), "ana@example.com")
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	assert.Error(t, NewWebhookChannel(failing.URL, func() string { return "" }, time.Second).Send(context.Background(), Notification{}))
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Tipos de notificación
+const (
+	KindWelcome          = "welcome"
+	KindRoleGranted      = "role_granted"
+	KindAccountSuspended = "account_suspended"
+	KindNewDeviceLogin   = "new_device_login"
+)
+
+// Kinds son todos los tipos de notificación
+var Kinds = []string{KindWelcome, KindRoleGranted, KindAccountSuspended, KindNewDeviceLogin}
+
+// DefaultLanguage se usa para los idiomas sin plantillas
+const DefaultLanguage = "en"
+
+// ErrUnknownKind indica un tipo de notificación sin plantillas
+var ErrUnknownKind = errors.New("notify: unknown notification kind")
+
+// TemplateData son los datos disponibles en las plantillas. Las fechas llegan ya
+// formateadas en el idioma y la zona horaria del usuario
+type TemplateData struct {
+	Name    string
+	Role    string
+	Reason  string
+	Until   string
+	Device  string
+	IP      string
+	Country string
+	Time    string
+}
+
+type message struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+type locale struct {
+	timeLayout string
+	messages   map[string]message
+}
+
+func newMessage(subject, body string) message {
+	return message{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(body)),
+	}
+}
+
+var locales = map[string]locale{
+	"en": {
+		timeLayout: "Jan 2, 2006 15:04 MST",
+		messages: map[string]message{
+			KindWelcome: newMessage("Welcome, {{.Name}}",
+				"Hi {{.Name}},\n\nYour account has been created. Welcome aboard!\n"),
+			KindRoleGranted: newMessage("You have a new role: {{.Role}}",
+				"Hi {{.Name}},\n\nThe role \"{{.Role}}\" was granted to your account on {{.Time}}.\n"),
+			KindAccountSuspended: newMessage("Your account has been suspended",
+				"Hi {{.Name}},\n\nYour account was suspended on {{.Time}}{{if .Reason}} (reason: {{.Reason}}){{end}}.\n"+
+					"{{if .Until}}The suspension ends on {{.Until}}.\n{{end}}"),
+			KindNewDeviceLogin: newMessage("New sign-in to your account",
+				"Hi {{.Name}},\n\nYour account was used to sign in from a new device on {{.Time}}.\n\n"+
+					"Device: {{.Device}}\n{{if .IP}}IP: {{.IP}}\n{{end}}{{if .Country}}Country: {{.Country}}\n{{end}}\n"+
+					"If this wasn't you, sign out that session and change your password.\n"),
+		},
+	},
+	"es": {
+		timeLayout: "02/01/2006 15:04 MST",
+		messages: map[string]message{
+			KindWelcome: newMessage("Te damos la bienvenida, {{.Name}}",
+				"Hola {{.Name}},\n\nTu cuenta fue creada. ¡Bienvenido!\n"),
+			KindRoleGranted: newMessage("Tienes un nuevo rol: {{.Role}}",
+				"Hola {{.Name}},\n\nEl {{.Time}} se asignó el rol \"{{.Role}}\" a tu cuenta.\n"),
+			KindAccountSuspended: newMessage("Tu cuenta fue suspendida",
+				"Hola {{.Name}},\n\nTu cuenta fue suspendida el {{.Time}}{{if .Reason}} (motivo: {{.Reason}}){{end}}.\n"+
+					"{{if .Until}}La suspensión termina el {{.Until}}.\n{{end}}"),
+			KindNewDeviceLogin: newMessage("Nuevo inicio de sesión en tu cuenta",
+				"Hola {{.Name}},\n\nEl {{.Time}} se inició sesión en tu cuenta desde un dispositivo nuevo.\n\n"+
+					"Dispositivo: {{.Device}}\n{{if .IP}}IP: {{.IP}}\n{{end}}{{if .Country}}País: {{.Country}}\n{{end}}\n"+
+					"Si no fuiste tú, cierra esa sesión y cambia tu contraseña.\n"),
+		},
+	},
+}
+
+// Language retorna el idioma con plantillas para language ("es-AR" -> "es"), o DefaultLanguage
+func Language(language string) string {
+	base := strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	if _, ok := locales[base]; ok {
+		return base
+	}
+	return DefaultLanguage
+}
+
+// FormatTime formatea t en la zona horaria loc con el formato del idioma
+func FormatTime(t time.Time, language string, loc *time.Location) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(locales[Language(language)].timeLayout)
+}
+
+// Render retorna el asunto y el cuerpo de la notificación kind en el idioma language
+func Render(kind, language string, data TemplateData) (string, string, error) {
+	msg, ok := locales[Language(language)].messages[kind]
+	if !ok {
+		return "", "", ErrUnknownKind
+	}
+
+	var subject, body strings.Builder
+	if err := msg.subject.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := msg.body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return subject.String(), body.String(), nil
+}
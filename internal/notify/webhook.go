@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader lleva la firma HMAC-SHA256 del cuerpo del webhook ("sha256=<hex>")
+const SignatureHeader = "X-Notification-Signature"
+
+// WebhookChannel entrega las notificaciones con un POST JSON a un endpoint configurado
+// (por ejemplo, un gateway de push o SMS). Respuestas fuera de 2xx cuentan como fallo
+type WebhookChannel struct {
+	url    string
+	secret func() string
+	client *http.Client
+}
+
+// NewWebhookChannel crea el canal. secret retorna la llave con la que se firma el cuerpo
+// (se lee en cada envío para tomar las rotaciones); vacía envía sin firma
+func NewWebhookChannel(url string, secret func() string, timeout time.Duration) *WebhookChannel {
+	return &WebhookChannel{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *WebhookChannel) Name() string {
+	return ChannelWebhook
+}
+
+// Send publica la notificación en el endpoint
+func (c *WebhookChannel) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key := c.secret(); key != "" {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notify: webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
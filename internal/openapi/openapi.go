@@ -0,0 +1,310 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Version es la versión de OpenAPI de los documentos generados
+const Version = "3.1.0"
+
+// Document es un documento OpenAPI 3.1
+type Document struct {
+	OpenAPI    string                        `json:"openapi"`
+	Info       Info                          `json:"info"`
+	Paths      map[string]map[string]*OpSpec `json:"paths"`
+	Components Components                    `json:"components"`
+	Security   []map[string][]string         `json:"security,omitempty"`
+}
+
+// Info describe la API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components agrupa los schemas reutilizables y los esquemas de seguridad
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describe un mecanismo de autenticación
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// OpSpec es una operación del documento (un método de un path)
+type OpSpec struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []*ParameterSpec       `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]*Response   `json:"responses"`
+	Security    *[]map[string][]string `json:"security,omitempty"`
+}
+
+// ParameterSpec es un parámetro de path o de query
+type ParameterSpec struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody es el cuerpo JSON de una operación
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response es una respuesta de una operación
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType asocia un content type con su schema
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Operation describe una ruta registrada en el router para generar el documento
+type Operation struct {
+	Summary string
+	Tags    []string
+	// Request es el modelo del cuerpo JSON (nil = sin cuerpo)
+	Request interface{}
+	// Patch es el modelo de los campos modificables con PATCH; el cuerpo se documenta como
+	// JSON Merge Patch y como JSON Patch
+	Patch interface{}
+	// Response es el modelo del campo "data" de la respuesta (nil = solo "message")
+	Response interface{}
+	// List indica que "data" es un arreglo de Response y la respuesta incluye "count"
+	List bool
+	// Raw indica que la respuesta es Response sin el sobre {data, message}; sin Response
+	// el contenido no se documenta (HTML, métricas)
+	Raw bool
+	// Status es el código de la respuesta exitosa (200 por defecto)
+	Status int
+	Query  []Parameter
+	// Public indica que la operación no requiere credenciales
+	Public bool
+}
+
+// Parameter es un parámetro de query de una operación
+type Parameter struct {
+	Name        string
+	Description string
+	// Type es el tipo JSON del parámetro: string (por defecto), integer o boolean
+	Type     string
+	Required bool
+}
+
+// Spec asocia cada ruta ("METHOD /path" con el template de mux) con su operación
+type Spec struct {
+	Title      string
+	Version    string
+	Operations map[string]Operation
+}
+
+// Generate construye el documento a partir de las rutas del router. Falla si alguna ruta
+// no tiene operación o alguna operación no tiene ruta, de modo que el documento no puede
+// divergir del router
+func (s Spec) Generate(router *mux.Router) (*Document, error) {
+	routes, err := Routes(router)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(routes); err != nil {
+		return nil, err
+	}
+
+	schemas := newSchemaRegistry()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: s.Title, Version: s.Version},
+		Paths:   make(map[string]map[string]*OpSpec),
+		Components: Components{
+			Schemas: schemas.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}},
+	}
+	for _, route := range routes {
+		op := s.Operations[route]
+		method, path, _ := strings.Cut(route, " ")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OpSpec)
+		}
+		doc.Paths[path][strings.ToLower(method)] = buildOperation(method, path, op, schemas)
+	}
+	return doc, nil
+}
+
+// check compara las rutas del router con las operaciones de la especificación
+func (s Spec) check(routes []string) error {
+	registered := make(map[string]bool, len(routes))
+	var problems []string
+	for _, route := range routes {
+		registered[route] = true
+		if _, ok := s.Operations[route]; !ok {
+			problems = append(problems, "route without operation: "+route)
+		}
+	}
+	for route := range s.Operations {
+		if !registered[route] {
+			problems = append(problems, "operation without route: "+route)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("openapi spec and router diverge:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Routes retorna las rutas del router como "METHOD /path", sin la ruta OPTIONS genérica
+func Routes(router *mux.Router) ([]string, error) {
+	var routes []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if method != http.MethodOptions {
+				routes = append(routes, method+" "+path)
+			}
+		}
+		return nil
+	})
+	sort.Strings(routes)
+	return routes, err
+}
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+func buildOperation(method, path string, op Operation, schemas *schemaRegistry) *OpSpec {
+	spec := &OpSpec{
+		OperationID: operationID(method, path),
+		Summary:     op.Summary,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*Response),
+	}
+	if op.Public {
+		spec.Security = &[]map[string][]string{}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		spec.Parameters = append(spec.Parameters, &ParameterSpec{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, param := range op.Query {
+		paramType := param.Type
+		if paramType == "" {
+			paramType = "string"
+		}
+		spec.Parameters = append(spec.Parameters, &ParameterSpec{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      &Schema{Type: paramType},
+		})
+	}
+
+	if op.Request != nil {
+		spec.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: schemas.schemaFor(op.Request)}},
+		}
+	}
+
+	if op.Patch != nil {
+		spec.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/merge-patch+json": {Schema: schemas.mergePatchSchema(op.Patch)},
+				"application/json-patch+json":  {Schema: jsonPatchSchema()},
+			},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := &Response{Description: http.StatusText(status)}
+	if !op.Raw || op.Response != nil {
+		response.Content = map[string]*MediaType{"application/json": {Schema: responseSchema(op, schemas)}}
+	}
+	spec.Responses[fmt.Sprint(status)] = response
+	if spec.RequestBody != nil || len(spec.Parameters) > 0 {
+		spec.Responses["400"] = &Response{Description: "Invalid request"}
+	}
+	if !op.Public {
+		spec.Responses["401"] = &Response{Description: "Missing or invalid credentials"}
+	}
+	return spec
+}
+
+// responseSchema arma el sobre {data, message[, count]} que usan los handlers
+func responseSchema(op Operation, schemas *schemaRegistry) *Schema {
+	if op.Raw {
+		return schemas.schemaFor(op.Response)
+	}
+	envelope := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"message": {Type: "string"}},
+	}
+	if op.Response != nil {
+		data := schemas.schemaFor(op.Response)
+		if op.List {
+			data = &Schema{Type: "array", Items: data}
+			envelope.Properties["count"] = &Schema{Type: "integer"}
+		}
+		envelope.Properties["data"] = data
+	}
+	if op.Patch != nil {
+		envelope.Properties["changed_fields"] = &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	}
+	return envelope
+}
+
+// operationID genera un identificador estable, por ejemplo GET /users/{id}/roles -> getUsersIdRoles
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '_' || r == '.'
+	}) {
+		if part == "api" || part == "v1" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
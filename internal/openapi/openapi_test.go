@@ -0,0 +1,131 @@
+package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type widget struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name" validate:"required,min=3,max=20"`
+	Email     string     `json:"email" validate:"omitempty,email"`
+	Kind      string     `json:"kind" validate:"omitempty,oneof=small large"`
+	Tags      []string   `json:"tags" validate:"omitempty,dive,oneof=a b"`
+	Count     int        `json:"count" validate:"min=0,max=10"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Secret    string     `json:"-"`
+}
+
+func newRouter() *mux.Router {
+	router := mux.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/widgets", ok).Methods("GET")
+	api.HandleFunc("/widgets", ok).Methods("POST")
+	api.HandleFunc("/widgets/{id}", ok).Methods("DELETE")
+	router.Methods(http.MethodOptions).HandlerFunc(ok)
+	return router
+}
+
+func widgetSpec() Spec {
+	return Spec{
+		Title:   "Widgets",
+		Version: "1.0.0",
+		Operations: map[string]Operation{
+			"GET /api/v1/widgets": {
+				Response: widget{}, List: true,
+				Query: []Parameter{{Name: "limit", Type: "integer"}, {Name: "q", Required: true}},
+			},
+			"POST /api/v1/widgets":        {Request: widget{}, Response: widget{}, Status: http.StatusCreated},
+			"DELETE /api/v1/widgets/{id}": {},
+		},
+	}
+}
+
+func TestGenerate_SchemasFromModels(t *testing.T) {
+	doc, err := widgetSpec().Generate(newRouter())
+	require.NoError(t, err)
+
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	schema := doc.Components.Schemas["widget"]
+	require.NotNil(t, schema)
+	assert.Equal(t, []string{"name"}, schema.Required)
+	assert.NotContains(t, schema.Properties, "Secret")
+	assert.Equal(t, 3, *schema.Properties["name"].MinLength)
+	assert.Equal(t, "email", schema.Properties["email"].Format)
+	assert.Equal(t, []string{"small", "large"}, schema.Properties["kind"].Enum)
+	assert.Equal(t, []string{"a", "b"}, schema.Properties["tags"].Items.Enum)
+	assert.Equal(t, 10.0, *schema.Properties["count"].Maximum)
+	assert.Equal(t, []string{"string", "null"}, schema.Properties["expires_at"].Type)
+
+	remove := doc.Paths["/api/v1/widgets/{id}"]["delete"]
+	require.NotNil(t, remove)
+	require.Len(t, remove.Parameters, 1)
+	assert.Equal(t, "path", remove.Parameters[0].In)
+	assert.Contains(t, doc.Paths["/api/v1/widgets"]["post"].Responses, "201")
+}
+
+func TestGenerate_FailsWhenRoutesAndSpecDiverge(t *testing.T) {
+	router := newRouter()
+	router.HandleFunc("/api/v1/gadgets", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	spec := widgetSpec()
+	spec.Operations["PUT /api/v1/widgets/{id}"] = Operation{}
+
+	_, err := spec.Generate(router)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "route without operation: GET /api/v1/gadgets")
+	assert.Contains(t, err.Error(), "operation without route: PUT /api/v1/widgets/{id}")
+}
+
+func TestValidateRequests(t *testing.T) {
+	router := newRouter()
+	doc, err := widgetSpec().Generate(router)
+	require.NoError(t, err)
+	router.Use(ValidateRequests(doc))
+
+	send := func(method, target, body string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("POST", "/api/v1/widgets", `{"name":"gear","email":"","kind":"","count":3}`))
+	assert.Equal(t, http.StatusOK, send("POST", "/api/v1/widgets", `{"name":"gear","expires_at":null,"tags":["a"]}`))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/v1/widgets", `{"email":"a@b.com"}`))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/v1/widgets", `{"name":"ge"}`))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/v1/widgets", `{"name":"gear","email":"nope"}`))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/v1/widgets", `{"name":"gear","tags":["c"]}`))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/v1/widgets", `{"name":"gear","count":"3"}`))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/v1/widgets", `{"name":"gear","count":1.5}`))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/v1/widgets", ``))
+
+	assert.Equal(t, http.StatusOK, send("GET", "/api/v1/widgets?q=x&limit=5", ""))
+	assert.Equal(t, http.StatusBadRequest, send("GET", "/api/v1/widgets?limit=5", ""))
+	assert.Equal(t, http.StatusBadRequest, send("GET", "/api/v1/widgets?q=x&limit=five", ""))
+}
+
+func TestValidateRequests_RestoresBody(t *testing.T) {
+	router := mux.NewRouter()
+	var received string
+	router.HandleFunc("/widgets", func(w http.ResponseWriter, r *http.Request) {
+		body := new(strings.Builder)
+		_, _ = io.Copy(body, r.Body)
+		received = body.String()
+	}).Methods("POST")
+	doc, err := Spec{Operations: map[string]Operation{"POST /widgets": {Request: widget{}}}}.Generate(router)
+	require.NoError(t, err)
+	router.Use(ValidateRequests(doc))
+
+	req := httptest.NewRequest("POST", "/widgets", strings.NewReader(`{"name":"gear"}`))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, `{"name":"gear"}`, received)
+}
@@ -0,0 +1,296 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema es un JSON Schema (el dialecto de OpenAPI 3.1)
+type Schema struct {
+	Dialect              string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+
+	// Closed rechaza las propiedades que no están en Properties (additionalProperties: false)
+	Closed bool `json:"-"`
+
+	// allowEmpty acepta "" aunque no cumpla las reglas del string (omitempty del validador)
+	allowEmpty bool
+}
+
+// MarshalJSON serializa el schema; Closed se escribe como additionalProperties: false
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if !s.Closed {
+		return json.Marshal(plain(s))
+	}
+	return json.Marshal(struct {
+		plain
+		AdditionalProperties bool `json:"additionalProperties"`
+	}{plain(s), false})
+}
+
+// types retorna los tipos JSON aceptados por el schema ("string" o ["string", "null"])
+func (s *Schema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaRegistry genera los schemas de los modelos y registra los structs en components
+type schemaRegistry struct {
+	components map[string]*Schema
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{components: make(map[string]*Schema)}
+}
+
+// schemaFor retorna el schema del modelo; los structs se referencian desde components
+func (r *schemaRegistry) schemaFor(model interface{}) *Schema {
+	return r.typeSchema(reflect.TypeOf(model))
+}
+
+func (r *schemaRegistry) typeSchema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema *Schema
+	switch {
+	case t == timeType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Kind() == reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return r.structSchema(t)
+		}
+		if _, ok := r.components[name]; !ok {
+			// Se registra antes de recorrer los campos para soportar tipos recursivos
+			r.components[name] = &Schema{}
+			*r.components[name] = *r.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			schema = &Schema{Type: "string", Format: "byte"}
+		} else {
+			schema = &Schema{Type: "array", Items: r.typeSchema(t.Elem())}
+		}
+	case t.Kind() == reflect.Map:
+		schema = &Schema{Type: "object", AdditionalProperties: r.typeSchema(t.Elem())}
+	case t.Kind() == reflect.Interface:
+		return &Schema{}
+	default:
+		schema = &Schema{Type: kindType(t.Kind())}
+	}
+
+	if nullable {
+		if typeName, ok := schema.Type.(string); ok {
+			schema.Type = []string{typeName, "null"}
+		}
+	}
+	return schema
+}
+
+// mergePatchSchema describe un JSON Merge Patch del modelo: todos los campos son opcionales
+// y aceptan null para vaciarlos
+func (r *schemaRegistry) mergePatchSchema(model interface{}) *Schema {
+	schema := r.structSchema(reflect.TypeOf(model))
+	schema.Required = nil
+	for _, property := range schema.Properties {
+		if typeName, ok := property.Type.(string); ok {
+			property.Type = []string{typeName, "null"}
+		}
+	}
+	return schema
+}
+
+// jsonPatchSchema describe un JSON Patch (RFC 6902)
+func jsonPatchSchema() *Schema {
+	return &Schema{
+		Type: "array",
+		Items: &Schema{
+			Type:     "object",
+			Required: []string{"op", "path"},
+			Properties: map[string]*Schema{
+				"op":    {Type: "string", Enum: []string{"add", "remove", "replace", "move", "copy", "test"}},
+				"path":  {Type: "string"},
+				"from":  {Type: "string"},
+				"value": {},
+			},
+		},
+	}
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.addFields(schema, t)
+	return schema
+}
+
+// addFields agrega los campos exportados de t al schema, aplanando los structs embebidos
+// igual que encoding/json
+func (r *schemaRegistry) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := r.typeSchema(field.Type)
+		if required := applyValidateTag(property, field.Tag.Get("validate")); required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyValidateTag traduce las reglas de go-playground/validator que tienen equivalente en
+// JSON Schema. Retorna true si el campo es requerido
+func applyValidateTag(schema *Schema, tag string) bool {
+	if tag == "" || schema.Ref != "" {
+		return strings.Contains(tag, "required")
+	}
+
+	target := schema
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			if target == schema {
+				required = true
+			}
+		case "omitempty":
+			target.allowEmpty = true
+		case "dive":
+			// Las reglas siguientes aplican a los elementos del arreglo
+			if schema.Items == nil || schema.Items.Ref != "" {
+				return required
+			}
+			target = schema.Items
+		case "email":
+			target.Format = "email"
+		case "uuid", "uuid4":
+			target.Format = "uuid"
+		case "url", "uri":
+			target.Format = "uri"
+		case "ip":
+			target.Format = "ip"
+		case "ipv4":
+			target.Format = "ipv4"
+		case "ipv6":
+			target.Format = "ipv6"
+		case "alphanum":
+			target.Pattern = "^[a-zA-Z0-9]+$"
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "min", "max", "len":
+			applyBound(target, name, param)
+		}
+	}
+	return required
+}
+
+// applyBound traduce min, max y len a longitudes, cantidad de elementos o límites numéricos
+// según el tipo del campo
+func applyBound(schema *Schema, rule, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	setMin, setMax := rule == "min" || rule == "len", rule == "max" || rule == "len"
+	intValue := int(n)
+
+	for _, typeName := range schema.types() {
+		switch typeName {
+		case "string":
+			if setMin {
+				schema.MinLength = &intValue
+			}
+			if setMax {
+				schema.MaxLength = &intValue
+			}
+		case "array":
+			if setMin {
+				schema.MinItems = &intValue
+			}
+			if setMax {
+				schema.MaxItems = &intValue
+			}
+		case "integer", "number":
+			if setMin {
+				schema.Minimum = &n
+			}
+			if setMax {
+				schema.Maximum = &n
+			}
+		}
+	}
+}
+
+func kindType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return "string"
+}
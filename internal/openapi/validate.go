@@ -0,0 +1,318 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"it-user-service/internal/logger"
+)
+
+// ValidateRequests valida los parámetros de query y el cuerpo JSON de cada request contra
+// la operación del documento que corresponde a la ruta, y responde 400 si no cumplen. Las
+// rutas sin operación en el documento pasan sin validar
+func ValidateRequests(doc *Document) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op := doc.operation(r)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err := doc.validateRequest(r, op); err != nil {
+				logger.FromContext(r.Context()).WithError(err).Warn("Request does not match the OpenAPI spec")
+				http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// operation busca la operación de la ruta de mux que atendió el request
+func (d *Document) operation(r *http.Request) *OpSpec {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return nil
+	}
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+	return d.Paths[path][strings.ToLower(r.Method)]
+}
+
+func (d *Document) validateRequest(r *http.Request, op *OpSpec) error {
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		if param.In != "query" {
+			continue
+		}
+		value, present := query.Get(param.Name), query.Has(param.Name)
+		if !present {
+			if param.Required {
+				return fmt.Errorf("query parameter %s is required", param.Name)
+			}
+			continue
+		}
+		if err := validateQueryValue(param, value); err != nil {
+			return err
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+	media := op.RequestBody.Content["application/json"]
+	if media == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("could not read body")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return fmt.Errorf("request body is required")
+		}
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("body is not valid JSON")
+	}
+	return d.validateValue("body", media.Schema, value)
+}
+
+func validateQueryValue(param *ParameterSpec, value string) error {
+	for _, typeName := range param.Schema.types() {
+		switch typeName {
+		case "integer":
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return fmt.Errorf("query parameter %s must be an integer", param.Name)
+			}
+		case "boolean":
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("query parameter %s must be a boolean", param.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// resolve sigue las referencias a components/schemas
+func (d *Document) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+func (d *Document) validateValue(path string, schema *Schema, value interface{}) error {
+	schema = d.resolve(schema)
+	if schema == nil || schema.Type == nil {
+		return nil
+	}
+	types := schema.types()
+
+	if value == nil {
+		if hasType(types, "null") {
+			return nil
+		}
+		return fmt.Errorf("%s must not be null", path)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if !hasType(types, "object") {
+			return typeError(path, types)
+		}
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s.%s is required", path, name)
+			}
+		}
+		for name, property := range schema.Properties {
+			if fieldValue, ok := v[name]; ok {
+				if err := d.validateValue(path+"."+name, property, fieldValue); err != nil {
+					return err
+				}
+			}
+		}
+		if schema.Closed {
+			for _, name := range sortedKeys(v) {
+				if _, known := schema.Properties[name]; !known {
+					return fmt.Errorf("%s.%s is not allowed", path, name)
+				}
+			}
+		}
+		if schema.AdditionalProperties != nil {
+			for name, fieldValue := range v {
+				if _, known := schema.Properties[name]; known {
+					continue
+				}
+				if err := d.validateValue(path+"."+name, schema.AdditionalProperties, fieldValue); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if !hasType(types, "array") {
+			return typeError(path, types)
+		}
+		if schema.MinItems != nil && len(v) < *schema.MinItems {
+			return fmt.Errorf("%s must have at least %d items", path, *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+			return fmt.Errorf("%s must have at most %d items", path, *schema.MaxItems)
+		}
+		for i, item := range v {
+			if err := d.validateValue(fmt.Sprintf("%s[%d]", path, i), schema.Items, item); err != nil {
+				return err
+			}
+		}
+	case string:
+		if !hasType(types, "string") {
+			return typeError(path, types)
+		}
+		return validateString(path, schema, v)
+	case json.Number:
+		return validateNumber(path, schema, types, v)
+	case bool:
+		if !hasType(types, "boolean") {
+			return typeError(path, types)
+		}
+	}
+	return nil
+}
+
+// ValidateValue valida un valor JSON (decodificado con UseNumber) contra schema, que no
+// puede tener referencias a components. path nombra el valor en los errores
+func ValidateValue(path string, schema *Schema, value interface{}) error {
+	return (&Document{}).validateValue(path, schema, value)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func validateString(path string, schema *Schema, value string) error {
+	if value == "" && schema.allowEmpty {
+		return nil
+	}
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		return fmt.Errorf("%s must be at least %d characters", path, *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return fmt.Errorf("%s must be at most %d characters", path, *schema.MaxLength)
+	}
+	if len(schema.Enum) > 0 && !hasType(schema.Enum, value) {
+		return fmt.Errorf("%s must be one of: %s", path, strings.Join(schema.Enum, ", "))
+	}
+	if schema.Pattern != "" && !compilePattern(schema.Pattern).MatchString(value) {
+		return fmt.Errorf("%s does not match pattern %s", path, schema.Pattern)
+	}
+	if !validFormat(schema.Format, value) {
+		return fmt.Errorf("%s must be a valid %s", path, schema.Format)
+	}
+	return nil
+}
+
+func validateNumber(path string, schema *Schema, types []string, value json.Number) error {
+	n, err := value.Float64()
+	if err != nil {
+		return typeError(path, types)
+	}
+	switch {
+	case hasType(types, "number"):
+	case hasType(types, "integer"):
+		if _, err := value.Int64(); err != nil {
+			return fmt.Errorf("%s must be an integer", path)
+		}
+	default:
+		return typeError(path, types)
+	}
+	if schema.Minimum != nil && n < *schema.Minimum {
+		return fmt.Errorf("%s must be at least %v", path, *schema.Minimum)
+	}
+	if schema.Maximum != nil && n > *schema.Maximum {
+		return fmt.Errorf("%s must be at most %v", path, *schema.Maximum)
+	}
+	return nil
+}
+
+func validFormat(format, value string) bool {
+	switch format {
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uuid":
+		_, err := uuid.Parse(value)
+		return err == nil
+	case "uri":
+		u, err := url.ParseRequestURI(value)
+		return err == nil && u.Scheme != ""
+	case "ip":
+		return net.ParseIP(value) != nil
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	}
+	return true
+}
+
+var patterns sync.Map
+
+func compilePattern(pattern string) *regexp.Regexp {
+	if compiled, ok := patterns.Load(pattern); ok {
+		return compiled.(*regexp.Regexp)
+	}
+	compiled := regexp.MustCompile(pattern)
+	patterns.Store(pattern, compiled)
+	return compiled
+}
+
+func hasType(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func typeError(path string, types []string) error {
+	return fmt.Errorf("%s must be of type %s", path, strings.Join(types, " or "))
+}
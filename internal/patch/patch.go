@@ -0,0 +1,434 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Content types de los patches soportados
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupportedContentType indica un content type que no es un patch soportado
+	ErrUnsupportedContentType = errors.New("unsupported patch content type")
+	// ErrInvalidPatch indica un patch mal formado o que no se puede aplicar al documento
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed indica que falló una operación test de un JSON Patch
+	ErrTestFailed = errors.New("patch test operation failed")
+)
+
+// Apply aplica body sobre doc según el content type: JSON Merge Patch (RFC 7396) para
+// application/merge-patch+json y application/json, JSON Patch (RFC 6902) para
+// application/json-patch+json
+func Apply(contentType string, doc, body []byte) ([]byte, error) {
+	mediaType := MergePatchContentType
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, ErrUnsupportedContentType
+		}
+		mediaType = parsed
+	}
+
+	switch mediaType {
+	case MergePatchContentType, "application/json":
+		return MergePatch(doc, body)
+	case JSONPatchContentType:
+		return JSONPatch(doc, body)
+	}
+	return nil, ErrUnsupportedContentType
+}
+
+// MergePatch aplica un JSON Merge Patch (RFC 7396): los miembros del patch reemplazan a los
+// del documento, los objetos se mezclan recursivamente y null elimina el miembro
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	mergeDoc, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(merge(target, mergeDoc))
+}
+
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// Operation es una operación de un JSON Patch
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch aplica las operaciones de un JSON Patch (RFC 6902) en orden. Si alguna falla el
+// documento no se modifica
+func JSONPatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	var operations []Operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: expected an array of operations", ErrInvalidPatch)
+	}
+
+	for i, operation := range operations {
+		target, err = applyOperation(target, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyOperation(doc interface{}, operation Operation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	value := func() (interface{}, error) {
+		if operation.Value == nil {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+		return decode(operation.Value)
+	}
+
+	switch operation.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "remove":
+		updated, _, err := remove(doc, path)
+		return updated, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return v, nil
+		}
+		updated, _, err := remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(updated, path, v)
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+			}
+			updated, moved, err := remove(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(updated, path, moved)
+		}
+		v, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		copied, err := deepCopy(v)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, copied)
+	case "test":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		current, err := get(doc, path)
+		if err != nil || !equal(current, v) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, operation.Op)
+}
+
+// parsePointer separa un JSON Pointer (RFC 6901) en sus tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+			}
+			node = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+		}
+	}
+	return node, nil
+}
+
+func add(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, last := path[0], len(path) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if last {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+		}
+		updated, err := add(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = updated
+		return n, nil
+	case []interface{}:
+		if last {
+			index := len(n)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(n)); err != nil {
+					return nil, err
+				}
+			}
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = value
+			return n, nil
+		}
+		index, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := add(n[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[index] = updated
+		return n, nil
+	}
+	return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+}
+
+// remove elimina el valor de path y lo retorna junto con el documento actualizado
+func remove(node interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	token, last := path[0], len(path) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+		}
+		if last {
+			delete(n, token)
+			return n, child, nil
+		}
+		updated, removed, err := remove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = updated
+		return n, removed, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := n[index]
+			return append(n[:index], n[index+1:]...), removed, nil
+		}
+		updated, removed, err := remove(n[index], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[index] = updated
+		return n, removed, nil
+	}
+	return nil, nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	return index, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ChangedFields compara dos objetos JSON y retorna, ordenados, los miembros de primer nivel
+// que se agregaron, eliminaron o cambiaron de valor
+func ChangedFields(before, after []byte) ([]string, error) {
+	beforeDoc, err := decodeObject(before)
+	if err != nil {
+		return nil, err
+	}
+	afterDoc, err := decodeObject(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+	for name, value := range afterDoc {
+		if previous, ok := beforeDoc[name]; !ok || !equal(previous, value) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range beforeDoc {
+		if _, ok := afterDoc[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	value, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	return object, nil
+}
+
+func deepCopy(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+// equal compara dos valores JSON; los números se comparan por valor (1 y 1.0 son iguales)
+func equal(a, b interface{}) bool {
+	if x, ok := a.(json.Number); ok {
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	}
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for name, value := range x {
+			other, ok := y[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
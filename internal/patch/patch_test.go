@@ -0,0 +1,67 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	doc := []byte(`{"bio":"hello","website":"https://a.dev","prefs":{"theme":"dark","lang":"es"}}`)
+
+	patched, err := MergePatch(doc, []byte(`{"bio":null,"location":"Lima","prefs":{"lang":null}}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"website":"https://a.dev","location":"Lima","prefs":{"theme":"dark"}}`, string(patched))
+
+	_, err = MergePatch(doc, []byte(`{"bio":`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestJSONPatch(t *testing.T) {
+	doc := []byte(`{"name":"a","tags":["x","y"],"meta":{"n":1}}`)
+
+	patched, err := JSONPatch(doc, []byte(`[
+		{"op":"test","path":"/meta/n","value":1.0},
+		{"op":"replace","path":"/name","value":"b"},
+		{"op":"add","path":"/tags/-","value":"z"},
+		{"op":"remove","path":"/tags/0"},
+		{"op":"copy","from":"/name","path":"/alias"},
+		{"op":"move","from":"/meta/n","path":"/count"}
+	]`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"b","alias":"b","tags":["y","z"],"meta":{},"count":1}`, string(patched))
+
+	_, err = JSONPatch(doc, []byte(`[{"op":"test","path":"/name","value":"other"}]`))
+	assert.ErrorIs(t, err, ErrTestFailed)
+
+	_, err = JSONPatch(doc, []byte(`[{"op":"replace","path":"/missing","value":1}]`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	_, err = JSONPatch(doc, []byte(`{"op":"remove","path":"/name"}`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestApply_ContentTypes(t *testing.T) {
+	doc := []byte(`{"bio":"hello"}`)
+
+	patched, err := Apply("application/merge-patch+json; charset=utf-8", doc, []byte(`{"bio":null}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(patched))
+
+	patched, err = Apply(JSONPatchContentType, doc, []byte(`[{"op":"remove","path":"/bio"}]`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(patched))
+
+	_, err = Apply("text/plain", doc, []byte(`bio`))
+	assert.ErrorIs(t, err, ErrUnsupportedContentType)
+}
+
+func TestChangedFields(t *testing.T) {
+	changed, err := ChangedFields(
+		[]byte(`{"a":1,"b":"x","c":true,"d":"gone"}`),
+		[]byte(`{"a":1.0,"b":"y","c":true,"e":"new"}`),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "d", "e"}, changed)
+}
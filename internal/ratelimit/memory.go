@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryStore guarda los buckets en memoria. Los límites no se comparten entre réplicas
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow consume un token del bucket key
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	tokens, result := take(b.tokens, b.last, now, limit)
+	b.tokens, b.last = tokens, now
+	return result, nil
+}
+
+// StartCleanup descarta periódicamente los buckets inactivos hasta que ctx se cancele
+func (s *MemoryStore) StartCleanup(ctx context.Context, idle time.Duration) {
+	go func() {
+		ticker := time.NewTicker(idle)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.cleanup(idle)
+			}
+		}
+	}()
+}
+
+func (s *MemoryStore) cleanup(idle time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-idle)
+	for key, b := range s.buckets {
+		if b.last.Before(cutoff) {
+			delete(s.buckets, key)
+		}
+	}
+}
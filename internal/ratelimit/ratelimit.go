@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit es un token bucket: Rate tokens por segundo con capacidad Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled indica si el límite aplica (Rate <= 0 deshabilita el rate limiting)
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result es el resultado de consumir un token
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter es cuánto esperar hasta que haya un token disponible (solo si !Allowed)
+	RetryAfter time.Duration
+	// ResetAfter es cuánto falta para que el bucket vuelva a estar lleno
+	ResetAfter time.Duration
+}
+
+// Store guarda el estado de los buckets. MemoryStore sirve para una réplica;
+// RedisStore comparte los límites entre réplicas
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// RouteLimit sobrescribe el límite por defecto para una ruta (template de mux)
+type RouteLimit struct {
+	Method string
+	Path   string
+	Limit  Limit
+}
+
+// ParseRouteLimits lee overrides con el formato "METHOD /path=rps:burst,..."
+func ParseRouteLimits(value string) ([]RouteLimit, error) {
+	var routes []RouteLimit
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, limit, ok := strings.Cut(entry, "=")
+		method, path, okRoute := strings.Cut(strings.TrimSpace(route), " ")
+		rps, burst, okLimit := strings.Cut(limit, ":")
+		if !ok || !okRoute || !okLimit {
+			return nil, fmt.Errorf("invalid route limit %q, expected \"METHOD /path=rps:burst\"", entry)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rps), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate in route limit %q: %w", entry, err)
+		}
+		burstValue, err := strconv.Atoi(strings.TrimSpace(burst))
+		if err != nil {
+			return nil, fmt.Errorf("invalid burst in route limit %q: %w", entry, err)
+		}
+
+		routes = append(routes, RouteLimit{
+			Method: strings.ToUpper(strings.TrimSpace(method)),
+			Path:   strings.TrimSpace(path),
+			Limit:  Limit{Rate: rate, Burst: burstValue},
+		})
+	}
+	return routes, nil
+}
+
+// take aplica el algoritmo de token bucket sobre el estado (tokens, last) y retorna el
+// nuevo estado. Es la misma lógica que ejecuta el script de RedisStore
+func take(tokens float64, last, now time.Time, limit Limit) (float64, Result) {
+	capacity := float64(limit.Burst)
+	elapsed := now.Sub(last).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*limit.Rate)
+	}
+
+	result := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.ResetAfter = secondsToDuration((capacity - tokens) / limit.Rate)
+	return tokens, result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
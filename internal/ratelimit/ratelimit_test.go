@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreLimitsAndRefills(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		result, err := store.Allow(context.Background(), "ip:1.2.3.4", limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, _ := store.Allow(context.Background(), "ip:1.2.3.4", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	now = now.Add(time.Second)
+	result, _ = store.Allow(context.Background(), "ip:1.2.3.4", limit)
+	assert.True(t, result.Allowed)
+}
+
+func TestRedisStoreSharesBuckets(t *testing.T) {
+	server := miniredis.RunT(t)
+	limit := Limit{Rate: 0.5, Burst: 3}
+
+	// Dos réplicas con su propio cliente comparten el mismo bucket
+	replicaA := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "ratelimit:")
+	replicaB := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "ratelimit:")
+
+	for _, store := range []*RedisStore{replicaA, replicaB, replicaA} {
+		result, err := store.Allow(context.Background(), "user:1", limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, err := replicaB.Allow(context.Background(), "user:1", limit)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 3, result.Limit)
+	assert.True(t, result.RetryAfter > 0)
+
+	result, err = replicaB.Allow(context.Background(), "user:2", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestParseRouteLimits(t *testing.T) {
+	routes, err := ParseRouteLimits("GET /api/v1/users/search=5:10, post /api/v1/users/create=0.5:2")
+	assert.NoError(t, err)
+	assert.Equal(t, []RouteLimit{
+		{Method: "GET", Path: "/api/v1/users/search", Limit: Limit{Rate: 5, Burst: 10}},
+		{Method: "POST", Path: "/api/v1/users/create", Limit: Limit{Rate: 0.5, Burst: 2}},
+	}, routes)
+
+	_, err = ParseRouteLimits("/api/v1/users=5")
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository crea el repositorio de API keys
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepositoryInterface {
+	return &APIKeyRepository{db: db}
+}
+
+// Create guarda una nueva API key (solo su hash)
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByID obtiene una API key por su ID
+func (r *APIKeyRepository) GetByID(ctx context.Context, id string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash obtiene una API key por el hash de la llave presentada
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetAll obtiene todas las API keys, de la más reciente a la más antigua
+func (r *APIKeyRepository) GetAll(ctx context.Context, includeRevoked bool) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	query := r.db.WithContext(ctx)
+	if !includeRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+	err := query.Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Revoke revoca una API key. Retorna gorm.ErrRecordNotFound si no existe o ya estaba revocada
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed registra el último uso de una API key
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id, ip string, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"last_used_at": usedAt,
+			"last_used_ip": ip,
+		}).Error
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+
+	"it-user-service/internal/cache"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+)
+
+// CachedRoleRepository agrega un caché read-through a los roles de cada usuario.
+// UserHasRole y UserHasAnyRole se resuelven con los roles cacheados
+type CachedRoleRepository struct {
+	RoleRepositoryInterface
+	loader *cache.Loader
+}
+
+// NewCachedRoleRepository envuelve inner con el caché de loader
+func NewCachedRoleRepository(inner RoleRepositoryInterface, loader *cache.Loader) *CachedRoleRepository {
+	return &CachedRoleRepository{
+		RoleRepositoryInterface: inner,
+		loader:                  loader,
+	}
+}
+
+func userRolesCacheKey(userID string) string {
+	return "roles:user:" + userID
+}
+
+// GetUserRoles obtiene los roles de un usuario desde el caché o la base de datos
+func (r *CachedRoleRepository) GetUserRoles(ctx context.Context, userID string) ([]*models.UserRole, error) {
+	var userRoles []*models.UserRole
+	err := r.loader.Load(ctx, userRolesCacheKey(userID), &userRoles, func(ctx context.Context) (interface{}, error) {
+		return r.RoleRepositoryInterface.GetUserRoles(ctx, userID)
+	})
+	return userRoles, err
+}
+
+// UserHasRole verifica si un usuario tiene un rol específico
+func (r *CachedRoleRepository) UserHasRole(ctx context.Context, userID string, roleName string) (bool, error) {
+	return r.UserHasAnyRole(ctx, userID, []string{roleName})
+}
+
+// UserHasAnyRole verifica si un usuario tiene alguno de los roles especificados
+func (r *CachedRoleRepository) UserHasAnyRole(ctx context.Context, userID string, roleNames []string) (bool, error) {
+	userRoles, err := r.GetUserRoles(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, userRole := range userRoles {
+		for _, roleName := range roleNames {
+			if userRole.Role == roleName {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// AssignRoleToUser asigna un rol e invalida los roles cacheados del usuario
+func (r *CachedRoleRepository) AssignRoleToUser(ctx context.Context, userID string, roleName string) error {
+	err := r.RoleRepositoryInterface.AssignRoleToUser(ctx, userID, roleName)
+	r.loader.Invalidate(context.WithoutCancel(ctx), userRolesCacheKey(userID))
+	return err
+}
+
+// RemoveRoleFromUser remueve un rol e invalida los roles cacheados del usuario
+func (r *CachedRoleRepository) RemoveRoleFromUser(ctx context.Context, userID string, roleName string) error {
+	err := r.RoleRepositoryInterface.RemoveRoleFromUser(ctx, userID, roleName)
+	r.loader.Invalidate(context.WithoutCancel(ctx), userRolesCacheKey(userID))
+	return err
+}
+
+// UpdateRole actualiza el rol e invalida los roles cacheados de los usuarios que lo tienen
+func (r *CachedRoleRepository) UpdateRole(ctx context.Context, role *models.Role) error {
+	keys := r.holderKeys(ctx, role.ID)
+	err := r.RoleRepositoryInterface.UpdateRole(ctx, role)
+	r.loader.Invalidate(context.WithoutCancel(ctx), keys...)
+	return err
+}
+
+// DeleteRole elimina el rol e invalida los roles cacheados de los usuarios que lo tenían
+func (r *CachedRoleRepository) DeleteRole(ctx context.Context, id uint) error {
+	keys := r.holderKeys(ctx, id)
+	err := r.RoleRepositoryInterface.DeleteRole(ctx, id)
+	r.loader.Invalidate(context.WithoutCancel(ctx), keys...)
+	return err
+}
+
+// holderKeys retorna las claves de caché de los usuarios que tienen el rol. Se buscan
+// antes de modificarlo porque después el rol puede no existir
+func (r *CachedRoleRepository) holderKeys(ctx context.Context, roleID uint) []string {
+	role, err := r.RoleRepositoryInterface.GetRoleByID(ctx, roleID)
+	if err != nil {
+		return nil
+	}
+	users, err := r.RoleRepositoryInterface.GetUsersWithRole(ctx, role.Name)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("role", role.Name).Error("Failed to list role holders for cache invalidation")
+		return nil
+	}
+	keys := make([]string, 0, len(users))
+	for _, user := range users {
+		keys = append(keys, userRolesCacheKey(user.ID))
+	}
+	return keys
+}
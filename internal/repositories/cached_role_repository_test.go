@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"it-user-service/internal/cache"
+	"it-user-service/internal/models"
+)
+
+type memoryRoleRepository struct {
+	RoleRepositoryInterface
+	roles     map[uint]*models.Role
+	userRoles map[string][]string
+}
+
+func (r *memoryRoleRepository) GetRoleByID(ctx context.Context, id uint) (*models.Role, error) {
+	role, ok := r.roles[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return role, nil
+}
+
+func (r *memoryRoleRepository) GetUserRoles(ctx context.Context, userID string) ([]*models.UserRole, error) {
+	var userRoles []*models.UserRole
+	for _, role := range r.userRoles[userID] {
+		userRoles = append(userRoles, &models.UserRole{UserID: userID, Role: role})
+	}
+	return userRoles, nil
+}
+
+func (r *memoryRoleRepository) GetUsersWithRole(ctx context.Context, roleName string) ([]*models.User, error) {
+	var users []*models.User
+	for userID, roles := range r.userRoles {
+		for _, role := range roles {
+			if role == roleName {
+				users = append(users, &models.User{ID: userID})
+			}
+		}
+	}
+	return users, nil
+}
+
+func (r *memoryRoleRepository) DeleteRole(ctx context.Context, id uint) error {
+	name := r.roles[id].Name
+	delete(r.roles, id)
+	for userID, roles := range r.userRoles {
+		kept := roles[:0]
+		for _, role := range roles {
+			if role != name {
+				kept = append(kept, role)
+			}
+		}
+		r.userRoles[userID] = kept
+	}
+	return nil
+}
+
+func TestCachedRoleRepository_DeleteRoleInvalidatesHolders(t *testing.T) {
+	ctx := context.Background()
+	inner := &memoryRoleRepository{
+		roles:     map[uint]*models.Role{1: {ID: 1, Name: "admin"}},
+		userRoles: map[string][]string{"u1": {"admin", "editor"}},
+	}
+	repo := NewCachedRoleRepository(inner, cache.NewLoader(cache.NewLRU(10), time.Minute, time.Minute, gorm.ErrRecordNotFound))
+
+	hasAdmin, err := repo.UserHasRole(ctx, "u1", "admin")
+	require.NoError(t, err)
+	assert.True(t, hasAdmin)
+
+	require.NoError(t, repo.DeleteRole(ctx, 1))
+
+	hasAdmin, err = repo.UserHasRole(ctx, "u1", "admin")
+	require.NoError(t, err)
+	assert.False(t, hasAdmin)
+}
@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"it-user-service/internal/cache"
+	"it-user-service/internal/models"
+)
+
+// CachedUserRepository agrega un caché read-through a GetByID y GetByFirebaseID.
+// La clave por Firebase ID solo guarda el ID del usuario, de modo que invalidar el
+// usuario por ID basta para ambas búsquedas
+type CachedUserRepository struct {
+	UserRepositoryInterface
+	loader *cache.Loader
+}
+
+// NewCachedUserRepository envuelve inner con el caché de loader
+func NewCachedUserRepository(inner UserRepositoryInterface, loader *cache.Loader) *CachedUserRepository {
+	return &CachedUserRepository{
+		UserRepositoryInterface: inner,
+		loader:                  loader,
+	}
+}
+
+func userCacheKey(id string) string {
+	return "user:id:" + id
+}
+
+func firebaseCacheKey(firebaseID string) string {
+	return "user:firebase:" + firebaseID
+}
+
+// GetByID obtiene un usuario por su ID desde el caché o la base de datos
+func (r *CachedUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	var user models.User
+	err := r.loader.Load(ctx, userCacheKey(id), &user, func(ctx context.Context) (interface{}, error) {
+		return r.UserRepositoryInterface.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByFirebaseID obtiene un usuario por su Firebase ID desde el caché o la base de datos
+func (r *CachedUserRepository) GetByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error) {
+	var id string
+	err := r.loader.Load(ctx, firebaseCacheKey(firebaseID), &id, func(ctx context.Context) (interface{}, error) {
+		user, err := r.UserRepositoryInterface.GetByFirebaseID(ctx, firebaseID)
+		if err != nil {
+			return nil, err
+		}
+		return user.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.GetByID(ctx, id)
+	if err == nil && user.FirebaseID != firebaseID {
+		// El Firebase ID del usuario cambió desde que se cacheó la referencia
+		r.loader.Invalidate(context.WithoutCancel(ctx), firebaseCacheKey(firebaseID))
+		return r.UserRepositoryInterface.GetByFirebaseID(ctx, firebaseID)
+	}
+	return user, err
+}
+
+// Create crea el usuario y descarta las búsquedas negativas cacheadas
+func (r *CachedUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := r.UserRepositoryInterface.Create(ctx, user); err != nil {
+		return err
+	}
+	r.loader.Invalidate(context.WithoutCancel(ctx), userCacheKey(user.ID), firebaseCacheKey(user.FirebaseID))
+	return nil
+}
+
+// Update actualiza el usuario e invalida su entrada
+func (r *CachedUserRepository) Update(ctx context.Context, user *models.User) error {
+	err := r.UserRepositoryInterface.Update(ctx, user)
+	r.loader.Invalidate(context.WithoutCancel(ctx), userCacheKey(user.ID), firebaseCacheKey(user.FirebaseID))
+	return err
+}
+
+// Delete elimina el usuario e invalida su entrada
+func (r *CachedUserRepository) Delete(ctx context.Context, id string) error {
+	err := r.UserRepositoryInterface.Delete(ctx, id)
+	r.InvalidateUser(ctx, id)
+	return err
+}
+
+// UpdateLoginInfo actualiza la información de login e invalida la entrada del usuario
+func (r *CachedUserRepository) UpdateLoginInfo(ctx context.Context, id string, loginIP, loginDevice string) error {
+	err := r.UserRepositoryInterface.UpdateLoginInfo(ctx, id, loginIP, loginDevice)
+	r.InvalidateUser(ctx, id)
+	return err
+}
+
+// InvalidateUser descarta el usuario cacheado. Lo usan los repositorios que modifican
+// la tabla users por fuera de este repositorio (historial de logins, estadísticas).
+// La invalidación se hace aunque ctx ya esté cancelado para no dejar entradas viejas
+func (r *CachedUserRepository) InvalidateUser(ctx context.Context, id string) {
+	r.loader.Invalidate(context.WithoutCancel(ctx), userCacheKey(id))
+}
+
+// UserInvalidator descarta usuarios cacheados
+type UserInvalidator interface {
+	InvalidateUser(ctx context.Context, id string)
+}
+
+// invalidatingLoginEventRepository invalida el usuario cacheado al registrar un login,
+// ya que Record actualiza los contadores de login en users
+type invalidatingLoginEventRepository struct {
+	LoginEventRepositoryInterface
+	users UserInvalidator
+}
+
+// WithLoginUserInvalidation envuelve el repositorio de logins para invalidar el caché de usuarios
+func WithLoginUserInvalidation(inner LoginEventRepositoryInterface, users UserInvalidator) LoginEventRepositoryInterface {
+	return &invalidatingLoginEventRepository{LoginEventRepositoryInterface: inner, users: users}
+}
+
+func (r *invalidatingLoginEventRepository) Record(ctx context.Context, event *models.LoginEvent) error {
+	err := r.LoginEventRepositoryInterface.Record(ctx, event)
+	r.users.InvalidateUser(ctx, event.UserID)
+	return err
+}
+
+func (r *invalidatingLoginEventRepository) RecordWithSession(ctx context.Context, event *models.LoginEvent, session *models.Session) error {
+	err := r.LoginEventRepositoryInterface.RecordWithSession(ctx, event, session)
+	r.users.InvalidateUser(ctx, event.UserID)
+	return err
+}
+
+// invalidatingProfileRepository invalida el usuario cacheado en los métodos de perfil
+// que también actualizan users
+type invalidatingProfileRepository struct {
+	ProfileRepositoryInterface
+	users UserInvalidator
+}
+
+// WithProfileUserInvalidation envuelve el repositorio de perfiles para invalidar el caché de usuarios
+func WithProfileUserInvalidation(inner ProfileRepositoryInterface, users UserInvalidator) ProfileRepositoryInterface {
+	return &invalidatingProfileRepository{ProfileRepositoryInterface: inner, users: users}
+}
+
+func (r *invalidatingProfileRepository) IncrementLoginCount(ctx context.Context, userID string) error {
+	err := r.ProfileRepositoryInterface.IncrementLoginCount(ctx, userID)
+	r.users.InvalidateUser(ctx, userID)
+	return err
+}
+
+func (r *invalidatingProfileRepository) UpdateLastLogin(ctx context.Context, userID string) error {
+	err := r.ProfileRepositoryInterface.UpdateLastLogin(ctx, userID)
+	r.users.InvalidateUser(ctx, userID)
+	return err
+}
+
+// invalidatingUserStatusRepository invalida el usuario cacheado al cambiar su estado
+type invalidatingUserStatusRepository struct {
+	UserStatusRepositoryInterface
+	users UserInvalidator
+}
+
+// WithStatusUserInvalidation envuelve el repositorio de estados para invalidar el caché de usuarios
+func WithStatusUserInvalidation(inner UserStatusRepositoryInterface, users UserInvalidator) UserStatusRepositoryInterface {
+	return &invalidatingUserStatusRepository{UserStatusRepositoryInterface: inner, users: users}
+}
+
+func (r *invalidatingUserStatusRepository) Transition(ctx context.Context, user *models.User, change *models.UserStatusChange) error {
+	err := r.UserStatusRepositoryInterface.Transition(ctx, user, change)
+	r.users.InvalidateUser(ctx, user.ID)
+	return err
+}
+
+// invalidatingEmailChangeRepository invalida el usuario cacheado al confirmar un cambio de email
+type invalidatingEmailChangeRepository struct {
+	EmailChangeRepositoryInterface
+	users UserInvalidator
+}
+
+// WithEmailChangeUserInvalidation envuelve el repositorio de cambios de email para invalidar el caché de usuarios
+func WithEmailChangeUserInvalidation(inner EmailChangeRepositoryInterface, users UserInvalidator) EmailChangeRepositoryInterface {
+	return &invalidatingEmailChangeRepository{EmailChangeRepositoryInterface: inner, users: users}
+}
+
+func (r *invalidatingEmailChangeRepository) Confirm(ctx context.Context, change *models.EmailChange, confirmedAt time.Time) (*models.User, *models.EmailHistory, error) {
+	user, history, err := r.EmailChangeRepositoryInterface.Confirm(ctx, change, confirmedAt)
+	r.users.InvalidateUser(ctx, change.UserID)
+	return user, history, err
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+)
+
+var (
+	// ErrEmailChangeNotPending indica que el cambio ya se confirmó o fue reemplazado
+	ErrEmailChangeNotPending = errors.New("email change is not pending")
+	// ErrEmailTaken indica que otro usuario ya usa el email
+	ErrEmailTaken = errors.New("email is already in use")
+)
+
+type EmailChangeRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailChangeRepository crea el repositorio de cambios de email y su historial
+func NewEmailChangeRepository(db *gorm.DB) EmailChangeRepositoryInterface {
+	return &EmailChangeRepository{db: db}
+}
+
+// Create registra un cambio de email y cancela los cambios pendientes anteriores del
+// usuario, de modo que solo el último token se puede confirmar
+func (r *EmailChangeRepository) Create(ctx context.Context, change *models.EmailChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.EmailChange{}).
+			Where("user_id = ? AND confirmed_at IS NULL AND canceled_at IS NULL", change.UserID).
+			Update("canceled_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// GetByID obtiene un cambio de email por su ID
+func (r *EmailChangeRepository) GetByID(ctx context.Context, id string) (*models.EmailChange, error) {
+	var change models.EmailChange
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&change).Error
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// Confirm aplica el cambio en una transacción: lo marca como confirmado (una sola vez),
+// reemplaza el email del usuario marcándolo como verificado y registra el email anterior
+// en el historial. Retorna el usuario actualizado y el registro del historial
+func (r *EmailChangeRepository) Confirm(ctx context.Context, change *models.EmailChange, confirmedAt time.Time) (*models.User, *models.EmailHistory, error) {
+	var user models.User
+	var history *models.EmailHistory
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.EmailChange{}).
+			Where("id = ? AND confirmed_at IS NULL AND canceled_at IS NULL", change.ID).
+			Update("confirmed_at", confirmedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmailChangeNotPending
+		}
+
+		if err := tx.Where("id = ?", change.UserID).First(&user).Error; err != nil {
+			return err
+		}
+		var taken int64
+		err := tx.Model(&models.User{}).
+			Where("email = ? AND id <> ?", change.NewEmail, change.UserID).
+			Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrEmailTaken
+		}
+
+		history = &models.EmailHistory{
+			UserID:        user.ID,
+			PreviousEmail: user.Email,
+			NewEmail:      change.NewEmail,
+			EmailChangeID: change.ID,
+		}
+		user.Email = change.NewEmail
+		user.EmailVerified = true
+		if err := updateVersioned(tx, &user, &user.Version); err != nil {
+			return err
+		}
+		return tx.Create(history).Error
+	})
+	if err != nil {
+		// Otro usuario tomó el email entre la verificación y el update (índice único)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, nil, ErrEmailTaken
+		}
+		return nil, nil, err
+	}
+	change.ConfirmedAt = &confirmedAt
+	return &user, history, nil
+}
+
+// GetHistory obtiene los cambios de email de un usuario, del más reciente al más antiguo
+func (r *EmailChangeRepository) GetHistory(ctx context.Context, userID string, limit, offset int) ([]models.EmailHistory, error) {
+	var history []models.EmailHistory
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("changed_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&history).Error
+	return history, err
+}
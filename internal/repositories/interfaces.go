@@ -1,24 +1,29 @@
 package repositories
 
-import "it-user-service/internal/models"
+import (
+	"context"
+	"time"
+
+	"it-user-service/internal/models"
+)
 
 // UserRepositoryInterface define los métodos para el repositorio de usuarios
 type UserRepositoryInterface interface {
 	// CRUD básico
-	GetByID(id string) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetByFirebaseID(firebaseID string) (*models.User, error)
-	GetByUsername(username string) (*models.User, error)
-	GetAll(limit, offset int) ([]models.User, error)
-	Create(user *models.User) error
-	Update(user *models.User) error
-	Delete(id string) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	GetAll(ctx context.Context, limit, offset int) ([]models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id string) error
 	
 	// Métodos específicos
-	UpdateLoginInfo(id string, loginIP, loginDevice string) error
-	GetActiveUsers() ([]models.User, error)
-	SearchUsers(query string, limit, offset int) ([]models.User, error)
-	CountUsers() (int64, error)
+	UpdateLoginInfo(ctx context.Context, id string, loginIP, loginDevice string) error
+	GetActiveUsers(ctx context.Context) ([]models.User, error)
+	SearchUsers(ctx context.Context, query string, limit, offset int) ([]models.User, error)
+	CountUsers(ctx context.Context) (int64, error)
 }
 
 
@@ -55,36 +60,103 @@ type UserStatsRepositoryInterface interface {
 
 // ProfileRepositoryInterface define los métodos para el repositorio de perfiles
 type ProfileRepositoryInterface interface {
-	GetByUserID(userID string) (*models.UserProfile, error)
-	Create(profile *models.UserProfile) error
-	Update(profile *models.UserProfile) error
-	Delete(userID string) error
-	CreateSettings(settings *models.UserSettings) error
-	GetSettingsByUserID(userID string) (*models.UserSettings, error)
-	UpdateSettings(settings *models.UserSettings) error
-	DeleteSettings(userID string) error
-	CreateStats(stats *models.UserStats) error
-	GetStatsByUserID(userID string) (*models.UserStats, error)
-	UpdateStats(stats *models.UserStats) error
-	DeleteStats(userID string) error
-	IncrementLoginCount(userID string) error
-	UpdateLastLogin(userID string) error
-	IncrementProfileViews(userID string) error
-	UpdateLastActivity(userID string) error
+	GetByUserID(ctx context.Context, userID string) (*models.UserProfile, error)
+	Create(ctx context.Context, profile *models.UserProfile) error
+	Update(ctx context.Context, profile *models.UserProfile) error
+	Delete(ctx context.Context, userID string) error
+	CreateSettings(ctx context.Context, settings *models.UserSettings) error
+	GetSettingsByUserID(ctx context.Context, userID string) (*models.UserSettings, error)
+	UpdateSettings(ctx context.Context, settings *models.UserSettings) error
+	DeleteSettings(ctx context.Context, userID string) error
+	CreateStats(ctx context.Context, stats *models.UserStats) error
+	GetStatsByUserID(ctx context.Context, userID string) (*models.UserStats, error)
+	UpdateStats(ctx context.Context, stats *models.UserStats) error
+	DeleteStats(ctx context.Context, userID string) error
+	IncrementLoginCount(ctx context.Context, userID string) error
+	UpdateLastLogin(ctx context.Context, userID string) error
+	IncrementProfileViews(ctx context.Context, userID string) error
+	UpdateLastActivity(ctx context.Context, userID string) error
+	ListProfiles(ctx context.Context, afterID uint, limit int) ([]models.UserProfile, error)
+	ListSettings(ctx context.Context, afterID uint, limit int) ([]models.UserSettings, error)
+	ClearNonObjectDocuments(ctx context.Context, migration string) (int64, error)
+	ArchiveDocuments(ctx context.Context, archives []models.DocumentArchive) error
+}
+
+// LoginEventRepositoryInterface define los métodos para el historial de logins
+type LoginEventRepositoryInterface interface {
+	Record(ctx context.Context, event *models.LoginEvent) error
+	RecordWithSession(ctx context.Context, event *models.LoginEvent, session *models.Session) error
+	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.LoginEvent, error)
+	CountByUserID(ctx context.Context, userID string) (int64, error)
+	PruneUser(ctx context.Context, userID string, keep int) error
+	GetFlagged(ctx context.Context, userID string, limit, offset int) ([]models.LoginEvent, error)
+}
+
+// SessionRepositoryInterface define los métodos para sesiones y dispositivos
+type SessionRepositoryInterface interface {
+	Touch(ctx context.Context, session *models.Session) error
+	GetByID(ctx context.Context, id string) (*models.Session, error)
+	GetByUserID(ctx context.Context, userID string, includeRevoked bool) ([]models.Session, error)
+	Revoke(ctx context.Context, userID, sessionID string) error
+	GetRevokedSince(ctx context.Context, since time.Time) ([]models.Session, error)
+}
+
+// RefreshTokenRepositoryInterface define los métodos para refresh tokens
+type RefreshTokenRepositoryInterface interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	MarkUsed(ctx context.Context, id string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID, reason string) error
+	RevokeBySession(ctx context.Context, sessionID, reason string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// APIKeyRepositoryInterface define los métodos para API keys de servicio
+type APIKeyRepositoryInterface interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByID(ctx context.Context, id string) (*models.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	GetAll(ctx context.Context, includeRevoked bool) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+	TouchLastUsed(ctx context.Context, id, ip string, usedAt time.Time) error
 }
 
 // RoleRepositoryInterface define los métodos para el repositorio de roles
 type RoleRepositoryInterface interface {
-	GetAllRoles() ([]*models.Role, error)
-	GetRoleByID(id uint) (*models.Role, error)
-	GetRoleByName(name string) (*models.Role, error)
-	CreateRole(role *models.Role) error
-	UpdateRole(role *models.Role) error
-	DeleteRole(id uint) error
-	GetActiveRoles() ([]*models.Role, error)
-	AssignRoleToUser(userID string, roleName string) error
-	RemoveRoleFromUser(userID string, roleName string) error
-	GetUserRoles(userID string) ([]*models.UserRole, error)
-	UserHasRole(userID string, roleName string) (bool, error)
-	UserHasAnyRole(userID string, roleNames []string) (bool, error)
-}
\ No newline at end of file
+	GetAllRoles(ctx context.Context) ([]*models.Role, error)
+	GetRoleByID(ctx context.Context, id uint) (*models.Role, error)
+	GetRoleByName(ctx context.Context, name string) (*models.Role, error)
+	CreateRole(ctx context.Context, role *models.Role) error
+	UpdateRole(ctx context.Context, role *models.Role) error
+	DeleteRole(ctx context.Context, id uint) error
+	GetActiveRoles(ctx context.Context) ([]*models.Role, error)
+	AssignRoleToUser(ctx context.Context, userID string, roleName string) error
+	RemoveRoleFromUser(ctx context.Context, userID string, roleName string) error
+	GetUserRoles(ctx context.Context, userID string) ([]*models.UserRole, error)
+	GetUsersWithRole(ctx context.Context, roleName string) ([]*models.User, error)
+	UserHasRole(ctx context.Context, userID string, roleName string) (bool, error)
+	UserHasAnyRole(ctx context.Context, userID string, roleNames []string) (bool, error)
+}
+// UserStatusRepositoryInterface define los métodos para el ciclo de vida de los usuarios
+type UserStatusRepositoryInterface interface {
+	Transition(ctx context.Context, user *models.User, change *models.UserStatusChange) error
+	GetHistory(ctx context.Context, userID string, limit, offset int) ([]models.UserStatusChange, error)
+	GetExpiredSuspensions(ctx context.Context, now time.Time, limit int) ([]models.User, error)
+}
+
+// EmailChangeRepositoryInterface define los métodos para los cambios de email
+type EmailChangeRepositoryInterface interface {
+	Create(ctx context.Context, change *models.EmailChange) error
+	GetByID(ctx context.Context, id string) (*models.EmailChange, error)
+	Confirm(ctx context.Context, change *models.EmailChange, confirmedAt time.Time) (*models.User, *models.EmailHistory, error)
+	GetHistory(ctx context.Context, userID string, limit, offset int) ([]models.EmailHistory, error)
+}
+
+// NotificationDeliveryRepositoryInterface define los métodos para el registro de entregas
+// de notificaciones
+type NotificationDeliveryRepositoryInterface interface {
+	Create(ctx context.Context, delivery *models.NotificationDelivery) error
+	Update(ctx context.Context, delivery *models.NotificationDelivery) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.NotificationDelivery, error)
+	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.NotificationDelivery, error)
+}
@@ -0,0 +1,258 @@
+package repositories
+
+import (
+	"context"
+
+	"it-user-service/internal/models"
+)
+
+// LegacyUserRepository es la interfaz del repositorio de usuarios sin contexto.
+//
+// Deprecated: usar UserRepositoryInterface y propagar el contexto del request
+type LegacyUserRepository interface {
+	GetByID(id string) (*models.User, error)
+	GetByEmail(email string) (*models.User, error)
+	GetByFirebaseID(firebaseID string) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	GetAll(limit, offset int) ([]models.User, error)
+	Create(user *models.User) error
+	Update(user *models.User) error
+	Delete(id string) error
+	UpdateLoginInfo(id string, loginIP, loginDevice string) error
+	GetActiveUsers() ([]models.User, error)
+	SearchUsers(query string, limit, offset int) ([]models.User, error)
+	CountUsers() (int64, error)
+}
+
+// LegacyProfileRepository es la interfaz del repositorio de perfiles sin contexto.
+//
+// Deprecated: usar ProfileRepositoryInterface y propagar el contexto del request
+type LegacyProfileRepository interface {
+	GetByUserID(userID string) (*models.UserProfile, error)
+	Create(profile *models.UserProfile) error
+	Update(profile *models.UserProfile) error
+	Delete(userID string) error
+	CreateSettings(settings *models.UserSettings) error
+	GetSettingsByUserID(userID string) (*models.UserSettings, error)
+	UpdateSettings(settings *models.UserSettings) error
+	DeleteSettings(userID string) error
+	CreateStats(stats *models.UserStats) error
+	GetStatsByUserID(userID string) (*models.UserStats, error)
+	UpdateStats(stats *models.UserStats) error
+	DeleteStats(userID string) error
+	IncrementLoginCount(userID string) error
+	UpdateLastLogin(userID string) error
+	IncrementProfileViews(userID string) error
+	UpdateLastActivity(userID string) error
+}
+
+// LegacyRoleRepository es la interfaz del repositorio de roles sin contexto.
+//
+// Deprecated: usar RoleRepositoryInterface y propagar el contexto del request
+type LegacyRoleRepository interface {
+	GetAllRoles() ([]*models.Role, error)
+	GetRoleByID(id uint) (*models.Role, error)
+	GetRoleByName(name string) (*models.Role, error)
+	CreateRole(role *models.Role) error
+	UpdateRole(role *models.Role) error
+	DeleteRole(id uint) error
+	GetActiveRoles() ([]*models.Role, error)
+	AssignRoleToUser(userID string, roleName string) error
+	RemoveRoleFromUser(userID string, roleName string) error
+	GetUserRoles(userID string) ([]*models.UserRole, error)
+	UserHasRole(userID string, roleName string) (bool, error)
+	UserHasAnyRole(userID string, roleNames []string) (bool, error)
+}
+
+// NewLegacyUserRepository adapta repo a la interfaz sin contexto usando context.Background().
+//
+// Deprecated: usar UserRepositoryInterface directamente
+func NewLegacyUserRepository(repo UserRepositoryInterface) LegacyUserRepository {
+	return legacyUserRepository{repo: repo}
+}
+
+// NewLegacyProfileRepository adapta repo a la interfaz sin contexto usando context.Background().
+//
+// Deprecated: usar ProfileRepositoryInterface directamente
+func NewLegacyProfileRepository(repo ProfileRepositoryInterface) LegacyProfileRepository {
+	return legacyProfileRepository{repo: repo}
+}
+
+// NewLegacyRoleRepository adapta repo a la interfaz sin contexto usando context.Background().
+//
+// Deprecated: usar RoleRepositoryInterface directamente
+func NewLegacyRoleRepository(repo RoleRepositoryInterface) LegacyRoleRepository {
+	return legacyRoleRepository{repo: repo}
+}
+
+type legacyUserRepository struct {
+	repo UserRepositoryInterface
+}
+
+func (l legacyUserRepository) GetByID(id string) (*models.User, error) {
+	return l.repo.GetByID(context.Background(), id)
+}
+
+func (l legacyUserRepository) GetByEmail(email string) (*models.User, error) {
+	return l.repo.GetByEmail(context.Background(), email)
+}
+
+func (l legacyUserRepository) GetByFirebaseID(firebaseID string) (*models.User, error) {
+	return l.repo.GetByFirebaseID(context.Background(), firebaseID)
+}
+
+func (l legacyUserRepository) GetByUsername(username string) (*models.User, error) {
+	return l.repo.GetByUsername(context.Background(), username)
+}
+
+func (l legacyUserRepository) GetAll(limit, offset int) ([]models.User, error) {
+	return l.repo.GetAll(context.Background(), limit, offset)
+}
+
+func (l legacyUserRepository) Create(user *models.User) error {
+	return l.repo.Create(context.Background(), user)
+}
+
+func (l legacyUserRepository) Update(user *models.User) error {
+	return l.repo.Update(context.Background(), user)
+}
+
+func (l legacyUserRepository) Delete(id string) error {
+	return l.repo.Delete(context.Background(), id)
+}
+
+func (l legacyUserRepository) UpdateLoginInfo(id string, loginIP, loginDevice string) error {
+	return l.repo.UpdateLoginInfo(context.Background(), id, loginIP, loginDevice)
+}
+
+func (l legacyUserRepository) GetActiveUsers() ([]models.User, error) {
+	return l.repo.GetActiveUsers(context.Background())
+}
+
+func (l legacyUserRepository) SearchUsers(query string, limit, offset int) ([]models.User, error) {
+	return l.repo.SearchUsers(context.Background(), query, limit, offset)
+}
+
+func (l legacyUserRepository) CountUsers() (int64, error) {
+	return l.repo.CountUsers(context.Background())
+}
+
+type legacyProfileRepository struct {
+	repo ProfileRepositoryInterface
+}
+
+func (l legacyProfileRepository) GetByUserID(userID string) (*models.UserProfile, error) {
+	return l.repo.GetByUserID(context.Background(), userID)
+}
+
+func (l legacyProfileRepository) Create(profile *models.UserProfile) error {
+	return l.repo.Create(context.Background(), profile)
+}
+
+func (l legacyProfileRepository) Update(profile *models.UserProfile) error {
+	return l.repo.Update(context.Background(), profile)
+}
+
+func (l legacyProfileRepository) Delete(userID string) error {
+	return l.repo.Delete(context.Background(), userID)
+}
+
+func (l legacyProfileRepository) CreateSettings(settings *models.UserSettings) error {
+	return l.repo.CreateSettings(context.Background(), settings)
+}
+
+func (l legacyProfileRepository) GetSettingsByUserID(userID string) (*models.UserSettings, error) {
+	return l.repo.GetSettingsByUserID(context.Background(), userID)
+}
+
+func (l legacyProfileRepository) UpdateSettings(settings *models.UserSettings) error {
+	return l.repo.UpdateSettings(context.Background(), settings)
+}
+
+func (l legacyProfileRepository) DeleteSettings(userID string) error {
+	return l.repo.DeleteSettings(context.Background(), userID)
+}
+
+func (l legacyProfileRepository) CreateStats(stats *models.UserStats) error {
+	return l.repo.CreateStats(context.Background(), stats)
+}
+
+func (l legacyProfileRepository) GetStatsByUserID(userID string) (*models.UserStats, error) {
+	return l.repo.GetStatsByUserID(context.Background(), userID)
+}
+
+func (l legacyProfileRepository) UpdateStats(stats *models.UserStats) error {
+	return l.repo.UpdateStats(context.Background(), stats)
+}
+
+func (l legacyProfileRepository) DeleteStats(userID string) error {
+	return l.repo.DeleteStats(context.Background(), userID)
+}
+
+func (l legacyProfileRepository) IncrementLoginCount(userID string) error {
+	return l.repo.IncrementLoginCount(context.Background(), userID)
+}
+
+func (l legacyProfileRepository) UpdateLastLogin(userID string) error {
+	return l.repo.UpdateLastLogin(context.Background(), userID)
+}
+
+func (l legacyProfileRepository) IncrementProfileViews(userID string) error {
+	return l.repo.IncrementProfileViews(context.Background(), userID)
+}
+
+func (l legacyProfileRepository) UpdateLastActivity(userID string) error {
+	return l.repo.UpdateLastActivity(context.Background(), userID)
+}
+
+type legacyRoleRepository struct {
+	repo RoleRepositoryInterface
+}
+
+func (l legacyRoleRepository) GetAllRoles() ([]*models.Role, error) {
+	return l.repo.GetAllRoles(context.Background())
+}
+
+func (l legacyRoleRepository) GetRoleByID(id uint) (*models.Role, error) {
+	return l.repo.GetRoleByID(context.Background(), id)
+}
+
+func (l legacyRoleRepository) GetRoleByName(name string) (*models.Role, error) {
+	return l.repo.GetRoleByName(context.Background(), name)
+}
+
+func (l legacyRoleRepository) CreateRole(role *models.Role) error {
+	return l.repo.CreateRole(context.Background(), role)
+}
+
+func (l legacyRoleRepository) UpdateRole(role *models.Role) error {
+	return l.repo.UpdateRole(context.Background(), role)
+}
+
+func (l legacyRoleRepository) DeleteRole(id uint) error {
+	return l.repo.DeleteRole(context.Background(), id)
+}
+
+func (l legacyRoleRepository) GetActiveRoles() ([]*models.Role, error) {
+	return l.repo.GetActiveRoles(context.Background())
+}
+
+func (l legacyRoleRepository) AssignRoleToUser(userID string, roleName string) error {
+	return l.repo.AssignRoleToUser(context.Background(), userID, roleName)
+}
+
+func (l legacyRoleRepository) RemoveRoleFromUser(userID string, roleName string) error {
+	return l.repo.RemoveRoleFromUser(context.Background(), userID, roleName)
+}
+
+func (l legacyRoleRepository) GetUserRoles(userID string) ([]*models.UserRole, error) {
+	return l.repo.GetUserRoles(context.Background(), userID)
+}
+
+func (l legacyRoleRepository) UserHasRole(userID string, roleName string) (bool, error) {
+	return l.repo.UserHasRole(context.Background(), userID, roleName)
+}
+
+func (l legacyRoleRepository) UserHasAnyRole(userID string, roleNames []string) (bool, error) {
+	return l.repo.UserHasAnyRole(context.Background(), userID, roleNames)
+}
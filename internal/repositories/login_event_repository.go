@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"it-user-service/internal/models"
+)
+
+type LoginEventRepository struct {
+	db         *gorm.DB
+	maxPerUser int
+}
+
+// NewLoginEventRepository crea el repositorio del historial de logins.
+// maxPerUser limita los eventos conservados por usuario (0 = sin límite)
+func NewLoginEventRepository(db *gorm.DB, maxPerUser int) LoginEventRepositoryInterface {
+	return &LoginEventRepository{db: db, maxPerUser: maxPerUser}
+}
+
+// Record registra un evento de login y actualiza los contadores derivados
+func (r *LoginEventRepository) Record(ctx context.Context, event *models.LoginEvent) error {
+	return recordLogin(r.db.WithContext(ctx), event, r.maxPerUser)
+}
+
+// RecordWithSession registra el dispositivo del login (ver SessionRepository.Touch) y el
+// evento vinculado a esa sesión en una sola transacción
+func (r *LoginEventRepository) RecordWithSession(ctx context.Context, event *models.LoginEvent, session *models.Session) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := touchSession(tx, session); err != nil {
+			return err
+		}
+		event.SessionID = session.ID
+		return recordLogin(tx, event, r.maxPerUser)
+	})
+}
+
+// GetByUserID obtiene el historial de logins de un usuario, del más reciente al más antiguo
+func (r *LoginEventRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.LoginEvent, error) {
+	var events []models.LoginEvent
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&events).Error
+	return events, err
+}
+
+// CountByUserID cuenta los eventos de login conservados de un usuario
+func (r *LoginEventRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.LoginEvent{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// PruneUser elimina los eventos más antiguos de un usuario conservando solo los últimos keep
+func (r *LoginEventRepository) PruneUser(ctx context.Context, userID string, keep int) error {
+	return pruneLoginEvents(r.db.WithContext(ctx), userID, keep)
+}
+
+// GetFlagged obtiene los logins marcados como sospechosos. Si userID está vacío
+// retorna los de todos los usuarios
+func (r *LoginEventRepository) GetFlagged(ctx context.Context, userID string, limit, offset int) ([]models.LoginEvent, error) {
+	var events []models.LoginEvent
+	query := r.db.WithContext(ctx).Where("flagged = ?", true)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&events).Error
+	return events, err
+}
+
+// recordLogin es el único punto donde se registran logins. Inserta el evento y, si fue
+// exitoso, deriva en la misma transacción users.login_count/last_login_* y las
+// estadísticas del usuario, de forma que ambos contadores no se desincronicen
+func recordLogin(db *gorm.DB, event *models.LoginEvent, keep int) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		if event.Success {
+			updates := map[string]interface{}{
+				"login_count":   gorm.Expr("login_count + 1"),
+				"last_login_at": event.CreatedAt,
+				"updated_at":    time.Now(),
+				"version":       gorm.Expr("version + 1"),
+			}
+			if event.IP != "" {
+				updates["last_login_ip"] = event.IP
+			}
+			if event.Device != "" {
+				updates["last_login_device"] = event.Device
+			}
+
+			if err := tx.Model(&models.User{}).Where("id = ?", event.UserID).Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := syncLoginStats(tx, event.UserID); err != nil {
+				return err
+			}
+		}
+
+		if keep > 0 {
+			return pruneLoginEvents(tx, event.UserID, keep)
+		}
+		return nil
+	})
+}
+
+// syncLoginStats copia login_count y last_login_at de users a user_stats,
+// creando el registro de estadísticas si aún no existe
+func syncLoginStats(tx *gorm.DB, userID string) error {
+	var user models.User
+	if err := tx.Select("login_count", "last_login_at").Where("id = ?", userID).First(&user).Error; err != nil {
+		return err
+	}
+
+	stats := &models.UserStats{
+		UserID:      userID,
+		LoginCount:  user.LoginCount,
+		LastLoginAt: user.LastLoginAt,
+		IsActive:    true,
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"login_count", "last_login_at", "updated_at"}),
+	}).Create(stats).Error
+}
+
+// pruneLoginEvents aplica el límite de retención por usuario
+func pruneLoginEvents(db *gorm.DB, userID string, keep int) error {
+	return db.Exec(
+		`DELETE FROM login_events WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM login_events WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?
+		)`,
+		userID, userID, keep,
+	).Error
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+
+	"it-user-service/internal/models"
+)
+
+// LoginRecorder registra logins evaluando su riesgo (lo implementa services.LoginService)
+type LoginRecorder interface {
+	RecordLogin(ctx context.Context, event *models.LoginEvent, session *models.Session) error
+}
+
+// riskAssessedUserRepository registra los logins de UpdateLoginInfo a través de un LoginRecorder
+type riskAssessedUserRepository struct {
+	UserRepositoryInterface
+	logins LoginRecorder
+}
+
+// WithLoginRecorder envuelve el repositorio de usuarios para que UpdateLoginInfo evalúe el
+// riesgo del login y aplique las acciones automáticas, como el resto de los logins
+func WithLoginRecorder(inner UserRepositoryInterface, logins LoginRecorder) UserRepositoryInterface {
+	return &riskAssessedUserRepository{UserRepositoryInterface: inner, logins: logins}
+}
+
+// UpdateLoginInfo registra un login exitoso con su evaluación de riesgo
+func (r *riskAssessedUserRepository) UpdateLoginInfo(ctx context.Context, id string, loginIP, loginDevice string) error {
+	event := &models.LoginEvent{
+		UserID:  id,
+		IP:      loginIP,
+		Device:  loginDevice,
+		Success: true,
+	}
+	return r.logins.RecordLogin(ctx, event, nil)
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+)
+
+type NotificationDeliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationDeliveryRepository crea el repositorio del registro de entregas de notificaciones
+func NewNotificationDeliveryRepository(db *gorm.DB) NotificationDeliveryRepositoryInterface {
+	return &NotificationDeliveryRepository{db: db}
+}
+
+// Create registra una entrega
+func (r *NotificationDeliveryRepository) Create(ctx context.Context, delivery *models.NotificationDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// Update guarda el resultado de un intento de entrega
+func (r *NotificationDeliveryRepository) Update(ctx context.Context, delivery *models.NotificationDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+// ClaimDue toma hasta limit entregas pendientes cuyo próximo intento venció y las reserva
+// durante lease (corre next_attempt_at), de modo que otra réplica no las reintente a la vez
+func (r *NotificationDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.NotificationDelivery, error) {
+	var due []models.NotificationDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	leaseUntil := now.Add(lease)
+	claimed := due[:0]
+	for _, delivery := range due {
+		result := r.db.WithContext(ctx).Model(&models.NotificationDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.DeliveryPending, delivery.NextAttemptAt).
+			Update("next_attempt_at", leaseUntil)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			delivery.NextAttemptAt = &leaseUntil
+			claimed = append(claimed, delivery)
+		}
+	}
+	return claimed, nil
+}
+
+// GetByUserID obtiene las entregas de un usuario, de la más reciente a la más antigua
+func (r *NotificationDeliveryRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.NotificationDelivery, error) {
+	var deliveries []models.NotificationDelivery
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&deliveries).Error
+	return deliveries, err
+}
@@ -1,25 +1,29 @@
 package repositories
 
 import (
+	"context"
 	"time"
 	"gorm.io/gorm"
 	"it-user-service/internal/models"
 )
 
 type ProfileRepository struct {
-	db *gorm.DB
+	db                *gorm.DB
+	loginHistoryLimit int
 }
 
-func NewProfileRepository(db *gorm.DB) ProfileRepositoryInterface {
-	return &ProfileRepository{db: db}
+// NewProfileRepository crea el repositorio de perfiles, configuraciones y estadísticas.
+// loginHistoryLimit limita los eventos de login conservados por usuario (0 = sin límite)
+func NewProfileRepository(db *gorm.DB, loginHistoryLimit int) ProfileRepositoryInterface {
+	return &ProfileRepository{db: db, loginHistoryLimit: loginHistoryLimit}
 }
 
 // Profile CRUD operations
 
 // GetByUserID obtiene el perfil de un usuario
-func (r *ProfileRepository) GetByUserID(userID string) (*models.UserProfile, error) {
+func (r *ProfileRepository) GetByUserID(ctx context.Context, userID string) (*models.UserProfile, error) {
 	var profile models.UserProfile
-	err := r.db.Where("user_id = ?", userID).First(&profile).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&profile).Error
 	if err != nil {
 		return nil, err
 	}
@@ -27,58 +31,59 @@ func (r *ProfileRepository) GetByUserID(userID string) (*models.UserProfile, err
 }
 
 // Create crea un nuevo perfil de usuario
-func (r *ProfileRepository) Create(profile *models.UserProfile) error {
-	return r.db.Create(profile).Error
+func (r *ProfileRepository) Create(ctx context.Context, profile *models.UserProfile) error {
+	return r.db.WithContext(ctx).Create(profile).Error
 }
 
-// Update actualiza un perfil de usuario
-func (r *ProfileRepository) Update(profile *models.UserProfile) error {
-	return r.db.Save(profile).Error
+// Update actualiza un perfil de usuario; retorna ErrVersionConflict si cambió desde que se leyó
+func (r *ProfileRepository) Update(ctx context.Context, profile *models.UserProfile) error {
+	return updateVersioned(r.db.WithContext(ctx), profile, &profile.Version)
 }
 
 // Delete elimina un perfil de usuario
-func (r *ProfileRepository) Delete(userID string) error {
-	return r.db.Where("user_id = ?", userID).Delete(&models.UserProfile{}).Error
+func (r *ProfileRepository) Delete(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserProfile{}).Error
 }
 
 // Settings CRUD operations
 
 // CreateSettings crea nuevas configuraciones de usuario
-func (r *ProfileRepository) CreateSettings(settings *models.UserSettings) error {
-	return r.db.Create(settings).Error
+func (r *ProfileRepository) CreateSettings(ctx context.Context, settings *models.UserSettings) error {
+	return r.db.WithContext(ctx).Create(settings).Error
 }
 
 // GetSettingsByUserID obtiene las configuraciones de un usuario
-func (r *ProfileRepository) GetSettingsByUserID(userID string) (*models.UserSettings, error) {
+func (r *ProfileRepository) GetSettingsByUserID(ctx context.Context, userID string) (*models.UserSettings, error) {
 	var settings models.UserSettings
-	err := r.db.Where("user_id = ?", userID).First(&settings).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&settings).Error
 	if err != nil {
 		return nil, err
 	}
 	return &settings, nil
 }
 
-// UpdateSettings actualiza las configuraciones de usuario
-func (r *ProfileRepository) UpdateSettings(settings *models.UserSettings) error {
-	return r.db.Save(settings).Error
+// UpdateSettings actualiza las configuraciones de usuario; retorna ErrVersionConflict si
+// cambiaron desde que se leyeron
+func (r *ProfileRepository) UpdateSettings(ctx context.Context, settings *models.UserSettings) error {
+	return updateVersioned(r.db.WithContext(ctx), settings, &settings.Version)
 }
 
 // DeleteSettings elimina las configuraciones de usuario
-func (r *ProfileRepository) DeleteSettings(userID string) error {
-	return r.db.Where("user_id = ?", userID).Delete(&models.UserSettings{}).Error
+func (r *ProfileRepository) DeleteSettings(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserSettings{}).Error
 }
 
 // Stats CRUD operations
 
 // CreateStats crea nuevas estadísticas de usuario
-func (r *ProfileRepository) CreateStats(stats *models.UserStats) error {
-	return r.db.Create(stats).Error
+func (r *ProfileRepository) CreateStats(ctx context.Context, stats *models.UserStats) error {
+	return r.db.WithContext(ctx).Create(stats).Error
 }
 
 // GetStatsByUserID obtiene las estadísticas de un usuario
-func (r *ProfileRepository) GetStatsByUserID(userID string) (*models.UserStats, error) {
+func (r *ProfileRepository) GetStatsByUserID(ctx context.Context, userID string) (*models.UserStats, error) {
 	var stats models.UserStats
-	err := r.db.Where("user_id = ?", userID).First(&stats).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&stats).Error
 	if err != nil {
 		return nil, err
 	}
@@ -86,36 +91,47 @@ func (r *ProfileRepository) GetStatsByUserID(userID string) (*models.UserStats,
 }
 
 // UpdateStats actualiza las estadísticas de usuario
-func (r *ProfileRepository) UpdateStats(stats *models.UserStats) error {
-	return r.db.Save(stats).Error
+func (r *ProfileRepository) UpdateStats(ctx context.Context, stats *models.UserStats) error {
+	return r.db.WithContext(ctx).Save(stats).Error
 }
 
 // DeleteStats elimina las estadísticas de usuario
-func (r *ProfileRepository) DeleteStats(userID string) error {
-	return r.db.Where("user_id = ?", userID).Delete(&models.UserStats{}).Error
+func (r *ProfileRepository) DeleteStats(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserStats{}).Error
 }
 
 // Stats operations
 
-// IncrementLoginCount incrementa el contador de logins en la tabla users
-func (r *ProfileRepository) IncrementLoginCount(userID string) error {
-	return r.db.Model(&models.User{}).Where("id = ?", userID).
-		UpdateColumn("login_count", gorm.Expr("login_count + 1")).Error
+// IncrementLoginCount registra un login exitoso sin detalles en el historial.
+// Los contadores de users y user_stats se derivan del mismo evento (ver recordLogin)
+func (r *ProfileRepository) IncrementLoginCount(ctx context.Context, userID string) error {
+	event := &models.LoginEvent{
+		UserID:  userID,
+		Success: true,
+	}
+	return recordLogin(r.db.WithContext(ctx), event, r.loginHistoryLimit)
 }
 
-// UpdateLastLogin actualiza la fecha del último login en la tabla users
-func (r *ProfileRepository) UpdateLastLogin(userID string) error {
+// UpdateLastLogin actualiza la fecha del último login en la tabla users y en las estadísticas
+func (r *ProfileRepository) UpdateLastLogin(ctx context.Context, userID string) error {
 	now := time.Now()
-	return r.db.Model(&models.User{}).Where("id = ?", userID).
-		Updates(map[string]interface{}{
-			"last_login_at": now,
-		}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"last_login_at": now,
+				"version":       gorm.Expr("version + 1"),
+			}).Error
+		if err != nil {
+			return err
+		}
+		return syncLoginStats(tx, userID)
+	})
 }
 
 // IncrementProfileViews incrementa el contador de vistas del perfil
-func (r *ProfileRepository) IncrementProfileViews(userID string) error {
+func (r *ProfileRepository) IncrementProfileViews(ctx context.Context, userID string) error {
 	// Primero intentar actualizar si existe
-	result := r.db.Model(&models.UserStats{}).Where("user_id = ?", userID).
+	result := r.db.WithContext(ctx).Model(&models.UserStats{}).Where("user_id = ?", userID).
 		UpdateColumn("profile_views", gorm.Expr("profile_views + 1"))
 	
 	if result.Error != nil {
@@ -129,18 +145,18 @@ func (r *ProfileRepository) IncrementProfileViews(userID string) error {
 			ProfileViews: 1,
 			IsActive:     true,
 		}
-		return r.db.Create(stats).Error
+		return r.db.WithContext(ctx).Create(stats).Error
 	}
 	
 	return nil
 }
 
 // UpdateLastActivity actualiza la última actividad del usuario
-func (r *ProfileRepository) UpdateLastActivity(userID string) error {
+func (r *ProfileRepository) UpdateLastActivity(ctx context.Context, userID string) error {
 	now := time.Now()
 	
 	// Primero intentar actualizar si existe
-	result := r.db.Model(&models.UserStats{}).Where("user_id = ?", userID).
+	result := r.db.WithContext(ctx).Model(&models.UserStats{}).Where("user_id = ?", userID).
 		Updates(map[string]interface{}{
 			"last_active_at": now,
 			"is_active":      true,
@@ -157,16 +173,76 @@ func (r *ProfileRepository) UpdateLastActivity(userID string) error {
 			LastActiveAt: &now,
 			IsActive:     true,
 		}
-		return r.db.Create(stats).Error
+		return r.db.WithContext(ctx).Create(stats).Error
 	}
 	
 	return nil
 }
 
+// ListProfiles retorna hasta limit perfiles con ID mayor a afterID, ordenados por ID
+func (r *ProfileRepository) ListProfiles(ctx context.Context, afterID uint, limit int) ([]models.UserProfile, error) {
+	var profiles []models.UserProfile
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&profiles).Error
+	return profiles, err
+}
+
+// ListSettings retorna hasta limit configuraciones con ID mayor a afterID, ordenadas por ID
+func (r *ProfileRepository) ListSettings(ctx context.Context, afterID uint, limit int) ([]models.UserSettings, error) {
+	var settings []models.UserSettings
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&settings).Error
+	return settings, err
+}
+
+// ClearNonObjectDocuments pone en NULL los documentos JSON de perfiles y configuraciones que
+// no son objetos (strings, números, arrays), que no se pueden leer como models.JSONDocument.
+// Los valores descartados se guardan en document_archives con la migración indicada.
+// Retorna la cantidad de filas modificadas
+func (r *ProfileRepository) ClearNonObjectDocuments(ctx context.Context, migration string) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, target := range []struct {
+			model   interface{}
+			table   string
+			columns []string
+		}{
+			{&models.UserProfile{}, "user_profiles", []string{"preferences", "privacy"}},
+			{&models.UserSettings{}, "user_settings", []string{"notifications", "privacy", "security"}},
+		} {
+			updates := map[string]interface{}{"version": gorm.Expr("version + 1")}
+			query := tx.Model(target.model)
+			for _, column := range target.columns {
+				err := tx.Exec("INSERT INTO document_archives (migration, source_table, row_id, column_name, value, created_at) "+
+					"SELECT ?, ?, id, ?, "+column+", NOW() FROM "+target.table+" WHERE jsonb_typeof("+column+") <> 'object'",
+					migration, target.table, column).Error
+				if err != nil {
+					return err
+				}
+				updates[column] = gorm.Expr("CASE WHEN jsonb_typeof(" + column + ") = 'object' THEN " + column + " END")
+				query = query.Or("jsonb_typeof(" + column + ") <> 'object'")
+			}
+			result := query.Updates(updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			total += result.RowsAffected
+		}
+		return nil
+	})
+	return total, err
+}
+
+// ArchiveDocuments guarda los valores originales de documentos modificados por una migración
+func (r *ProfileRepository) ArchiveDocuments(ctx context.Context, archives []models.DocumentArchive) error {
+	if len(archives) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&archives).Error
+}
+
 // GetCompleteProfile obtiene el perfil completo con usuario, perfil, configuraciones y estadísticas
-func (r *ProfileRepository) GetCompleteProfile(userID string) (*models.ProfileResponse, error) {
+func (r *ProfileRepository) GetCompleteProfile(ctx context.Context, userID string) (*models.ProfileResponse, error) {
 	var user models.User
-	err := r.db.Where("id = ?", userID).First(&user).Error
+	err := r.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -177,19 +253,19 @@ func (r *ProfileRepository) GetCompleteProfile(userID string) (*models.ProfileRe
 	
 	// Obtener perfil (opcional)
 	var profile models.UserProfile
-	if err := r.db.Where("user_id = ?", userID).First(&profile).Error; err == nil {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&profile).Error; err == nil {
 		response.Profile = &profile
 	}
 	
 	// Obtener configuraciones (opcional)
 	var settings models.UserSettings
-	if err := r.db.Where("user_id = ?", userID).First(&settings).Error; err == nil {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&settings).Error; err == nil {
 		response.Settings = &settings
 	}
 	
 	// Obtener estadísticas (opcional)
 	var stats models.UserStats
-	if err := r.db.Where("user_id = ?", userID).First(&stats).Error; err == nil {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&stats).Error; err == nil {
 		response.Stats = &stats
 	}
 	
@@ -197,8 +273,8 @@ func (r *ProfileRepository) GetCompleteProfile(userID string) (*models.ProfileRe
 }
 
 // CreateCompleteProfile crea un perfil completo con configuraciones iniciales
-func (r *ProfileRepository) CreateCompleteProfile(userID string, profileReq *models.CreateProfileRequest, settingsReq *models.CreateSettingsRequest) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *ProfileRepository) CreateCompleteProfile(ctx context.Context, userID string, profileReq *models.CreateProfileRequest, settingsReq *models.CreateSettingsRequest) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Crear perfil si se proporciona
 		if profileReq != nil {
 			profile := &models.UserProfile{
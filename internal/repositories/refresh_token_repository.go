@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+)
+
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository crea el repositorio de refresh tokens
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepositoryInterface {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Create guarda un nuevo refresh token (solo su hash)
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByHash obtiene un refresh token por el hash del valor entregado al cliente
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed marca el token como usado solo si no se había usado ni revocado.
+// Retorna false si otro request ya lo consumió (reutilización)
+func (r *RefreshTokenRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily revoca todos los tokens vigentes de una familia
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID, reason string) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error
+}
+
+// RevokeBySession revoca todos los tokens vigentes de una sesión
+func (r *RefreshTokenRepository) RevokeBySession(ctx context.Context, sessionID, reason string) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error
+}
+
+// DeleteExpired elimina los tokens expirados antes de before
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
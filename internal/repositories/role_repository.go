@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"it-user-service/internal/models"
 )
@@ -16,16 +17,16 @@ func NewRoleRepository(db *gorm.DB) RoleRepositoryInterface {
 // Role CRUD operations
 
 // GetAllRoles obtiene todos los roles
-func (r *RoleRepository) GetAllRoles() ([]*models.Role, error) {
+func (r *RoleRepository) GetAllRoles(ctx context.Context) ([]*models.Role, error) {
 	var roles []*models.Role
-	err := r.db.Find(&roles).Error
+	err := r.db.WithContext(ctx).Find(&roles).Error
 	return roles, err
 }
 
 // GetRoleByID obtiene un rol por ID
-func (r *RoleRepository) GetRoleByID(id uint) (*models.Role, error) {
+func (r *RoleRepository) GetRoleByID(ctx context.Context, id uint) (*models.Role, error) {
 	var role models.Role
-	err := r.db.First(&role, id).Error
+	err := r.db.WithContext(ctx).First(&role, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -33,9 +34,9 @@ func (r *RoleRepository) GetRoleByID(id uint) (*models.Role, error) {
 }
 
 // GetRoleByName obtiene un rol por nombre
-func (r *RoleRepository) GetRoleByName(name string) (*models.Role, error) {
+func (r *RoleRepository) GetRoleByName(ctx context.Context, name string) (*models.Role, error) {
 	var role models.Role
-	err := r.db.Where("name = ?", name).First(&role).Error
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
 	if err != nil {
 		return nil, err
 	}
@@ -43,60 +44,69 @@ func (r *RoleRepository) GetRoleByName(name string) (*models.Role, error) {
 }
 
 // CreateRole crea un nuevo rol
-func (r *RoleRepository) CreateRole(role *models.Role) error {
-	return r.db.Create(role).Error
+func (r *RoleRepository) CreateRole(ctx context.Context, role *models.Role) error {
+	return r.db.WithContext(ctx).Create(role).Error
 }
 
-// UpdateRole actualiza un rol
-func (r *RoleRepository) UpdateRole(role *models.Role) error {
-	return r.db.Save(role).Error
+// UpdateRole actualiza un rol; retorna ErrVersionConflict si cambió desde que se leyó
+func (r *RoleRepository) UpdateRole(ctx context.Context, role *models.Role) error {
+	return updateVersioned(r.db.WithContext(ctx), role, &role.Version)
 }
 
-// DeleteRole elimina un rol
-func (r *RoleRepository) DeleteRole(id uint) error {
-	return r.db.Delete(&models.Role{}, id).Error
+// DeleteRole elimina un rol y sus asignaciones a usuarios
+func (r *RoleRepository) DeleteRole(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role models.Role
+		if err := tx.First(&role, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role = ?", role.Name).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
 }
 
 // GetActiveRoles obtiene todos los roles activos
-func (r *RoleRepository) GetActiveRoles() ([]*models.Role, error) {
+func (r *RoleRepository) GetActiveRoles(ctx context.Context) ([]*models.Role, error) {
 	var roles []*models.Role
-	err := r.db.Where("active = ?", true).Find(&roles).Error
+	err := r.db.WithContext(ctx).Where("active = ?", true).Find(&roles).Error
 	return roles, err
 }
 
 // User-Role relationships (usando string role según tu SQL)
 
 // AssignRoleToUser asigna un rol a un usuario
-func (r *RoleRepository) AssignRoleToUser(userID string, roleName string) error {
+func (r *RoleRepository) AssignRoleToUser(ctx context.Context, userID string, roleName string) error {
 	userRole := &models.UserRole{
 		UserID: userID,
 		Role:   roleName,
 	}
-	return r.db.Create(userRole).Error
+	return r.db.WithContext(ctx).Create(userRole).Error
 }
 
 // RemoveRoleFromUser remueve un rol de un usuario
-func (r *RoleRepository) RemoveRoleFromUser(userID string, roleName string) error {
-	return r.db.Where("user_id = ? AND role = ?", userID, roleName).
+func (r *RoleRepository) RemoveRoleFromUser(ctx context.Context, userID string, roleName string) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND role = ?", userID, roleName).
 		Delete(&models.UserRole{}).Error
 }
 
 // GetUserRoles obtiene todos los roles de un usuario
-func (r *RoleRepository) GetUserRoles(userID string) ([]*models.UserRole, error) {
+func (r *RoleRepository) GetUserRoles(ctx context.Context, userID string) ([]*models.UserRole, error) {
 	var userRoles []*models.UserRole
-	err := r.db.Where("user_id = ?", userID).Find(&userRoles).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&userRoles).Error
 	return userRoles, err
 }
 
 // GetUserWithRoles obtiene un usuario con sus roles
-func (r *RoleRepository) GetUserWithRoles(userID string) (*models.UserWithRoles, error) {
+func (r *RoleRepository) GetUserWithRoles(ctx context.Context, userID string) (*models.UserWithRoles, error) {
 	var user models.User
-	err := r.db.First(&user, userID).Error
+	err := r.db.WithContext(ctx).First(&user, userID).Error
 	if err != nil {
 		return nil, err
 	}
 	
-	roles, err := r.GetUserRoles(userID)
+	roles, err := r.GetUserRoles(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -114,9 +124,9 @@ func (r *RoleRepository) GetUserWithRoles(userID string) (*models.UserWithRoles,
 }
 
 // GetUsersWithRole obtiene todos los usuarios que tienen un rol específico
-func (r *RoleRepository) GetUsersWithRole(roleName string) ([]*models.User, error) {
+func (r *RoleRepository) GetUsersWithRole(ctx context.Context, roleName string) ([]*models.User, error) {
 	var users []*models.User
-	err := r.db.Table("users").
+	err := r.db.WithContext(ctx).Table("users").
 		Joins("JOIN user_roles ON users.id = user_roles.user_id").
 		Where("user_roles.role = ?", roleName).
 		Find(&users).Error
@@ -126,18 +136,18 @@ func (r *RoleRepository) GetUsersWithRole(roleName string) ([]*models.User, erro
 // Role checking
 
 // UserHasRole verifica si un usuario tiene un rol específico
-func (r *RoleRepository) UserHasRole(userID string, roleName string) (bool, error) {
+func (r *RoleRepository) UserHasRole(ctx context.Context, userID string, roleName string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.UserRole{}).
+	err := r.db.WithContext(ctx).Model(&models.UserRole{}).
 		Where("user_id = ? AND role = ?", userID, roleName).
 		Count(&count).Error
 	return count > 0, err
 }
 
 // UserHasAnyRole verifica si un usuario tiene alguno de los roles especificados
-func (r *RoleRepository) UserHasAnyRole(userID string, roleNames []string) (bool, error) {
+func (r *RoleRepository) UserHasAnyRole(ctx context.Context, userID string, roleNames []string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.UserRole{}).
+	err := r.db.WithContext(ctx).Model(&models.UserRole{}).
 		Where("user_id = ? AND role IN ?", userID, roleNames).
 		Count(&count).Error
 	return count > 0, err
@@ -146,8 +156,8 @@ func (r *RoleRepository) UserHasAnyRole(userID string, roleNames []string) (bool
 // Bulk operations
 
 // AssignMultipleRolesToUser asigna múltiples roles a un usuario
-func (r *RoleRepository) AssignMultipleRolesToUser(userID string, roleNames []string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *RoleRepository) AssignMultipleRolesToUser(ctx context.Context, userID string, roleNames []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		for _, roleName := range roleNames {
 			userRole := &models.UserRole{
 				UserID: userID,
@@ -162,12 +172,12 @@ func (r *RoleRepository) AssignMultipleRolesToUser(userID string, roleNames []st
 }
 
 // RemoveMultipleRolesFromUser remueve múltiples roles de un usuario
-func (r *RoleRepository) RemoveMultipleRolesFromUser(userID string, roleNames []string) error {
-	return r.db.Where("user_id = ? AND role IN ?", userID, roleNames).
+func (r *RoleRepository) RemoveMultipleRolesFromUser(ctx context.Context, userID string, roleNames []string) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND role IN ?", userID, roleNames).
 		Delete(&models.UserRole{}).Error
 }
 
 // RemoveAllUserRoles remueve todos los roles de un usuario
-func (r *RoleRepository) RemoveAllUserRoles(userID string) error {
-	return r.db.Where("user_id = ?", userID).Delete(&models.UserRole{}).Error
+func (r *RoleRepository) RemoveAllUserRoles(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserRole{}).Error
 }
\ No newline at end of file
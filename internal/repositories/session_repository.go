@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository crea el repositorio de sesiones y dispositivos
+func NewSessionRepository(db *gorm.DB) SessionRepositoryInterface {
+	return &SessionRepository{db: db}
+}
+
+// Touch registra actividad de un dispositivo. Si el usuario ya tiene una sesión activa
+// en ese dispositivo se actualiza; si no, se crea una nueva. session queda con los datos persistidos
+func (r *SessionRepository) Touch(ctx context.Context, session *models.Session) error {
+	return touchSession(r.db.WithContext(ctx), session)
+}
+
+func touchSession(db *gorm.DB, session *models.Session) error {
+	now := time.Now()
+
+	var existing models.Session
+	err := db.Where("user_id = ? AND device_id = ? AND revoked_at IS NULL", session.UserID, session.DeviceID).
+		Order("last_seen_at DESC").
+		First(&existing).Error
+
+	if err == gorm.ErrRecordNotFound {
+		if session.ID == "" {
+			session.ID = uuid.NewString()
+		}
+		session.FirstSeenAt = now
+		session.LastSeenAt = now
+		return db.Create(session).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	existing.LastSeenAt = now
+	if session.IP != "" {
+		existing.IP = session.IP
+	}
+	if session.UserAgent != "" {
+		existing.UserAgent = session.UserAgent
+	}
+	if session.Platform != "" {
+		existing.Platform = session.Platform
+	}
+	if session.DeviceName != "" {
+		existing.DeviceName = session.DeviceName
+	}
+	if session.PushToken != "" {
+		existing.PushToken = session.PushToken
+	}
+	if err := db.Save(&existing).Error; err != nil {
+		return err
+	}
+
+	*session = existing
+	return nil
+}
+
+// GetByID obtiene una sesión por su ID
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetByUserID obtiene las sesiones de un usuario, de la más reciente a la más antigua
+func (r *SessionRepository) GetByUserID(ctx context.Context, userID string, includeRevoked bool) ([]models.Session, error) {
+	var sessions []models.Session
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if !includeRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+	err := query.Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke revoca una sesión activa de un usuario. Retorna gorm.ErrRecordNotFound
+// si la sesión no existe, no pertenece al usuario o ya estaba revocada
+func (r *SessionRepository) Revoke(ctx context.Context, userID, sessionID string) error {
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Updates(map[string]interface{}{
+			"revoked_at": time.Now(),
+			"push_token": "",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetRevokedSince obtiene los IDs de sesiones revocadas desde since
+func (r *SessionRepository) GetRevokedSince(ctx context.Context, since time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).Select("id", "user_id", "revoked_at").
+		Where("revoked_at IS NOT NULL AND revoked_at >= ?", since).
+		Find(&sessions).Error
+	return sessions, err
+}
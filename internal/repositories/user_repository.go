@@ -1,24 +1,26 @@
 package repositories
 
 import (
-	"time"
+	"context"
 	"gorm.io/gorm"
 	"it-user-service/internal/models"
 )
 
 type UserRepository struct {
-	db *gorm.DB
+	db                *gorm.DB
+	loginHistoryLimit int
 }
 
-// NewUserRepository crea una nueva instancia del repositorio de usuarios
-func NewUserRepository(db *gorm.DB) UserRepositoryInterface {
-	return &UserRepository{db: db}
+// NewUserRepository crea una nueva instancia del repositorio de usuarios.
+// loginHistoryLimit limita los eventos de login conservados por usuario (0 = sin límite)
+func NewUserRepository(db *gorm.DB, loginHistoryLimit int) UserRepositoryInterface {
+	return &UserRepository{db: db, loginHistoryLimit: loginHistoryLimit}
 }
 
 // GetByID obtiene un usuario por su ID
-func (r *UserRepository) GetByID(id string) (*models.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("id = ?", id).First(&user).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -26,9 +28,9 @@ func (r *UserRepository) GetByID(id string) (*models.User, error) {
 }
 
 // GetByEmail obtiene un usuario por su email
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -36,9 +38,9 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 }
 
 // GetByFirebaseID obtiene un usuario por su Firebase ID
-func (r *UserRepository) GetByFirebaseID(firebaseID string) (*models.User, error) {
+func (r *UserRepository) GetByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("firebase_id = ?", firebaseID).First(&user).Error
+	err := r.db.WithContext(ctx).Where("firebase_id = ?", firebaseID).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -46,9 +48,9 @@ func (r *UserRepository) GetByFirebaseID(firebaseID string) (*models.User, error
 }
 
 // GetByUsername obtiene un usuario por su username
-func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -56,57 +58,57 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 }
 
 // GetAll obtiene todos los usuarios con paginación
-func (r *UserRepository) GetAll(limit, offset int) ([]models.User, error) {
+func (r *UserRepository) GetAll(ctx context.Context, limit, offset int) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Limit(limit).Offset(offset).Find(&users).Error
+	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&users).Error
 	return users, err
 }
 
 // Create crea un nuevo usuario
-func (r *UserRepository) Create(user *models.User) error {
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	// Si no tiene ID, dejar que PostgreSQL lo genere
 	if user.ID == "" {
-		return r.db.Omit("id").Create(user).Error
+		return r.db.WithContext(ctx).Omit("id").Create(user).Error
 	}
-	return r.db.Create(user).Error
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-// Update actualiza un usuario existente
-func (r *UserRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+// Update actualiza un usuario existente; retorna ErrVersionConflict si cambió desde que se leyó
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	return updateVersioned(r.db.WithContext(ctx), user, &user.Version)
 }
 
 // Delete elimina un usuario por su ID
-func (r *UserRepository) Delete(id string) error {
-	return r.db.Where("id = ?", id).Delete(&models.User{}).Error
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.User{}).Error
 }
 
-// UpdateLoginInfo actualiza la información de login del usuario
-func (r *UserRepository) UpdateLoginInfo(id string, loginIP, loginDevice string) error {
-	updates := map[string]interface{}{
-		"login_count":        gorm.Expr("login_count + 1"),
-		"last_login_at":      time.Now(),
-		"last_login_ip":      &loginIP,
-		"last_login_device":  &loginDevice,
-		"updated_at":         time.Now(),
+// UpdateLoginInfo registra un login exitoso en el historial y actualiza la información
+// de último login del usuario. No evalúa el riesgo del login: el servidor envuelve el
+// repositorio con WithLoginRecorder para que pase por LoginService
+func (r *UserRepository) UpdateLoginInfo(ctx context.Context, id string, loginIP, loginDevice string) error {
+	event := &models.LoginEvent{
+		UserID:  id,
+		IP:      loginIP,
+		Device:  loginDevice,
+		Success: true,
 	}
-	
-	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error
+	return recordLogin(r.db.WithContext(ctx), event, r.loginHistoryLimit)
 }
 
 // GetActiveUsers obtiene todos los usuarios activos
-func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
+func (r *UserRepository) GetActiveUsers(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("status = ? AND disabled = ?", "active", false).Find(&users).Error
+	err := r.db.WithContext(ctx).Where("status = ? AND disabled = ?", "active", false).Find(&users).Error
 	return users, err
 }
 
 // SearchUsers busca usuarios por nombre, email o username
-func (r *UserRepository) SearchUsers(query string, limit, offset int) ([]models.User, error) {
+func (r *UserRepository) SearchUsers(ctx context.Context, query string, limit, offset int) ([]models.User, error) {
 	var users []models.User
 	searchPattern := "%" + query + "%"
 	
-	err := r.db.Where(
+	err := r.db.WithContext(ctx).Where(
 		"first_name ILIKE ? OR last_name ILIKE ? OR email ILIKE ? OR username ILIKE ?",
 		searchPattern, searchPattern, searchPattern, searchPattern,
 	).Limit(limit).Offset(offset).Find(&users).Error
@@ -115,8 +117,8 @@ func (r *UserRepository) SearchUsers(query string, limit, offset int) ([]models.
 }
 
 // CountUsers cuenta el total de usuarios
-func (r *UserRepository) CountUsers() (int64, error) {
+func (r *UserRepository) CountUsers(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&models.User{}).Count(&count).Error
 	return count, err
 }
\ No newline at end of file
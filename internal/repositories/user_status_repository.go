@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+)
+
+type UserStatusRepository struct {
+	db *gorm.DB
+}
+
+// NewUserStatusRepository crea el repositorio de estados y su historial
+func NewUserStatusRepository(db *gorm.DB) UserStatusRepositoryInterface {
+	return &UserStatusRepository{db: db}
+}
+
+// Transition guarda el nuevo estado de user y registra change en la misma transacción. Si el
+// usuario cambió desde que se leyó retorna ErrVersionConflict y no registra el cambio
+func (r *UserStatusRepository) Transition(ctx context.Context, user *models.User, change *models.UserStatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, user, &user.Version); err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// GetHistory obtiene los cambios de estado de un usuario, del más reciente al más antiguo
+func (r *UserStatusRepository) GetHistory(ctx context.Context, userID string, limit, offset int) ([]models.UserStatusChange, error) {
+	var changes []models.UserStatusChange
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&changes).Error
+	return changes, err
+}
+
+// GetExpiredSuspensions obtiene los usuarios suspendidos cuya suspensión venció antes de now
+func (r *UserStatusRepository) GetExpiredSuspensions(ctx context.Context, now time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND suspended_until IS NOT NULL AND suspended_until <= ?", models.StatusSuspended, now).
+		Order("suspended_until").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionConflict indica que el registro cambió (o se eliminó) desde que se leyó
+var ErrVersionConflict = errors.New("record was modified concurrently")
+
+// updateVersioned guarda todas las columnas de model solo si la versión en la base de datos
+// sigue siendo *version, e incrementa la versión. Reemplaza a Save, que sobrescribía los
+// cambios de otros requests
+func updateVersioned(db *gorm.DB, model interface{}, version *int) error {
+	expected := *version
+	*version = expected + 1
+
+	result := db.Model(model).
+		Where("version = ?", expected).
+		Select("*").
+		Omit("created_at", clause.Associations).
+		Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		*version = expected
+	}
+	return result.Error
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+)
+
+func TestUpdateVersioned_ChecksAndIncrementsVersion(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	var statement string
+	require.NoError(t, db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		statement = tx.Statement.SQL.String()
+	}))
+
+	user := &models.User{ID: "2b7c1a52-7f1e-4d8e-9a55-0d6f1f3e2a10", Username: "alice", Version: 3}
+	err = updateVersioned(db, user, &user.Version)
+
+	// En DryRun no se afectan filas: se comporta como un conflicto de versión
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, 3, user.Version)
+	assert.Contains(t, statement, `"version"=$`)
+	assert.Contains(t, statement, `WHERE version = $`)
+	assert.Contains(t, statement, `"id" = $`)
+	assert.NotContains(t, statement, `"created_at"`)
+}
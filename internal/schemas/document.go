@@ -0,0 +1,235 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"it-user-service/internal/models"
+	"it-user-service/internal/openapi"
+)
+
+// VersionKey es la propiedad con la versión del schema con la que se escribió el documento
+const VersionKey = "schema_version"
+
+var (
+	// ErrInvalidDocument indica un documento que no cumple su schema
+	ErrInvalidDocument = errors.New("invalid document")
+	// ErrUnknownKey indica una clave que no está en el schema del documento
+	ErrUnknownKey = errors.New("unknown document key")
+)
+
+// Document es un documento JSON del perfil o de las configuraciones con su schema versionado.
+// Los documentos guardados solo tienen las claves que el usuario escribió; al leerlos se
+// completan con los defaults del schema
+type Document struct {
+	// Name identifica el documento en /api/v1/schemas/{name}, por ejemplo "settings.notifications"
+	Name string
+	// Field es la propiedad del modelo que guarda el documento, por ejemplo "notifications"
+	Field string
+	// Version se incrementa con cada cambio incompatible del schema
+	Version int
+	Schema  *openapi.Schema
+}
+
+func newDocument(scope, field, title string, version int, properties map[string]*openapi.Schema) *Document {
+	name := scope + "." + field
+	properties[VersionKey] = &openapi.Schema{Type: "integer", Minimum: number(1), Maximum: number(version)}
+	return &Document{
+		Name:    name,
+		Field:   field,
+		Version: version,
+		Schema: &openapi.Schema{
+			Dialect:    "https://json-schema.org/draft/2020-12/schema",
+			ID:         fmt.Sprintf("urn:it-user-service:schema:%s:v%d", name, version),
+			Title:      title,
+			Type:       "object",
+			Properties: properties,
+			Closed:     true,
+		},
+	}
+}
+
+// Property retorna el schema de una clave modificable del documento
+func (d *Document) Property(key string) (*openapi.Schema, bool) {
+	if key == VersionKey {
+		return nil, false
+	}
+	property, ok := d.Schema.Properties[key]
+	return property, ok
+}
+
+// Validate valida el documento contra el schema. Un documento nil es válido
+func (d *Document) Validate(doc models.JSONDocument) error {
+	if doc == nil {
+		return nil
+	}
+	value, err := normalize(doc)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if err := openapi.ValidateValue(d.Field, d.Schema, map[string]interface{}(value)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	return nil
+}
+
+// Prepare valida el documento y retorna la copia que se guarda, con la versión del schema
+func (d *Document) Prepare(doc models.JSONDocument) (models.JSONDocument, error) {
+	if err := d.Validate(doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	prepared, err := normalize(doc)
+	if err != nil {
+		return nil, err
+	}
+	prepared[VersionKey] = json.Number(fmt.Sprint(d.Version))
+	return prepared, nil
+}
+
+// WithDefaults retorna una copia del documento con los defaults del schema en las claves que
+// no tiene
+func (d *Document) WithDefaults(doc models.JSONDocument) models.JSONDocument {
+	merged, err := normalize(doc)
+	if err != nil || merged == nil {
+		merged = models.JSONDocument{}
+	}
+	applyDefaults(d.Schema, merged)
+	merged[VersionKey] = json.Number(fmt.Sprint(d.Version))
+	return merged
+}
+
+// Repair retorna una copia del documento sin las claves desconocidas ni los valores que no
+// cumplen el schema, y con la versión actual. changed indica si hubo que corregir algo
+func (d *Document) Repair(doc models.JSONDocument) (repaired models.JSONDocument, changed bool) {
+	if doc == nil {
+		return nil, false
+	}
+	repaired, err := normalize(doc)
+	if err != nil {
+		return nil, true
+	}
+	changed = repairObject(d.Field, d.Schema, repaired)
+	version := json.Number(fmt.Sprint(d.Version))
+	if repaired[VersionKey] != version {
+		repaired[VersionKey] = version
+		changed = true
+	}
+	return repaired, changed
+}
+
+// Set retorna una copia del documento con key en value, validada y lista para guardar
+func (d *Document) Set(doc models.JSONDocument, key string, value interface{}) (models.JSONDocument, error) {
+	if _, ok := d.Property(key); !ok {
+		return nil, ErrUnknownKey
+	}
+	updated, err := normalize(doc)
+	if err != nil || updated == nil {
+		updated = models.JSONDocument{}
+	}
+	updated[key] = value
+	return d.Prepare(updated)
+}
+
+// Unset retorna una copia del documento sin key, que vuelve a su default
+func (d *Document) Unset(doc models.JSONDocument, key string) (models.JSONDocument, error) {
+	if _, ok := d.Property(key); !ok {
+		return nil, ErrUnknownKey
+	}
+	updated, err := normalize(doc)
+	if err != nil || updated == nil {
+		updated = models.JSONDocument{}
+	}
+	delete(updated, key)
+	return d.Prepare(updated)
+}
+
+// normalize retorna una copia profunda del documento tal como queda después de guardarlo y
+// leerlo: los números como json.Number y los objetos como map[string]interface{}
+func normalize(doc models.JSONDocument) (models.JSONDocument, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]interface{}(doc))
+	if err != nil {
+		return nil, err
+	}
+	var copied map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&copied); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+// applyDefaults completa las propiedades de object que faltan con los defaults de schema
+func applyDefaults(schema *openapi.Schema, object map[string]interface{}) {
+	for name, property := range schema.Properties {
+		if _, ok := object[name]; !ok && property.Default != nil {
+			object[name] = copyValue(property.Default)
+		}
+		if nested, ok := object[name].(map[string]interface{}); ok && property.Properties != nil {
+			applyDefaults(property, nested)
+		}
+	}
+}
+
+// repairObject elimina de object las claves desconocidas y los valores inválidos. Los
+// objetos anidados se reparan clave por clave. Retorna si eliminó algo
+func repairObject(path string, schema *openapi.Schema, object map[string]interface{}) bool {
+	changed := false
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property, known := schema.Properties[key]
+		if !known {
+			delete(object, key)
+			changed = true
+			continue
+		}
+		if nested, ok := object[key].(map[string]interface{}); ok && property.Properties != nil {
+			if repairObject(path+"."+key, property, nested) {
+				changed = true
+			}
+			continue
+		}
+		if err := openapi.ValidateValue(path+"."+key, property, object[key]); err != nil {
+			delete(object, key)
+			changed = true
+		}
+	}
+	return changed
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	}
+	return value
+}
+
+func number(v int) *float64 {
+	n := float64(v)
+	return &n
+}
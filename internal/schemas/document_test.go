@@ -0,0 +1,82 @@
+package schemas
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"it-user-service/internal/models"
+)
+
+func TestDocument_Validate(t *testing.T) {
+	assert.NoError(t, Notifications.Validate(nil))
+	assert.NoError(t, Notifications.Validate(models.JSONDocument{
+		"email": map[string]interface{}{"enabled": false, "kinds": map[string]interface{}{"welcome": false}},
+	}))
+
+	err := Notifications.Validate(models.JSONDocument{"sms": map[string]interface{}{"enabled": true}})
+	assert.ErrorIs(t, err, ErrInvalidDocument)
+	assert.Contains(t, err.Error(), "notifications.sms is not allowed")
+
+	err = Notifications.Validate(models.JSONDocument{"email": map[string]interface{}{"kinds": map[string]interface{}{"welcome": "no"}}})
+	assert.ErrorIs(t, err, ErrInvalidDocument)
+
+	assert.Error(t, Security.Validate(models.JSONDocument{"session_timeout_minutes": 1.5}))
+	assert.Error(t, ProfilePrivacy.Validate(models.JSONDocument{"phone": "friends"}))
+	assert.Error(t, Preferences.Validate(models.JSONDocument{VersionKey: 2}))
+}
+
+func TestDocument_WithDefaults(t *testing.T) {
+	stored := models.JSONDocument{"email": map[string]interface{}{"kinds": map[string]interface{}{"welcome": false}}}
+	merged := Notifications.WithDefaults(stored)
+
+	email := merged["email"].(map[string]interface{})
+	assert.Equal(t, true, email["enabled"])
+	assert.Equal(t, false, email["kinds"].(map[string]interface{})["welcome"])
+	assert.Equal(t, true, email["kinds"].(map[string]interface{})["role_granted"])
+	assert.Equal(t, true, merged["webhook"].(map[string]interface{})["enabled"])
+	assert.Equal(t, json.Number("1"), merged[VersionKey])
+
+	// El documento guardado no se modifica
+	assert.NotContains(t, stored, "webhook")
+	assert.Equal(t, VisibilityPrivate, ProfilePrivacy.WithDefaults(nil)["phone"])
+}
+
+func TestDocument_SetAndUnset(t *testing.T) {
+	doc, err := Security.Set(nil, "mfa_enabled", true)
+	require.NoError(t, err)
+	assert.Equal(t, true, doc["mfa_enabled"])
+	assert.Equal(t, json.Number("1"), doc[VersionKey])
+
+	_, err = Security.Set(doc, "mfa_enabled", "yes")
+	assert.ErrorIs(t, err, ErrInvalidDocument)
+	_, err = Security.Set(doc, VersionKey, 1)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	doc, err = Security.Unset(doc, "mfa_enabled")
+	require.NoError(t, err)
+	assert.NotContains(t, doc, "mfa_enabled")
+}
+
+func TestRepairSettings(t *testing.T) {
+	settings := &models.UserSettings{
+		Notifications: models.JSONDocument{
+			"email": map[string]interface{}{"enabled": "yes", "kinds": map[string]interface{}{"welcome": false, "digest": true}},
+			"push":  true,
+		},
+		Privacy: models.JSONDocument{"profile_visibility": "private", VersionKey: json.Number("1")},
+	}
+
+	assert.True(t, RepairSettings(settings))
+	assert.Equal(t, models.JSONDocument{
+		"email":    map[string]interface{}{"kinds": map[string]interface{}{"welcome": false}},
+		VersionKey: json.Number("1"),
+	}, settings.Notifications)
+	assert.Equal(t, "private", settings.Privacy["profile_visibility"])
+	assert.Nil(t, settings.Security)
+	assert.NoError(t, PrepareSettings(settings))
+
+	// Los documentos válidos y versionados no se vuelven a escribir
+	assert.False(t, RepairSettings(settings))
+}
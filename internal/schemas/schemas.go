@@ -0,0 +1,206 @@
+package schemas
+
+import (
+	"encoding/json"
+
+	"it-user-service/internal/models"
+	"it-user-service/internal/notify"
+	"it-user-service/internal/openapi"
+)
+
+// Visibilidades de los campos del perfil, de la más abierta a la más restringida
+const (
+	VisibilityPublic        = "public"
+	VisibilityAuthenticated = "authenticated"
+	VisibilityContacts      = "contacts"
+	VisibilityPrivate       = "private"
+)
+
+// Visibilities son las visibilidades válidas
+var Visibilities = []string{VisibilityPublic, VisibilityAuthenticated, VisibilityContacts, VisibilityPrivate}
+
+// Documentos del perfil (UserProfile) y de las configuraciones (UserSettings)
+var (
+	Preferences = newDocument("profile", "preferences", "Profile preferences", 1, map[string]*openapi.Schema{
+		"newsletter":       boolean(false),
+		"marketing_emails": boolean(false),
+		"show_tips":        boolean(true),
+		"content_languages": {
+			Type:     "array",
+			Items:    &openapi.Schema{Type: "string", Pattern: `^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`},
+			MaxItems: count(10),
+		},
+	})
+
+	ProfilePrivacy = newDocument("profile", "privacy", "Profile field visibility", 1, map[string]*openapi.Schema{
+		"avatar":   visibility(VisibilityPublic),
+		"bio":      visibility(VisibilityPublic),
+		"website":  visibility(VisibilityPublic),
+		"location": visibility(VisibilityAuthenticated),
+		"birthday": visibility(VisibilityPrivate),
+		"gender":   visibility(VisibilityPrivate),
+		"phone":    visibility(VisibilityPrivate),
+	})
+
+	Notifications = newDocument("settings", "notifications", "Notification preferences", 1, map[string]*openapi.Schema{
+		notify.ChannelEmail:   channel(),
+		notify.ChannelWebhook: channel(),
+	})
+
+	Privacy = newDocument("settings", "privacy", "Privacy settings", 1, map[string]*openapi.Schema{
+		"profile_visibility": visibility(VisibilityPublic),
+		"searchable":         boolean(true),
+	})
+
+	Security = newDocument("settings", "security", "Security settings", 1, map[string]*openapi.Schema{
+		"mfa_enabled":      boolean(false),
+		"remember_devices": boolean(true),
+		// 0 usa el tiempo de sesión del servicio
+		"session_timeout_minutes": {Type: "integer", Minimum: number(0), Maximum: number(43200), Default: json.Number("0")},
+	})
+)
+
+// All retorna todos los documentos
+func All() []*Document {
+	return []*Document{Preferences, ProfilePrivacy, Notifications, Privacy, Security}
+}
+
+// Lookup busca un documento por nombre
+func Lookup(name string) (*Document, bool) {
+	for _, doc := range All() {
+		if doc.Name == name {
+			return doc, true
+		}
+	}
+	return nil, false
+}
+
+func boolean(def bool) *openapi.Schema {
+	return &openapi.Schema{Type: "boolean", Default: def}
+}
+
+func visibility(def string) *openapi.Schema {
+	return &openapi.Schema{Type: "string", Enum: Visibilities, Default: def}
+}
+
+// channel es la preferencia de un canal de notificación (ver notify.ChannelPreference)
+func channel() *openapi.Schema {
+	kinds := make(map[string]*openapi.Schema, len(notify.Kinds))
+	for _, kind := range notify.Kinds {
+		kinds[kind] = boolean(true)
+	}
+	return &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"enabled": boolean(true),
+			"kinds":   {Type: "object", Properties: kinds, Closed: true, Default: map[string]interface{}{}},
+		},
+		Closed:  true,
+		Default: map[string]interface{}{},
+	}
+}
+
+func count(v int) *int {
+	return &v
+}
+
+// field es un documento de un modelo junto con el campo que lo guarda
+type field struct {
+	doc   *Document
+	value *models.JSONDocument
+}
+
+func profileFields(profile *models.UserProfile) []field {
+	return []field{{Preferences, &profile.Preferences}, {ProfilePrivacy, &profile.Privacy}}
+}
+
+func settingsFields(settings *models.UserSettings) []field {
+	return []field{{Notifications, &settings.Notifications}, {Privacy, &settings.Privacy}, {Security, &settings.Security}}
+}
+
+// ProfileField retorna el documento del perfil guardado en name ("preferences" o "privacy")
+// y el campo que lo guarda
+func ProfileField(profile *models.UserProfile, name string) (*Document, *models.JSONDocument, bool) {
+	return lookupField(profileFields(profile), name)
+}
+
+// SettingsField retorna el documento de las configuraciones guardado en name
+// ("notifications", "privacy" o "security") y el campo que lo guarda
+func SettingsField(settings *models.UserSettings, name string) (*Document, *models.JSONDocument, bool) {
+	return lookupField(settingsFields(settings), name)
+}
+
+func lookupField(fields []field, name string) (*Document, *models.JSONDocument, bool) {
+	for _, f := range fields {
+		if f.doc.Field == name {
+			return f.doc, f.value, true
+		}
+	}
+	return nil, nil, false
+}
+
+// PrepareProfile valida los documentos del perfil y les asigna la versión del schema
+func PrepareProfile(profile *models.UserProfile) error {
+	return prepare(profileFields(profile))
+}
+
+// PrepareSettings valida los documentos de las configuraciones y les asigna la versión del
+// schema
+func PrepareSettings(settings *models.UserSettings) error {
+	return prepare(settingsFields(settings))
+}
+
+func prepare(fields []field) error {
+	for _, f := range fields {
+		prepared, err := f.doc.Prepare(*f.value)
+		if err != nil {
+			return err
+		}
+		*f.value = prepared
+	}
+	return nil
+}
+
+// ProfileWithDefaults retorna una copia del perfil con los defaults en sus documentos
+func ProfileWithDefaults(profile *models.UserProfile) *models.UserProfile {
+	copied := *profile
+	withDefaults(profileFields(&copied))
+	return &copied
+}
+
+// SettingsWithDefaults retorna una copia de las configuraciones con los defaults en sus
+// documentos
+func SettingsWithDefaults(settings *models.UserSettings) *models.UserSettings {
+	copied := *settings
+	withDefaults(settingsFields(&copied))
+	return &copied
+}
+
+func withDefaults(fields []field) {
+	for _, f := range fields {
+		*f.value = f.doc.WithDefaults(*f.value)
+	}
+}
+
+// RepairProfile corrige los documentos del perfil que no cumplen su schema. Retorna si cambió
+// alguno
+func RepairProfile(profile *models.UserProfile) bool {
+	return repair(profileFields(profile))
+}
+
+// RepairSettings corrige los documentos de las configuraciones que no cumplen su schema.
+// Retorna si cambió alguno
+func RepairSettings(settings *models.UserSettings) bool {
+	return repair(settingsFields(settings))
+}
+
+func repair(fields []field) bool {
+	changed := false
+	for _, f := range fields {
+		if repaired, ok := f.doc.Repair(*f.value); ok {
+			*f.value = repaired
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"it-user-service/internal/auth"
+)
+
+// KeySource provee las llaves de firma del key ring desde referencias a secretos
+// (vault://, file://, env://); los valores sin esquema se leen como rutas de archivo.
+// El key ring la recarga periódicamente, por lo que las llaves rotadas en la fuente
+// se usan sin reiniciar
+type KeySource struct {
+	Resolver *Resolver
+	Active   string
+	Next     string
+	Retired  []string
+}
+
+// LoadKeys resuelve las llaves PEM. Next y Retired son opcionales
+func (s KeySource) LoadKeys(ctx context.Context) (*auth.KeySet, error) {
+	set := &auth.KeySet{}
+	active, err := s.load(ctx, s.Active)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load active signing key: %w", err)
+	}
+	set.Active = []byte(active)
+
+	if s.Next != "" {
+		next, err := s.load(ctx, s.Next)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load next signing key: %w", err)
+		}
+		set.Next = []byte(next)
+	}
+	for _, ref := range s.Retired {
+		retired, err := s.load(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load retired signing key: %w", err)
+		}
+		set.Retired = append(set.Retired, []byte(retired))
+	}
+	return set, nil
+}
+
+func (s KeySource) load(ctx context.Context, ref string) (string, error) {
+	if !IsReference(ref) {
+		ref = SchemeFile + "://" + ref
+	}
+	return s.Resolver.Resolve(ctx, ref)
+}
@@ -0,0 +1,38 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// EnvProvider lee secretos desde variables de entorno: env://DB_PASSWORD
+type EnvProvider struct{}
+
+// GetSecret obtiene el valor de la variable ref
+func (EnvProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrSecretNotFound, ref)
+	}
+	return value, nil
+}
+
+// FileProvider lee secretos montados como archivos (Docker/Kubernetes secrets):
+// file:///run/secrets/db_password. Se eliminan los saltos de línea finales
+type FileProvider struct{}
+
+// GetSecret lee el archivo ref en cada llamada para detectar secretos rotados
+func (FileProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w: %v", ErrSecretNotFound, err)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
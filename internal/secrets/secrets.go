@@ -0,0 +1,190 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"it-user-service/internal/logger"
+)
+
+// Esquemas de las referencias a secretos, por ejemplo vault://secret/microservice#db_password
+const (
+	SchemeEnv   = "env"
+	SchemeFile  = "file"
+	SchemeVault = "vault"
+)
+
+var (
+	ErrSecretNotFound    = errors.New("secret not found")
+	ErrUnknownSecretType = errors.New("no secret provider configured for scheme")
+)
+
+// SecretProvider obtiene secretos de una fuente. ref es la referencia sin el esquema
+// (para vault://secret/microservice#db_password, "secret/microservice#db_password")
+type SecretProvider interface {
+	GetSecret(ctx context.Context, ref string) (string, error)
+}
+
+// IsReference indica si value es una referencia a un secreto y no un valor literal
+func IsReference(value string) bool {
+	scheme, _, ok := splitReference(value)
+	return ok && (scheme == SchemeEnv || scheme == SchemeFile || scheme == SchemeVault)
+}
+
+func splitReference(value string) (scheme, ref string, ok bool) {
+	scheme, ref, ok = strings.Cut(value, "://")
+	if !ok || scheme == "" || ref == "" {
+		return "", "", false
+	}
+	return scheme, ref, true
+}
+
+// Secret es un valor resuelto desde una referencia que el Resolver refresca
+// periódicamente. Los valores literales nunca cambian
+type Secret struct {
+	ref string
+
+	mu        sync.RWMutex
+	value     string
+	listeners []func(string)
+}
+
+// Value retorna el valor actual del secreto
+func (s *Secret) Value() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.value
+}
+
+// OnChange registra fn para ser llamada con el nuevo valor cuando el secreto rota
+func (s *Secret) OnChange(fn func(string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// update guarda value y notifica a los listeners si cambió
+func (s *Secret) update(value string) bool {
+	s.mu.Lock()
+	if s.value == value {
+		s.mu.Unlock()
+		return false
+	}
+	s.value = value
+	listeners := append([]func(string){}, s.listeners...)
+	s.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(value)
+	}
+	return true
+}
+
+// Resolver resuelve referencias a secretos con el provider de su esquema y mantiene
+// actualizados los secretos observados con Watch
+type Resolver struct {
+	mu        sync.RWMutex
+	providers map[string]SecretProvider
+	watched   []*Secret
+}
+
+// NewResolver crea un Resolver con los providers env:// y file:// registrados
+func NewResolver() *Resolver {
+	return &Resolver{
+		providers: map[string]SecretProvider{
+			SchemeEnv:  EnvProvider{},
+			SchemeFile: FileProvider{},
+		},
+	}
+}
+
+// Register asocia un provider a un esquema (por ejemplo, SchemeVault)
+func (r *Resolver) Register(scheme string, provider SecretProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = provider
+}
+
+// Resolve retorna el valor del secreto referenciado, o value tal cual si es un literal
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+	scheme, ref, _ := splitReference(value)
+
+	r.mu.RLock()
+	provider, ok := r.providers[scheme]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w %s://", ErrUnknownSecretType, scheme)
+	}
+
+	secret, err := provider.GetSecret(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s://%s: %w", scheme, ref, err)
+	}
+	return secret, nil
+}
+
+// Watch resuelve value y, si es una referencia, lo incluye en los refrescos periódicos
+func (r *Resolver) Watch(ctx context.Context, value string) (*Secret, error) {
+	resolved, err := r.Resolve(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &Secret{ref: value, value: resolved}
+	if IsReference(value) {
+		r.mu.Lock()
+		r.watched = append(r.watched, secret)
+		r.mu.Unlock()
+	}
+	return secret, nil
+}
+
+// Refresh vuelve a resolver los secretos observados y notifica los que cambiaron.
+// Si una referencia falla se conserva su último valor
+func (r *Resolver) Refresh(ctx context.Context) error {
+	r.mu.RLock()
+	watched := append([]*Secret{}, r.watched...)
+	r.mu.RUnlock()
+
+	var errs []error
+	for _, secret := range watched {
+		value, err := r.Resolve(ctx, secret.ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if secret.update(value) {
+			logger.GetLogger().WithField("secret", secret.ref).Info("Secret rotated")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Start refresca los secretos observados cada interval hasta que ctx se cancele
+func (r *Resolver) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Refresh(ctx); err != nil {
+					logger.GetLogger().WithError(err).Error("Failed to refresh secrets")
+				}
+			}
+		}
+	}()
+}
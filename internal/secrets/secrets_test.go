@@ -0,0 +1,116 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_ResolvesReferencesAndLiterals(t *testing.T) {
+	ctx := context.Background()
+	resolver := NewResolver()
+	t.Setenv("TEST_SECRET", "from-env")
+	path := filepath.Join(t.TempDir(), "db_password")
+	require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+
+	value, err := resolver.Resolve(ctx, "plain-value")
+	require.NoError(t, err)
+	assert.Equal(t, "plain-value", value)
+
+	value, err = resolver.Resolve(ctx, "env://TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	value, err = resolver.Resolve(ctx, "file://"+path)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", value)
+
+	_, err = resolver.Resolve(ctx, "vault://secret/microservice#db_password")
+	assert.ErrorIs(t, err, ErrUnknownSecretType)
+}
+
+func TestResolver_RefreshNotifiesRotatedSecrets(t *testing.T) {
+	ctx := context.Background()
+	resolver := NewResolver()
+	path := filepath.Join(t.TempDir(), "jwt_secret")
+	require.NoError(t, os.WriteFile(path, []byte("v1"), 0o600))
+
+	secret, err := resolver.Watch(ctx, "file://"+path)
+	require.NoError(t, err)
+	var rotated []string
+	secret.OnChange(func(value string) { rotated = append(rotated, value) })
+
+	require.NoError(t, resolver.Refresh(ctx))
+	assert.Empty(t, rotated)
+
+	require.NoError(t, os.WriteFile(path, []byte("v2"), 0o600))
+	require.NoError(t, resolver.Refresh(ctx))
+	assert.Equal(t, "v2", secret.Value())
+	assert.Equal(t, []string{"v2"}, rotated)
+
+	// Si la fuente falla se conserva el último valor
+	require.NoError(t, os.Remove(path))
+	assert.Error(t, resolver.Refresh(ctx))
+	assert.Equal(t, "v2", secret.Value())
+}
+
+func TestVaultProvider_ReadsKVv2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "dev-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/microservice" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     map[string]interface{}{"db_password": "postgres"},
+				"metadata": map[string]interface{}{"version": 3},
+			},
+		})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	provider := NewVaultProvider(VaultConfig{Address: server.URL, Token: "dev-token"})
+
+	value, err := provider.GetSecret(ctx, "secret/microservice#db_password")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", value)
+
+	_, err = provider.GetSecret(ctx, "secret/microservice#missing")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+
+	_, err = provider.GetSecret(ctx, "secret/other#db_password")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+
+	_, err = NewVaultProvider(VaultConfig{Address: server.URL, Token: "wrong"}).GetSecret(ctx, "secret/microservice#db_password")
+	assert.ErrorContains(t, err, "permission denied")
+}
+
+// TestVaultProvider_DevServer usa el Vault de docker-compose inicializado con
+// scripts/vault-init.sh: VAULT_ADDR=http://localhost:8200 VAULT_TOKEN=dev-token
+func TestVaultProvider_DevServer(t *testing.T) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		t.Skip("VAULT_ADDR not set")
+	}
+
+	resolver := NewResolver()
+	resolver.Register(SchemeVault, NewVaultProvider(VaultConfig{Address: addr, Token: os.Getenv("VAULT_TOKEN")}))
+
+	value, err := resolver.Resolve(context.Background(), "vault://secret/microservice#db_password")
+	require.NoError(t, err)
+	assert.NotEmpty(t, value)
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultConfig configura el acceso a Vault
+type VaultConfig struct {
+	Address   string
+	Token     string
+	Namespace string
+	Timeout   time.Duration
+}
+
+// VaultProvider lee secretos de un motor KV v2 de Vault. La referencia tiene el formato
+// mount/path#key, por ejemplo vault://secret/microservice#db_password lee la clave
+// db_password de secret/data/microservice
+type VaultProvider struct {
+	cfg    VaultConfig
+	client *http.Client
+}
+
+func NewVaultProvider(cfg VaultConfig) *VaultProvider {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &VaultProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// vaultKVResponse es la respuesta de GET /v1/{mount}/data/{path}
+type vaultKVResponse struct {
+	Data struct {
+		Data     map[string]interface{} `json:"data"`
+		Metadata struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// GetSecret obtiene la clave indicada del secreto KV v2
+func (p *VaultProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	mount, secretPath, hasPath := strings.Cut(path, "/")
+	if !ok || key == "" || !hasPath || mount == "" || secretPath == "" {
+		return "", fmt.Errorf("invalid vault reference %q, expected mount/path#key", ref)
+	}
+
+	data, err := p.readKV(ctx, mount, secretPath)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: key %s not in %s", ErrSecretNotFound, key, path)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	// Los valores que no son strings se retornan como JSON
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// readKV lee la última versión de un secreto KV v2
+func (p *VaultProvider) readKV(ctx context.Context, mount, path string) (map[string]interface{}, error) {
+	endpoint, err := url.JoinPath(p.cfg.Address, "v1", mount, "data", path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault address: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.cfg.Token)
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var kv vaultKVResponse
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &kv); err != nil && resp.StatusCode == http.StatusOK {
+			return nil, fmt.Errorf("invalid vault response: %w", err)
+		}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		if kv.Data.Data == nil {
+			// Versión eliminada o destruida
+			return nil, fmt.Errorf("%w: %s/%s has no data", ErrSecretNotFound, mount, path)
+		}
+		return kv.Data.Data, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s/%s", ErrSecretNotFound, mount, path)
+	default:
+		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(kv.Errors, "; "))
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"it-user-service/internal/config"
+	"it-user-service/internal/database"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+	"it-user-service/internal/services"
+)
+
+// Migrate conecta a la base de datos configurada y aplica las migraciones del esquema.
+// Se ejecuta con el subcomando "migrate" antes de desplegar una versión nueva: el probe
+// de startup falla mientras falten tablas, columnas o pasos de datos
+func Migrate(ctx context.Context, cfg config.Config) error {
+	resolver := newSecretResolver(cfg.Secrets)
+	password, err := resolver.Resolve(ctx, cfg.Database.Password)
+	if err != nil {
+		return err
+	}
+	if err := database.ConnectDB(cfg.Database, func() string { return password }); err != nil {
+		return err
+	}
+	db := database.GetDB().WithContext(ctx)
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	if err := models.MigrateDB(db); err != nil {
+		return err
+	}
+
+	// Corregir una sola vez los documentos JSON de perfiles y configuraciones que no cumplen
+	// sus schemas; los valores originales quedan en document_archives
+	_, err = models.RunOnce(db, models.MigrationRepairDocuments, func(tx *gorm.DB) error {
+		repaired, err := services.RepairDocuments(ctx, repositories.NewProfileRepository(tx, cfg.LoginHistoryLimit), models.MigrationRepairDocuments)
+		if err != nil {
+			return err
+		}
+		logger.GetLogger().WithField("count", repaired).Info("Profile and settings documents repaired")
+		return nil
+	})
+	return err
+}
@@ -1,15 +1,35 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/cache"
 	"it-user-service/internal/config"
 	"it-user-service/internal/database"
+	"it-user-service/internal/events"
+	"it-user-service/internal/geoip"
 	"it-user-service/internal/handlers"
+	"it-user-service/internal/idempotency"
 	"it-user-service/internal/logger"
+	"it-user-service/internal/mail"
+	"it-user-service/internal/middleware"
+	"it-user-service/internal/notify"
+	"it-user-service/internal/ratelimit"
 	"it-user-service/internal/repositories"
+	"it-user-service/internal/secrets"
+	"it-user-service/internal/services"
+	"it-user-service/internal/views"
 )
 
 type Server struct {
@@ -18,42 +38,571 @@ type Server struct {
 	userRepo    repositories.UserRepositoryInterface
 	profileRepo repositories.ProfileRepositoryInterface
 	roleRepo    repositories.RoleRepositoryInterface
+	loginRepo   repositories.LoginEventRepositoryInterface
+	stopWorkers context.CancelFunc
+
+	httpServer    *http.Server
+	ready         atomic.Bool
+	shutdownHooks []shutdownHook
+}
+
+// shutdownHook es una tarea de cierre (por ejemplo, vaciar el exportador de trazas)
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
 }
 
 func NewServer(cfg config.Config) (*Server, error) {
-	// Conectar a la base de datos
-	database.ConnectDB()
+	// Secretos referenciados desde la configuración (vault://, file://, env://)
+	secretResolver := newSecretResolver(cfg.Secrets)
+	dbPassword, err := secretResolver.Watch(context.Background(), cfg.Database.Password)
+	if err != nil {
+		return nil, err
+	}
+	jwtSecret, err := secretResolver.Watch(context.Background(), cfg.Auth.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+	redisURL, err := secretResolver.Resolve(context.Background(), cfg.Redis.URL)
+	if err != nil {
+		return nil, err
+	}
+	emailTokenSecret, err := secretResolver.Watch(context.Background(), cfg.EmailChange.TokenSecret)
+	if err != nil {
+		return nil, err
+	}
+	webhookSecret, err := secretResolver.Watch(context.Background(), cfg.Notifications.WebhookSecret)
+	if err != nil {
+		return nil, err
+	}
+	mailer, err := newMailer(cfg.Mail, secretResolver)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conectar a la base de datos. La contraseña se lee en cada conexión nueva para
+	// tomar las rotaciones
+	if err := database.ConnectDB(cfg.Database, dbPassword.Value); err != nil {
+		return nil, err
+	}
 
 	// Inicializar repositorios
 	db := database.GetDB()
-	userRepo := repositories.NewUserRepository(db)
-	profileRepo := repositories.NewProfileRepository(db)
+	userRepo := repositories.NewUserRepository(db, cfg.LoginHistoryLimit)
+	profileRepo := repositories.NewProfileRepository(db, cfg.LoginHistoryLimit)
 	roleRepo := repositories.NewRoleRepository(db)
+	loginRepo := repositories.NewLoginEventRepository(db, cfg.LoginHistoryLimit)
+	sessionRepo := repositories.NewSessionRepository(db)
+	refreshRepo := repositories.NewRefreshTokenRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	statusRepo := repositories.NewUserStatusRepository(db)
+	emailChangeRepo := repositories.NewEmailChangeRepository(db)
+	notificationRepo := repositories.NewNotificationDeliveryRepository(db)
+
+	// Redis compartido (caché y rate limiting), opcional
+	var redisClient *redis.Client
+	if redisURL != "" {
+		redisOptions, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		redisClient = redis.NewClient(redisOptions)
+	}
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	secretResolver.Start(workersCtx, cfg.Secrets.RefreshInterval)
+
+	// Caché read-through de usuarios y roles
+	if cfg.Cache.Enabled {
+		loader, err := newCacheLoader(workersCtx, cfg.Cache, redisClient)
+		if err != nil {
+			stopWorkers()
+			return nil, err
+		}
+		cachedUsers := repositories.NewCachedUserRepository(userRepo, loader)
+		userRepo = cachedUsers
+		roleRepo = repositories.NewCachedRoleRepository(roleRepo, loader)
+		loginRepo = repositories.WithLoginUserInvalidation(loginRepo, cachedUsers)
+		profileRepo = repositories.WithProfileUserInvalidation(profileRepo, cachedUsers)
+		statusRepo = repositories.WithStatusUserInvalidation(statusRepo, cachedUsers)
+		emailChangeRepo = repositories.WithEmailChangeUserInvalidation(emailChangeRepo, cachedUsers)
+	}
+
+	// Bus de eventos de dominio
+	eventBus := events.NewBus(0)
+	eventBus.Subscribe(events.AllEvents, events.LogHandler)
+
+	// Base de datos GeoIP para la detección de logins sospechosos
+	geoLocator, err := geoip.NewLocator(cfg.LoginRisk.GeoIPDBPath)
+	if err != nil {
+		stopWorkers()
+		return nil, err
+	}
+	// Ciclo de vida de los usuarios; reactiva las suspensiones temporales vencidas
+	statusService := services.NewUserStatusService(statusRepo, eventBus)
+	statusService.StartSuspensionExpiry(workersCtx, cfg.SuspensionExpiryInterval)
+
+	// Cambio de email con tokens firmados con EMAIL_TOKEN_SECRET (o JWT_SECRET)
+	emailTokenKey := func() string {
+		if key := emailTokenSecret.Value(); key != "" {
+			return key
+		}
+		return jwtSecret.Value()
+	}
+	emailService := services.NewEmailChangeService(userRepo, emailChangeRepo, mailer, eventBus, emailTokenKey, services.EmailChangeConfig{
+		TokenTTL:   cfg.EmailChange.TokenTTL,
+		ConfirmURL: cfg.EmailChange.ConfirmURL,
+	})
+
+	// Notificaciones a los usuarios por los canales configurados, con reintentos
+	var notificationService *services.NotificationService
+	if cfg.Notifications.Enabled {
+		channels := []notify.Channel{notify.NewEmailChannel(mailer)}
+		if cfg.Notifications.WebhookURL != "" {
+			channels = append(channels, notify.NewWebhookChannel(cfg.Notifications.WebhookURL, webhookSecret.Value, cfg.Notifications.WebhookTimeout))
+		}
+		notificationService = services.NewNotificationService(userRepo, profileRepo, notificationRepo, channels, services.NotificationConfig{
+			MaxAttempts:  cfg.Notifications.MaxAttempts,
+			RetryBackoff: cfg.Notifications.RetryBackoff,
+		})
+		notificationService.Subscribe(eventBus)
+		notificationService.StartRetries(workersCtx, cfg.Notifications.RetryInterval)
+	}
+
+	// Perfiles públicos; las vistas repetidas de un visitante se cuentan una vez por ventana
+	viewStore, err := newProfileViewStore(workersCtx, cfg.ProfileViews, redisClient)
+	if err != nil {
+		stopWorkers()
+		return nil, err
+	}
+	publicProfileService := services.NewPublicProfileService(userRepo, profileRepo, viewStore, cfg.ProfileViews.Window)
+
+	loginService := services.NewLoginService(userRepo, loginRepo, sessionRepo, statusService, geoLocator, eventBus, cfg.LoginRisk)
+	// UpdateLoginInfo pasa por la evaluación de riesgo de LoginService
+	userRepo = repositories.WithLoginRecorder(userRepo, loginService)
+
+	// Sesiones y lista de revocación consultada por el middleware de autenticación
+	tokenConfig := services.TokenConfig{
+		AccessTTL:  cfg.Auth.AccessTokenTTL,
+		RefreshTTL: cfg.Auth.RefreshTokenTTL,
+	}
+	// Sesiones revocadas: lista local sincronizada con la base de datos y, con Redis,
+	// compartida entre réplicas para rechazar los tokens revocados en el siguiente request
+	localRevocations := auth.NewMemoryRevocationList()
+	revocations, err := newRevocationList(cfg.Auth, localRevocations, redisClient)
+	if err != nil {
+		stopWorkers()
+		return nil, err
+	}
+	sessionService := services.NewSessionService(sessionRepo, refreshRepo, localRevocations, revocations, maxDuration(tokenConfig.AccessTTL, auth.DefaultTokenTTL))
+	sessionService.StartRevocationSync(workersCtx, cfg.Auth.RevocationSyncInterval)
+
+	trustedProxies, err := auth.ParseCIDRs(cfg.Auth.TrustedProxies)
+	if err != nil {
+		stopWorkers()
+		return nil, err
+	}
+
+	// Rate limiting: store en memoria o en Redis para compartir límites entre réplicas
+	rateLimitConfig, err := newRateLimitConfig(workersCtx, cfg.RateLimit, redisClient, trustedProxies)
+	if err != nil {
+		stopWorkers()
+		return nil, err
+	}
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, rateLimitConfig.Store)
+
+	// Idempotency-Key: respuestas guardadas en memoria o en Redis para compartirlas entre réplicas
+	idempotencyConfig, err := newIdempotencyConfig(workersCtx, cfg.Idempotency, redisClient)
+	if err != nil {
+		stopWorkers()
+		return nil, err
+	}
+
+	// Deadline de las consultas de cada request, con overrides por ruta
+	routeQueryTimeouts, err := middleware.ParseRouteTimeouts(cfg.Database.RouteQueryTimeouts)
+	if err != nil {
+		stopWorkers()
+		return nil, err
+	}
+
+	authConfig := middleware.AuthConfig{
+		Revocations:    revocations,
+		TrustedProxies: trustedProxies,
+		Required:       cfg.Auth.Required,
+		PublicPaths:    []string{"/api/v1/health", "/api/v1/health/", "/api/v1/ready", "/api/v1/auth/", "/api/v1/schemas/", "/api/v1/profiles/"},
+	}
+	if cfg.Features.APIKeys {
+		authConfig.APIKeys = apiKeyService
+	}
+	var jwtManager *auth.JWTManager
+	var keyRing *auth.KeyRing
+	if cfg.Auth.SigningKeys.Enabled() {
+		keyRing, err = newKeyRing(workersCtx, cfg.Auth.SigningKeys, secretResolver)
+		if err != nil {
+			stopWorkers()
+			return nil, err
+		}
+		keyRing.Start(workersCtx)
+		jwtManager = auth.NewJWTManagerWithKeyRing(keyRing, cfg.Auth.JWTIssuer)
+	} else if jwtSecret.Value() != "" {
+		jwtManager = auth.NewJWTManager(jwtSecret.Value(), cfg.Auth.JWTIssuer)
+		jwtSecret.OnChange(jwtManager.SetSecret)
+	}
+	if jwtManager != nil {
+		authConfig.Validator = jwtManager
+	} else {
+		logger.GetLogger().Warn("No JWT signing keys configured, bearer token authentication is disabled")
+	}
+
+	// Intercambio de ID tokens de Firebase por tokens del servicio
+	var firebaseVerifier services.IDTokenVerifier
+	if !cfg.Features.TokenExchange {
+		logger.GetLogger().Info("Token exchange disabled by FEATURE_TOKEN_EXCHANGE")
+	} else if cfg.Auth.FirebaseProjectID != "" {
+		firebaseVerifier = auth.NewFirebaseVerifier(cfg.Auth.FirebaseProjectID)
+	} else {
+		logger.GetLogger().Warn("FIREBASE_PROJECT_ID not set, token exchange is disabled")
+	}
+	tokenService := services.NewTokenService(userRepo, roleRepo, sessionRepo, refreshRepo, loginService, sessionService, jwtManager, firebaseVerifier, eventBus, tokenConfig)
 
 	server := &Server{
 		config:      cfg,
 		userRepo:    userRepo,
 		profileRepo: profileRepo,
 		roleRepo:    roleRepo,
+		loginRepo:   loginRepo,
+		stopWorkers: stopWorkers,
+	}
+
+	// Tareas de cierre, en orden: terminar los reintentos de notificaciones, entregar los
+	// eventos pendientes (que pueden generar notificaciones y trazas), vaciar las trazas y
+	// cerrar las conexiones que usaron
+	if notificationService != nil {
+		server.OnShutdown("notifications", notificationService.WaitRetries)
+	}
+	server.OnShutdown("events", eventBus.Close)
+	server.OnShutdown("tracing", shutdownTracerProvider)
+	server.OnShutdown("geoip", func(ctx context.Context) error {
+		return geoLocator.Close()
+	})
+	if redisClient != nil {
+		server.OnShutdown("redis", func(ctx context.Context) error {
+			return redisClient.Close()
+		})
 	}
 
-	server.router = handlers.SetupRoutes(server.userRepo, server.profileRepo, server.roleRepo)
+	// Probes: startup espera la base de datos y el esquema; ready además deja de pasar
+	// durante el apagado. Redis y el bus de eventos solo degradan el reporte
+	checkTimeout := cfg.Health.CheckTimeout
+	healthService := services.NewHealthService()
+	healthService.Register(services.ProbeStartup, services.HealthCheck{Name: "database", Critical: true, Timeout: checkTimeout, Check: services.DatabaseCheck(db)})
+	healthService.Register(services.ProbeStartup, services.HealthCheck{Name: "migrations", Critical: true, Timeout: maxDuration(checkTimeout, 10*time.Second), Check: services.MigrationsCheck(db)})
+	healthService.Register(services.ProbeReadiness, services.HealthCheck{Name: "shutdown", Critical: true, Check: services.ShutdownCheck(server.ready.Load)})
+	healthService.Register(services.ProbeReadiness, services.HealthCheck{Name: "database", Critical: true, Timeout: checkTimeout, Check: services.DatabaseCheck(db)})
+	if redisClient != nil {
+		healthService.Register(services.ProbeReadiness, services.HealthCheck{Name: "redis", Timeout: checkTimeout, Check: services.RedisCheck(redisClient)})
+	}
+	healthService.Register(services.ProbeReadiness, services.HealthCheck{Name: "events", Check: services.EventBusCheck(eventBus, cfg.Health.MaxEventLag)})
+
+	router, err := handlers.SetupRoutes(handlers.Dependencies{
+		UserRepo:       server.userRepo,
+		ProfileRepo:    server.profileRepo,
+		RoleRepo:       server.roleRepo,
+		LoginRepo:      server.loginRepo,
+		LoginService:   loginService,
+		StatusService:  statusService,
+		EmailService:   emailService,
+		Notifications:  notificationService,
+		PublicProfiles: publicProfileService,
+		Publisher:      eventBus,
+		SessionService: sessionService,
+		TokenService:   tokenService,
+		APIKeyService:  apiKeyService,
+		Auth:           authConfig,
+		RateLimit:      rateLimitConfig,
+		Idempotency:    idempotencyConfig,
+		QueryDeadline:  middleware.QueryDeadlineConfig{Default: cfg.Database.QueryTimeout, Routes: routeQueryTimeouts},
+		KeyRing:        keyRing,
+		HealthService:  healthService,
+		MaxBodyBytes:   int64(cfg.Server.MaxBodyBytes),
+		CORS: middleware.CORSConfig{
+			AllowedOrigins:   cfg.CORS.Origins(cfg.Environment),
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			ExposedHeaders:   cfg.CORS.ExposedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		},
+		ValidateRequests: cfg.Server.ValidateRequests,
+	})
+	if err != nil {
+		stopWorkers()
+		return nil, err
+	}
+	server.router = router
+	server.httpServer = &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.Server.Port),
+		Handler:           server.router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
 	return server, nil
 }
 
-func (s *Server) Start() error {
+// OnShutdown registra una tarea que se ejecuta al apagar, después de drenar las
+// conexiones HTTP y antes de cerrar el pool de la base de datos
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.shutdownHooks = append(s.shutdownHooks, shutdownHook{name: name, fn: fn})
+}
+
+// Run atiende requests hasta que ctx se cancele (SIGTERM) y luego apaga el servidor de
+// forma ordenada: deja de estar ready, espera ShutdownDelay, drena las conexiones
+// dentro de ShutdownTimeout y cierra los workers y el pool de la base de datos
+func (s *Server) Run(ctx context.Context) error {
 	log := logger.GetLogger()
-	
-	addr := fmt.Sprintf(":%s", s.config.Port)
-	log.WithField("address", addr).Info("Starting User Service server")
-	
-	return http.ListenAndServe(addr, s.router)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.WithField("address", s.httpServer.Addr).Info("Starting User Service server")
+		serveErr <- s.httpServer.ListenAndServe()
+	}()
+	s.ready.Store(true)
+
+	select {
+	case err := <-serveErr:
+		s.ready.Store(false)
+		s.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutdown signal received, draining connections")
+	s.ready.Store(false)
+	if delay := s.config.Server.ShutdownDelay; delay > 0 {
+		time.Sleep(delay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout)
+	defer cancel()
+	err := s.httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		log.WithError(err).Warn("Connections not drained before the shutdown deadline")
+	}
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+
+	if closeErr := s.Close(); closeErr != nil {
+		err = errors.Join(err, closeErr)
+	}
+	log.Info("User Service stopped")
+	return err
 }
 
+// Close detiene los workers, ejecuta las tareas de cierre registradas (eventos pendientes,
+// trazas, Redis) y cierra el pool de la base de datos, en ese orden
 func (s *Server) Close() error {
+	log := logger.GetLogger()
+	s.stopWorkers()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, hook := range s.shutdownHooks {
+		if err := hook.fn(ctx); err != nil {
+			log.WithError(err).WithField("hook", hook.name).Warn("Shutdown hook failed")
+		}
+	}
+
 	sqlDB, err := database.GetDB().DB()
 	if err != nil {
 		return err
 	}
 	return sqlDB.Close()
-}
\ No newline at end of file
+}
+
+// shutdownTracerProvider vacía y cierra el tracer provider global si se instaló uno del
+// SDK de OpenTelemetry (el provider por defecto no exporta nada)
+func shutdownTracerProvider(ctx context.Context) error {
+	if provider, ok := otel.GetTracerProvider().(interface {
+		Shutdown(ctx context.Context) error
+	}); ok {
+		return provider.Shutdown(ctx)
+	}
+	return nil
+}
+
+// newSecretResolver crea el resolver de referencias a secretos. vault:// solo está
+// disponible si se configuró VAULT_ADDR
+func newSecretResolver(cfg config.SecretsConfig) *secrets.Resolver {
+	resolver := secrets.NewResolver()
+	if cfg.VaultAddress != "" {
+		resolver.Register(secrets.SchemeVault, secrets.NewVaultProvider(secrets.VaultConfig{
+			Address:   cfg.VaultAddress,
+			Token:     cfg.VaultToken,
+			Namespace: cfg.VaultNamespace,
+			Timeout:   cfg.VaultTimeout,
+		}))
+	}
+	return resolver
+}
+
+// newKeyRing crea el key ring de firma desde archivos o secretos referenciados o, si
+// no hay, con llaves generadas
+func newKeyRing(ctx context.Context, cfg config.SigningKeysConfig, resolver *secrets.Resolver) (*auth.KeyRing, error) {
+	ringConfig := auth.KeyRingConfig{
+		Algorithm:        cfg.Algorithm,
+		RotationInterval: cfg.RotationInterval,
+		RotationOverlap:  cfg.RotationOverlap,
+		RetiredKeyTTL:    cfg.RetiredKeyTTL,
+		ReloadInterval:   cfg.ReloadInterval,
+	}
+	if cfg.ActiveKeyFile == "" {
+		logger.GetLogger().Warn("JWT_SIGNING_KEY_FILE not set, using in-memory signing keys (not shared between replicas)")
+		return auth.NewKeyRing(ctx, nil, ringConfig)
+	}
+
+	source := secrets.KeySource{
+		Resolver: resolver,
+		Active:   cfg.ActiveKeyFile,
+		Next:     cfg.NextKeyFile,
+		Retired:  cfg.RetiredKeyFiles,
+	}
+	return auth.NewKeyRing(ctx, source, ringConfig)
+}
+
+// newMailer crea el mailer configurado
+func newMailer(cfg config.MailConfig, resolver *secrets.Resolver) (mail.Mailer, error) {
+	switch cfg.Backend {
+	case "smtp":
+		password, err := resolver.Resolve(context.Background(), cfg.SMTPPassword)
+		if err != nil {
+			return nil, err
+		}
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: password,
+			From:     cfg.From,
+		}), nil
+	case "log", "":
+		logger.GetLogger().Warn("MAIL_BACKEND=log: emails are written to the log instead of being sent")
+		return mail.NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_BACKEND %q", cfg.Backend)
+	}
+}
+
+// newCacheLoader crea el caché de usuarios: LRU local o, con CACHE_BACKEND=redis, LRU
+// local más Redis compartido con invalidación entre réplicas
+func newCacheLoader(ctx context.Context, cfg config.CacheConfig, redisClient *redis.Client) (*cache.Loader, error) {
+	local := cache.NewLRU(cfg.MaxEntries)
+
+	switch cfg.Backend {
+	case "redis":
+		if redisClient == nil {
+			return nil, fmt.Errorf("CACHE_BACKEND=redis requires REDIS_URL")
+		}
+		layered := cache.NewLayered(local, redisClient, "cache:", cfg.LocalTTL)
+		layered.StartInvalidationListener(ctx)
+		return cache.NewLoader(layered, cfg.TTL, cfg.NegativeTTL, gorm.ErrRecordNotFound), nil
+	case "memory", "":
+		return cache.NewLoader(local, cfg.TTL, cfg.NegativeTTL, gorm.ErrRecordNotFound), nil
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q", cfg.Backend)
+	}
+}
+
+// newRateLimitConfig crea la configuración del rate limiting con el store configurado
+func newRateLimitConfig(ctx context.Context, cfg config.RateLimitConfig, redisClient *redis.Client, trustedProxies []*net.IPNet) (middleware.RateLimitConfig, error) {
+	routes, err := ratelimit.ParseRouteLimits(cfg.Routes)
+	if err != nil {
+		return middleware.RateLimitConfig{}, err
+	}
+
+	rateLimitConfig := middleware.RateLimitConfig{
+		Default:        ratelimit.Limit{Rate: cfg.RPS, Burst: cfg.Burst},
+		Routes:         routes,
+		TrustedProxies: trustedProxies,
+	}
+	switch cfg.Store {
+	case "redis":
+		if redisClient == nil {
+			return middleware.RateLimitConfig{}, fmt.Errorf("RATE_LIMIT_STORE=redis requires REDIS_URL")
+		}
+		rateLimitConfig.Store = ratelimit.NewRedisStore(redisClient, "ratelimit:")
+	case "memory", "":
+		store := ratelimit.NewMemoryStore()
+		store.StartCleanup(ctx, 10*time.Minute)
+		rateLimitConfig.Store = store
+	default:
+		return middleware.RateLimitConfig{}, fmt.Errorf("unknown RATE_LIMIT_STORE %q", cfg.Store)
+	}
+	return rateLimitConfig, nil
+}
+
+// newIdempotencyConfig crea la configuración del middleware de Idempotency-Key con el store
+// configurado. Deshabilitado retorna una configuración sin store
+func newIdempotencyConfig(ctx context.Context, cfg config.IdempotencyConfig, redisClient *redis.Client) (middleware.IdempotencyConfig, error) {
+	if !cfg.Enabled {
+		return middleware.IdempotencyConfig{}, nil
+	}
+
+	idempotencyConfig := middleware.IdempotencyConfig{
+		TTL:         cfg.TTL,
+		LockTimeout: cfg.LockTimeout,
+	}
+	switch cfg.Store {
+	case "redis":
+		if redisClient == nil {
+			return middleware.IdempotencyConfig{}, fmt.Errorf("IDEMPOTENCY_STORE=redis requires REDIS_URL")
+		}
+		idempotencyConfig.Store = idempotency.NewRedisStore(redisClient, "idempotency:")
+	case "memory", "":
+		store := idempotency.NewMemoryStore()
+		store.StartCleanup(ctx, 10*time.Minute)
+		idempotencyConfig.Store = store
+	default:
+		return middleware.IdempotencyConfig{}, fmt.Errorf("unknown IDEMPOTENCY_STORE %q", cfg.Store)
+	}
+	return idempotencyConfig, nil
+}
+
+func newRevocationList(cfg config.AuthConfig, local *auth.MemoryRevocationList, redisClient *redis.Client) (auth.RevocationList, error) {
+	switch cfg.RevocationStore {
+	case "redis":
+		if redisClient == nil {
+			return nil, fmt.Errorf("SESSION_REVOCATION_STORE=redis requires REDIS_URL")
+		}
+		return auth.NewRedisRevocationList(local, redisClient, "revoked_sessions:"), nil
+	case "memory", "":
+		return local, nil
+	default:
+		return nil, fmt.Errorf("unknown SESSION_REVOCATION_STORE %q", cfg.RevocationStore)
+	}
+}
+
+func newProfileViewStore(ctx context.Context, cfg config.ProfileViewsConfig, redisClient *redis.Client) (views.Store, error) {
+	switch cfg.Store {
+	case "redis":
+		if redisClient == nil {
+			return nil, fmt.Errorf("PROFILE_VIEWS_STORE=redis requires REDIS_URL")
+		}
+		return views.NewRedisStore(redisClient, "profile_views:"), nil
+	case "memory", "":
+		store := views.NewMemoryStore()
+		store.StartCleanup(ctx, 10*time.Minute)
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown PROFILE_VIEWS_STORE %q", cfg.Store)
+	}
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/ratelimit"
+	"it-user-service/internal/repositories"
+)
+
+// APIKeyPrefix identifica las API keys del servicio (facilita detectarlas en escaneos de secretos)
+const APIKeyPrefix = "itk_"
+
+// lastUsedInterval limita la frecuencia con la que se persiste el último uso de una llave
+const lastUsedInterval = time.Minute
+
+var (
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrAPIKeyIPForbidden = errors.New("api key not allowed from this ip")
+	ErrAPIKeyRateLimited = fmt.Errorf("api key %w", auth.ErrRateLimited)
+)
+
+// APIKeyService gestiona las API keys de servicio y autentica los requests que las usan.
+// El rate limit propio de cada llave usa el store del rate limiting, así con Redis el
+// límite se comparte entre réplicas
+type APIKeyService struct {
+	apiKeyRepo repositories.APIKeyRepositoryInterface
+	limits     ratelimit.Store
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepositoryInterface, limits ratelimit.Store) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		limits:     limits,
+	}
+}
+
+// CreateKey genera una nueva API key. La llave en claro solo se retorna aquí
+func (s *APIKeyService) CreateKey(ctx context.Context, req *models.CreateAPIKeyRequest, createdBy string) (*models.CreateAPIKeyResponse, error) {
+	if _, err := auth.ParseCIDRs(req.AllowedIPs); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	rawKey := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	key := &models.APIKey{
+		ID:           uuid.NewString(),
+		Name:         req.Name,
+		Prefix:       rawKey[:len(APIKeyPrefix)+8],
+		KeyHash:      hashAPIKey(rawKey),
+		Scopes:       req.Scopes,
+		AllowedIPs:   req.AllowedIPs,
+		RateLimitRPS: req.RateLimitRPS,
+		ExpiresAt:    req.ExpiresAt,
+		CreatedBy:    createdBy,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return &models.CreateAPIKeyResponse{APIKey: key, Key: rawKey}, nil
+}
+
+// ListKeys obtiene las API keys registradas
+func (s *APIKeyService) ListKeys(ctx context.Context, includeRevoked bool) ([]models.APIKey, error) {
+	return s.apiKeyRepo.GetAll(ctx, includeRevoked)
+}
+
+// RevokeKey revoca una API key
+func (s *APIKeyService) RevokeKey(ctx context.Context, id string) error {
+	return s.apiKeyRepo.Revoke(ctx, id)
+}
+
+// Authenticate valida una API key presentada desde ip: vigencia, allowlist de IPs y
+// rate limit propio. Retorna el principal con los scopes de la llave
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey, ip string) (*auth.Principal, error) {
+	if !strings.HasPrefix(rawKey, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if len(key.AllowedIPs) > 0 {
+		networks, err := auth.ParseCIDRs(key.AllowedIPs)
+		if err != nil || !auth.IPInNetworks(ip, networks) {
+			return nil, ErrAPIKeyIPForbidden
+		}
+	}
+
+	if !s.allow(ctx, key) {
+		return nil, ErrAPIKeyRateLimited
+	}
+
+	s.touchLastUsed(ctx, key, ip, now)
+
+	return &auth.Principal{
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// allow aplica el rate limit propio de la llave (RateLimitRPS = 0 usa solo el límite global).
+// Si el store no responde se deja pasar el request, como en el rate limiting global
+func (s *APIKeyService) allow(ctx context.Context, key *models.APIKey) bool {
+	if key.RateLimitRPS <= 0 || s.limits == nil {
+		return true
+	}
+
+	limit := ratelimit.Limit{Rate: float64(key.RateLimitRPS), Burst: key.RateLimitRPS * 2}
+	result, err := s.limits.Allow(ctx, "apikey:"+key.ID, limit)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("api_key_id", key.ID).Error("Rate limit store unavailable")
+		return true
+	}
+	return result.Allowed
+}
+
+// touchLastUsed persiste el último uso como máximo una vez por lastUsedInterval por llave.
+// Se compara con el último uso guardado, que comparten todas las réplicas
+func (s *APIKeyService) touchLastUsed(ctx context.Context, key *models.APIKey, ip string, now time.Time) {
+	if key.LastUsedAt != nil && now.Sub(*key.LastUsedAt) < lastUsedInterval {
+		return
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, ip, now); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("api_key_id", key.ID).Warn("Failed to record api key usage")
+	}
+}
+
+// hashAPIKey calcula el hash con el que se guarda la API key
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+	"it-user-service/internal/ratelimit"
+)
+
+type fakeAPIKeyRepo struct {
+	keys    map[string]*models.APIKey
+	touches int
+}
+
+func (r *fakeAPIKeyRepo) Create(_ context.Context, key *models.APIKey) error {
+	r.keys[key.ID] = key
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) GetByID(_ context.Context, id string) (*models.APIKey, error) {
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return key, nil
+}
+
+func (r *fakeAPIKeyRepo) GetByHash(_ context.Context, keyHash string) (*models.APIKey, error) {
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeAPIKeyRepo) GetAll(_ context.Context, includeRevoked bool) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	for _, key := range r.keys {
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func (r *fakeAPIKeyRepo) Revoke(_ context.Context, id string) error {
+	key, ok := r.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) TouchLastUsed(_ context.Context, id, ip string, usedAt time.Time) error {
+	r.touches++
+	r.keys[id].LastUsedAt, r.keys[id].LastUsedIP = &usedAt, ip
+	return nil
+}
+
+func createTestAPIKey(t *testing.T, service *APIKeyService, req models.CreateAPIKeyRequest) *models.CreateAPIKeyResponse {
+	t.Helper()
+	req.Name = "integration"
+	if req.Scopes == nil {
+		req.Scopes = []string{"users:read"}
+	}
+	created, err := service.CreateKey(context.Background(), &req, "admin-1")
+	require.NoError(t, err)
+	return created
+}
+
+func TestAPIKeyService_AuthenticateReturnsScopes(t *testing.T) {
+	repo := &fakeAPIKeyRepo{keys: map[string]*models.APIKey{}}
+	service := NewAPIKeyService(repo, ratelimit.NewMemoryStore())
+	ctx := context.Background()
+	created := createTestAPIKey(t, service, models.CreateAPIKeyRequest{Scopes: []string{"users:read", "sessions:write"}})
+
+	principal, err := service.Authenticate(ctx, created.Key, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, created.APIKey.ID, principal.APIKeyID)
+	assert.True(t, principal.HasScope("users:read"))
+	assert.True(t, principal.HasScope("sessions:write"))
+	assert.False(t, principal.HasScope("users:write"))
+
+	// El último uso se guarda una vez por intervalo
+	_, err = service.Authenticate(ctx, created.Key, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.touches)
+
+	_, err = service.Authenticate(ctx, "itk_unknown", "10.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = service.Authenticate(ctx, "not-an-api-key", "10.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestAPIKeyService_RejectsExpiredAndRevokedKeys(t *testing.T) {
+	repo := &fakeAPIKeyRepo{keys: map[string]*models.APIKey{}}
+	service := NewAPIKeyService(repo, ratelimit.NewMemoryStore())
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(-time.Minute)
+	expired := createTestAPIKey(t, service, models.CreateAPIKeyRequest{ExpiresAt: &expiresAt})
+	_, err := service.Authenticate(ctx, expired.Key, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	revoked := createTestAPIKey(t, service, models.CreateAPIKeyRequest{})
+	_, err = service.Authenticate(ctx, revoked.Key, "10.0.0.1")
+	require.NoError(t, err)
+	require.NoError(t, service.RevokeKey(ctx, revoked.APIKey.ID))
+	_, err = service.Authenticate(ctx, revoked.Key, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestAPIKeyService_EnforcesIPAllowlist(t *testing.T) {
+	repo := &fakeAPIKeyRepo{keys: map[string]*models.APIKey{}}
+	service := NewAPIKeyService(repo, ratelimit.NewMemoryStore())
+	ctx := context.Background()
+	created := createTestAPIKey(t, service, models.CreateAPIKeyRequest{AllowedIPs: []string{"10.0.0.0/24", "192.168.1.10"}})
+
+	_, err := service.Authenticate(ctx, created.Key, "10.0.0.42")
+	assert.NoError(t, err)
+	_, err = service.Authenticate(ctx, created.Key, "192.168.1.10")
+	assert.NoError(t, err)
+	_, err = service.Authenticate(ctx, created.Key, "10.0.1.1")
+	assert.ErrorIs(t, err, ErrAPIKeyIPForbidden)
+}
+
+func TestAPIKeyService_RateLimitIsSharedThroughStore(t *testing.T) {
+	repo := &fakeAPIKeyRepo{keys: map[string]*models.APIKey{}}
+	store := ratelimit.NewMemoryStore()
+	// Dos réplicas con el mismo store comparten el límite de la llave
+	replicaA := NewAPIKeyService(repo, store)
+	replicaB := NewAPIKeyService(repo, store)
+	ctx := context.Background()
+	created := createTestAPIKey(t, replicaA, models.CreateAPIKeyRequest{RateLimitRPS: 1})
+
+	_, err := replicaA.Authenticate(ctx, created.Key, "10.0.0.1")
+	assert.NoError(t, err)
+	_, err = replicaB.Authenticate(ctx, created.Key, "10.0.0.1")
+	assert.NoError(t, err)
+	_, err = replicaA.Authenticate(ctx, created.Key, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrAPIKeyRateLimited)
+	_, err = replicaB.Authenticate(ctx, created.Key, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrAPIKeyRateLimited)
+}
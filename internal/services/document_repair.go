@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+	"it-user-service/internal/schemas"
+)
+
+// documentRepairBatch es la cantidad de filas que se leen por consulta al reparar documentos
+const documentRepairBatch = 200
+
+// RepairDocuments corrige los documentos JSON de perfiles y configuraciones guardados antes de
+// validarlos contra sus schemas: los que no son objetos quedan en NULL y en el resto se
+// descartan las claves desconocidas y los valores inválidos (ver schemas.Document.Repair).
+// El valor original de cada documento modificado se guarda en document_archives con la
+// migración indicada. Las filas que cambian mientras tanto se saltean; ya se escribieron con
+// validación. Se ejecuta una sola vez desde el comando migrate. Retorna la cantidad de filas
+// corregidas
+func RepairDocuments(ctx context.Context, repo repositories.ProfileRepositoryInterface, migration string) (int64, error) {
+	repaired, err := repo.ClearNonObjectDocuments(ctx, migration)
+	if err != nil {
+		return 0, err
+	}
+
+	var afterID uint
+	for {
+		profiles, err := repo.ListProfiles(ctx, afterID, documentRepairBatch)
+		if err != nil {
+			return repaired, err
+		}
+		for i := range profiles {
+			profile := &profiles[i]
+			afterID = profile.ID
+			original := profileDocuments(profile)
+			if !schemas.RepairProfile(profile) {
+				continue
+			}
+			archives := changedDocuments(ctx, migration, "user_profiles", profile.ID, original, profileDocuments(profile))
+			if err := repo.ArchiveDocuments(ctx, archives); err != nil {
+				return repaired, err
+			}
+			if err := repo.Update(ctx, profile); err != nil {
+				if errors.Is(err, repositories.ErrVersionConflict) {
+					continue
+				}
+				return repaired, err
+			}
+			repaired++
+		}
+		if len(profiles) < documentRepairBatch {
+			break
+		}
+	}
+
+	afterID = 0
+	for {
+		settings, err := repo.ListSettings(ctx, afterID, documentRepairBatch)
+		if err != nil {
+			return repaired, err
+		}
+		for i := range settings {
+			userSettings := &settings[i]
+			afterID = userSettings.ID
+			original := settingsDocuments(userSettings)
+			if !schemas.RepairSettings(userSettings) {
+				continue
+			}
+			archives := changedDocuments(ctx, migration, "user_settings", userSettings.ID, original, settingsDocuments(userSettings))
+			if err := repo.ArchiveDocuments(ctx, archives); err != nil {
+				return repaired, err
+			}
+			if err := repo.UpdateSettings(ctx, userSettings); err != nil {
+				if errors.Is(err, repositories.ErrVersionConflict) {
+					continue
+				}
+				return repaired, err
+			}
+			repaired++
+		}
+		if len(settings) < documentRepairBatch {
+			break
+		}
+	}
+	return repaired, nil
+}
+
+// profileDocuments serializa los documentos del perfil por columna
+func profileDocuments(profile *models.UserProfile) map[string]string {
+	return serializeDocuments(map[string]models.JSONDocument{
+		"preferences": profile.Preferences,
+		"privacy":     profile.Privacy,
+	})
+}
+
+// settingsDocuments serializa los documentos de la configuración por columna
+func settingsDocuments(settings *models.UserSettings) map[string]string {
+	return serializeDocuments(map[string]models.JSONDocument{
+		"notifications": settings.Notifications,
+		"privacy":       settings.Privacy,
+		"security":      settings.Security,
+	})
+}
+
+func serializeDocuments(documents map[string]models.JSONDocument) map[string]string {
+	serialized := make(map[string]string, len(documents))
+	for column, doc := range documents {
+		if doc == nil {
+			continue
+		}
+		data, _ := json.Marshal(doc)
+		serialized[column] = string(data)
+	}
+	return serialized
+}
+
+// changedDocuments retorna los valores originales de las columnas que la reparación modificó
+func changedDocuments(ctx context.Context, migration, table string, rowID uint, before, after map[string]string) []models.DocumentArchive {
+	var archives []models.DocumentArchive
+	for column, value := range before {
+		if after[column] == value {
+			continue
+		}
+		logger.FromContext(ctx).WithFields(map[string]interface{}{
+			"table":  table,
+			"row_id": rowID,
+			"column": column,
+		}).Info("Archiving repaired document")
+		archives = append(archives, models.DocumentArchive{
+			Migration:   migration,
+			SourceTable: table,
+			RowID:       rowID,
+			ColumnName:  column,
+			Value:       value,
+		})
+	}
+	return archives
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"it-user-service/internal/events"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/mail"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+var (
+	ErrEmailChangeDisabled  = errors.New("email change is not configured")
+	ErrEmailUnchanged       = errors.New("new email is the current email")
+	ErrEmailInUse           = errors.New("email is already in use")
+	ErrInvalidEmailToken    = errors.New("invalid email change token")
+	ErrEmailTokenExpired    = errors.New("email change token expired")
+	ErrEmailTokenConsumed   = errors.New("email change token already used")
+	ErrEmailChangeNotActive = errors.New("user status does not allow email changes")
+)
+
+// EmailChangeConfig configura los tokens de cambio de email
+type EmailChangeConfig struct {
+	TokenTTL time.Duration
+	// ConfirmURL es la página que confirma el cambio; el token se agrega como ?token=
+	ConfirmURL string
+}
+
+// EmailChangeService implementa el cambio de email: el pedido guarda el nuevo email como
+// pendiente y envía al nuevo email un token firmado de un solo uso; la confirmación
+// reemplaza el email (verificado) y registra el anterior en el historial
+type EmailChangeService struct {
+	userRepo   repositories.UserRepositoryInterface
+	changeRepo repositories.EmailChangeRepositoryInterface
+	mailer     mail.Mailer
+	publisher  events.Publisher
+	secret     func() string
+	cfg        EmailChangeConfig
+	now        func() time.Time
+}
+
+// NewEmailChangeService crea el servicio. secret retorna la llave con la que se firman los
+// tokens (se lee en cada uso para tomar las rotaciones); vacía deshabilita el cambio de email
+func NewEmailChangeService(userRepo repositories.UserRepositoryInterface, changeRepo repositories.EmailChangeRepositoryInterface, mailer mail.Mailer, publisher events.Publisher, secret func() string, cfg EmailChangeConfig) *EmailChangeService {
+	return &EmailChangeService{
+		userRepo:   userRepo,
+		changeRepo: changeRepo,
+		mailer:     mailer,
+		publisher:  publisher,
+		secret:     secret,
+		cfg:        cfg,
+		now:        time.Now,
+	}
+}
+
+// RequestChange registra el cambio de email de user a newEmail y envía el token de
+// confirmación al nuevo email. Un pedido nuevo invalida los tokens anteriores
+func (s *EmailChangeService) RequestChange(ctx context.Context, user *models.User, newEmail string) (*models.EmailChange, error) {
+	if s.secret() == "" {
+		return nil, ErrEmailChangeDisabled
+	}
+	if user.Status != models.StatusActive && user.Status != models.StatusPending {
+		return nil, ErrEmailChangeNotActive
+	}
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, ErrEmailUnchanged
+	}
+	if _, err := s.userRepo.GetByEmail(ctx, newEmail); err == nil {
+		return nil, ErrEmailInUse
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	change := &models.EmailChange{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		NewEmail:  newEmail,
+		ExpiresAt: s.now().Add(s.cfg.TokenTTL).UTC().Truncate(time.Second),
+	}
+	if err := s.changeRepo.Create(ctx, change); err != nil {
+		return nil, err
+	}
+
+	token := s.sign(change)
+	msg := mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: "Confirm the change of your account email to " + newEmail + ":\n\n" +
+			s.confirmLink(token) + "\n\n" +
+			"The link expires at " + change.ExpiresAt.Format(time.RFC1123) + ". " +
+			"If you did not request this change, ignore this email.\n",
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// Confirm valida el token y aplica el cambio de email. Cada token se puede usar una sola vez
+func (s *EmailChangeService) Confirm(ctx context.Context, token string) (*models.User, error) {
+	if s.secret() == "" {
+		return nil, ErrEmailChangeDisabled
+	}
+	id, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidEmailToken
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidEmailToken
+	}
+
+	change, err := s.changeRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidEmailToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(token), []byte(s.sign(change))) {
+		return nil, ErrInvalidEmailToken
+	}
+	if change.ConfirmedAt != nil || change.CanceledAt != nil {
+		return nil, ErrEmailTokenConsumed
+	}
+	if !change.Pending(s.now()) {
+		return nil, ErrEmailTokenExpired
+	}
+
+	user, history, err := s.changeRepo.Confirm(ctx, change, s.now())
+	switch {
+	case errors.Is(err, repositories.ErrEmailChangeNotPending):
+		return nil, ErrEmailTokenConsumed
+	case errors.Is(err, repositories.ErrEmailTaken):
+		return nil, ErrEmailInUse
+	case err != nil:
+		return nil, err
+	}
+
+	s.publish(ctx, history)
+	return user, nil
+}
+
+// History retorna los emails anteriores de un usuario, del cambio más reciente al más antiguo
+func (s *EmailChangeService) History(ctx context.Context, userID string, limit, offset int) ([]models.EmailHistory, error) {
+	return s.changeRepo.GetHistory(ctx, userID, limit, offset)
+}
+
+// sign retorna el token del cambio: "<id>.<firma>", donde la firma (HMAC-SHA256) cubre el
+// ID, el usuario, el nuevo email y la expiración
+func (s *EmailChangeService) sign(change *models.EmailChange) string {
+	mac := hmac.New(sha256.New, []byte(s.secret()))
+	mac.Write([]byte(change.ID + "|" + change.UserID + "|" + change.NewEmail + "|" + strconv.FormatInt(change.ExpiresAt.Unix(), 10)))
+	return change.ID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *EmailChangeService) confirmLink(token string) string {
+	separator := "?"
+	if strings.Contains(s.cfg.ConfirmURL, "?") {
+		separator = "&"
+	}
+	return s.cfg.ConfirmURL + separator + "token=" + token
+}
+
+func (s *EmailChangeService) publish(ctx context.Context, history *models.EmailHistory) {
+	if s.publisher == nil {
+		return
+	}
+	payload := map[string]interface{}{
+		"email_change_id": history.EmailChangeID,
+		"previous_email":  history.PreviousEmail,
+		"new_email":       history.NewEmail,
+	}
+	if err := s.publisher.Publish(ctx, events.New(events.TypeUserEmailChanged, history.UserID, payload)); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("user_id", history.UserID).Error("Failed to emit user email changed event")
+	}
+}
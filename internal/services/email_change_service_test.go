@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"it-user-service/internal/mail"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+type fakeEmailUserRepo struct {
+	repositories.UserRepositoryInterface
+	emails map[string]bool
+}
+
+func (r *fakeEmailUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if r.emails[email] {
+		return &models.User{Email: email}, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeEmailChangeRepo struct {
+	changes map[string]*models.EmailChange
+	user    *models.User
+}
+
+func (r *fakeEmailChangeRepo) Create(ctx context.Context, change *models.EmailChange) error {
+	copied := *change
+	r.changes[change.ID] = &copied
+	return nil
+}
+
+func (r *fakeEmailChangeRepo) GetByID(ctx context.Context, id string) (*models.EmailChange, error) {
+	change, ok := r.changes[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *change
+	return &copied, nil
+}
+
+func (r *fakeEmailChangeRepo) Confirm(ctx context.Context, change *models.EmailChange, confirmedAt time.Time) (*models.User, *models.EmailHistory, error) {
+	stored := r.changes[change.ID]
+	if stored.ConfirmedAt != nil {
+		return nil, nil, repositories.ErrEmailChangeNotPending
+	}
+	stored.ConfirmedAt = &confirmedAt
+	history := &models.EmailHistory{UserID: r.user.ID, PreviousEmail: r.user.Email, NewEmail: change.NewEmail, EmailChangeID: change.ID}
+	r.user.Email = change.NewEmail
+	r.user.EmailVerified = true
+	return r.user, history, nil
+}
+
+func (r *fakeEmailChangeRepo) GetHistory(ctx context.Context, userID string, limit, offset int) ([]models.EmailHistory, error) {
+	return nil, nil
+}
+
+type recordingMailer struct {
+	sent []mail.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var tokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+func newTestEmailChangeService(user *models.User) (*EmailChangeService, *recordingMailer, *recordingPublisher) {
+	mailer := &recordingMailer{}
+	publisher := &recordingPublisher{}
+	service := NewEmailChangeService(
+		&fakeEmailUserRepo{emails: map[string]bool{"taken@example.com": true}},
+		&fakeEmailChangeRepo{changes: map[string]*models.EmailChange{}, user: user},
+		mailer, publisher,
+		func() string { return "test-secret" },
+		EmailChangeConfig{TokenTTL: time.Hour, ConfirmURL: "https://app.example.com/confirm-email"},
+	)
+	return service, mailer, publisher
+}
+
+func TestEmailChangeService_RequestAndConfirm(t *testing.T) {
+	user := &models.User{ID: "u1", Email: "old@example.com", Status: models.StatusActive}
+	service, mailer, publisher := newTestEmailChangeService(user)
+
+	_, err := service.RequestChange(context.Background(), user, "new@example.com")
+	require.NoError(t, err)
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, "new@example.com", mailer.sent[0].To)
+	match := tokenPattern.FindStringSubmatch(mailer.sent[0].Body)
+	require.Len(t, match, 2)
+
+	updated, err := service.Confirm(context.Background(), match[1])
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", updated.Email)
+	assert.True(t, updated.EmailVerified)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "old@example.com", publisher.events[0].Payload["previous_email"])
+
+	_, err = service.Confirm(context.Background(), match[1])
+	assert.ErrorIs(t, err, ErrEmailTokenConsumed)
+}
+
+func TestEmailChangeService_RejectsInvalidRequestsAndTokens(t *testing.T) {
+	user := &models.User{ID: "u1", Email: "old@example.com", Status: models.StatusActive}
+	service, mailer, _ := newTestEmailChangeService(user)
+
+	_, err := service.RequestChange(context.Background(), user, "taken@example.com")
+	assert.ErrorIs(t, err, ErrEmailInUse)
+	_, err = service.RequestChange(context.Background(), user, "OLD@example.com")
+	assert.ErrorIs(t, err, ErrEmailUnchanged)
+
+	_, err = service.RequestChange(context.Background(), user, "new@example.com")
+	require.NoError(t, err)
+	token := tokenPattern.FindStringSubmatch(mailer.sent[0].Body)[1]
+
+	_, err = service.Confirm(context.Background(), token+"x")
+	assert.ErrorIs(t, err, ErrInvalidEmailToken)
+	_, err = service.Confirm(context.Background(), "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidEmailToken)
+
+	service.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = service.Confirm(context.Background(), token)
+	assert.ErrorIs(t, err, ErrEmailTokenExpired)
+}
@@ -1,77 +1,223 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"it-user-service/internal/events"
+	"it-user-service/internal/models"
+)
+
+// ServiceName identifica al servicio en los reportes de salud
+const ServiceName = "it-user-service"
+
+// Version es la versión del binario (se puede fijar con -ldflags "-X ...services.Version=...")
+var Version = "dev"
+
+// Probe es el tipo de verificación que consulta el orquestador
+type Probe string
+
+const (
+	// ProbeLiveness indica si el proceso está vivo. No debe depender de servicios externos
+	ProbeLiveness Probe = "live"
+	// ProbeReadiness indica si el servicio puede recibir tráfico
+	ProbeReadiness Probe = "ready"
+	// ProbeStartup indica si el servicio terminó de iniciar. Una vez que pasa no se repite
+	ProbeStartup Probe = "startup"
 )
 
-type HealthService interface {
-	CheckHealth() map[string]interface{}
-	CheckReadiness() map[string]interface{}
+// Estados de un check y de un reporte
+const (
+	HealthPass = "pass"
+	HealthWarn = "warn"
+	HealthFail = "fail"
+)
+
+// defaultCheckTimeout se usa cuando un HealthCheck no define Timeout
+const defaultCheckTimeout = 2 * time.Second
+
+// HealthCheck es una verificación de una dependencia
+type HealthCheck struct {
+	Name string
+	// Critical hace fallar el probe; si no es crítico un error solo degrada el reporte (warn)
+	Critical bool
+	Timeout  time.Duration
+	Check    func(ctx context.Context) error
+}
+
+// CheckResult es el resultado de un HealthCheck
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
 }
 
-type healthService struct {
+// HealthReport es la respuesta de un probe
+type HealthReport struct {
+	Status    string        `json:"status"`
+	Probe     Probe         `json:"probe"`
+	Service   string        `json:"service"`
+	Version   string        `json:"version"`
+	Uptime    string        `json:"uptime"`
+	Timestamp time.Time     `json:"timestamp"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+// HealthService ejecuta los checks registrados para cada probe
+type HealthService struct {
 	startTime time.Time
+
+	mu      sync.RWMutex
+	checks  map[Probe][]HealthCheck
+	started atomic.Bool
 }
 
-func NewHealthService() HealthService {
-	return &healthService{
+func NewHealthService() *HealthService {
+	return &HealthService{
 		startTime: time.Now(),
+		checks:    make(map[Probe][]HealthCheck),
 	}
 }
 
-func (s *healthService) CheckHealth() map[string]interface{} {
-	return map[string]interface{}{
-		"status":    "healthy",
-		"timestamp": time.Now().UTC(),
-		"uptime":    time.Since(s.startTime).String(),
-		"service":   "microservice-template",
-		"version":   "1.0.0",
-	}
+// Register agrega un check a un probe
+func (s *HealthService) Register(probe Probe, check HealthCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks[probe] = append(s.checks[probe], check)
 }
 
-func (s *healthService) CheckReadiness() map[string]interface{} {
-	// Aquí puedes agregar checks adicionales como:
-	// - Conexión a base de datos
-	// - Conexión a servicios externos
-	// - Estado de dependencias críticas
-	
-	ready := true
-	checks := make(map[string]bool)
-	
-	// Ejemplo de checks (comentados para testing)
-	// checks["database"] = s.checkDatabase()
-	// checks["external_api"] = s.checkExternalAPI()
-	// checks["vault"] = s.checkVault()
-	
-	// Si algún check falla, el servicio no está ready
-	for _, check := range checks {
-		if !check {
-			ready = false
+// Check ejecuta en paralelo los checks del probe y reporta el resultado y la latencia
+// de cada uno. El probe de startup, una vez que pasa, ya no vuelve a ejecutar sus checks
+func (s *HealthService) Check(ctx context.Context, probe Probe) HealthReport {
+	s.mu.RLock()
+	checks := append([]HealthCheck{}, s.checks[probe]...)
+	s.mu.RUnlock()
+	if probe == ProbeStartup && s.started.Load() {
+		checks = nil
+	}
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	status := HealthPass
+	for _, result := range results {
+		if result.Status == HealthFail {
+			status = HealthFail
 			break
 		}
+		if result.Status == HealthWarn {
+			status = HealthWarn
+		}
+	}
+	if probe == ProbeStartup && status != HealthFail {
+		s.started.Store(true)
+	}
+
+	return HealthReport{
+		Status:    status,
+		Probe:     probe,
+		Service:   ServiceName,
+		Version:   Version,
+		Uptime:    time.Since(s.startTime).Round(time.Second).String(),
+		Timestamp: time.Now().UTC(),
+		Checks:    results,
+	}
+}
+
+func runCheck(ctx context.Context, check HealthCheck) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	result := CheckResult{
+		Name:      check.Name,
+		Status:    HealthPass,
+		Critical:  check.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Status = HealthWarn
+		if check.Critical {
+			result.Status = HealthFail
+		}
+	}
+	return result
+}
+
+// DatabaseCheck hace ping a la base de datos
+func DatabaseCheck(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
 	}
-	
-	return map[string]interface{}{
-		"ready":     ready,
-		"timestamp": time.Now().UTC(),
-		"checks":    checks,
+}
+
+// MigrationsCheck falla si faltan tablas o columnas de los modelos
+func MigrationsCheck(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		pending, err := models.PendingMigrations(db.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("pending migrations (run the migrate command): %s", strings.Join(pending, ", "))
+		}
+		return nil
 	}
 }
 
-// Ejemplos de checks comentados
-/*
-func (s *healthService) checkDatabase() bool {
-	// Implementar check de base de datos
-	return true
+// RedisCheck hace ping al Redis usado por el caché, el rate limiting y la invalidación
+func RedisCheck(client *redis.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
 }
 
-func (s *healthService) checkExternalAPI() bool {
-	// Implementar check de API externa
-	return true
+// EventBusCheck falla si el bus está cerrado o si la entrega de eventos se atrasa más de maxLag
+func EventBusCheck(bus *events.Bus, maxLag time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if bus.Closed() {
+			return events.ErrBusClosed
+		}
+		if lag := bus.Lag(); maxLag > 0 && lag > maxLag {
+			return fmt.Errorf("event delivery lag %s exceeds %s (%d pending)", lag.Round(time.Millisecond), maxLag, bus.Pending())
+		}
+		return nil
+	}
 }
 
-func (s *healthService) checkVault() bool {
-	// Implementar check de Vault
-	return true
+// ShutdownCheck falla cuando ready retorna false (el servicio se está apagando)
+func ShutdownCheck(ready func() bool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if !ready() {
+			return errors.New("shutting down")
+		}
+		return nil
+	}
 }
-*/
\ No newline at end of file
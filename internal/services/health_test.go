@@ -1,29 +1,61 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestHealthService_CheckHealth(t *testing.T) {
+func failingCheck(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func passingCheck(ctx context.Context) error {
+	return nil
+}
+
+func TestHealthService_LivenessWithoutChecks(t *testing.T) {
 	service := NewHealthService()
-	
-	result := service.CheckHealth()
-	
-	assert.Equal(t, "healthy", result["status"])
-	assert.Equal(t, "microservice-template", result["service"])
-	assert.Equal(t, "1.0.0", result["version"])
-	assert.NotNil(t, result["timestamp"])
-	assert.NotNil(t, result["uptime"])
+
+	report := service.Check(context.Background(), ProbeLiveness)
+
+	assert.Equal(t, HealthPass, report.Status)
+	assert.Equal(t, ServiceName, report.Service)
+	assert.Empty(t, report.Checks)
 }
 
-func TestHealthService_CheckReadiness(t *testing.T) {
+func TestHealthService_ReadinessReportsEachCheck(t *testing.T) {
 	service := NewHealthService()
-	
-	result := service.CheckReadiness()
-	
-	assert.Equal(t, true, result["ready"])
-	assert.NotNil(t, result["timestamp"])
-	assert.NotNil(t, result["checks"])
-}
\ No newline at end of file
+	service.Register(ProbeReadiness, HealthCheck{Name: "database", Critical: true, Check: passingCheck})
+	service.Register(ProbeReadiness, HealthCheck{Name: "redis", Check: failingCheck})
+
+	report := service.Check(context.Background(), ProbeReadiness)
+	assert.Equal(t, HealthWarn, report.Status)
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, HealthPass, report.Checks[0].Status)
+	assert.Equal(t, HealthWarn, report.Checks[1].Status)
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+
+	service.Register(ProbeReadiness, HealthCheck{Name: "shutdown", Critical: true, Check: ShutdownCheck(func() bool { return false })})
+	report = service.Check(context.Background(), ProbeReadiness)
+	assert.Equal(t, HealthFail, report.Status)
+}
+
+func TestHealthService_StartupPassesOnce(t *testing.T) {
+	service := NewHealthService()
+	calls := 0
+	service.Register(ProbeStartup, HealthCheck{Name: "migrations", Critical: true, Check: func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return errors.New("pending migrations: users")
+		}
+		return nil
+	}})
+
+	assert.Equal(t, HealthFail, service.Check(context.Background(), ProbeStartup).Status)
+	assert.Equal(t, HealthPass, service.Check(context.Background(), ProbeStartup).Status)
+	assert.Equal(t, HealthPass, service.Check(context.Background(), ProbeStartup).Status)
+	assert.Equal(t, 2, calls)
+}
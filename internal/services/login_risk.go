@@ -0,0 +1,155 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"it-user-service/internal/config"
+	"it-user-service/internal/models"
+)
+
+// minTravelDistanceKm evita falsos positivos de viaje imposible por la imprecisión de GeoIP
+const minTravelDistanceKm = 100
+
+// LoginRiskAssessment es el resultado de evaluar un login contra el historial del usuario
+type LoginRiskAssessment struct {
+	Score   int
+	Reasons []string
+	Flagged bool
+}
+
+// LoginRiskDetector detecta anomalías en un login: dispositivo nuevo, país nuevo,
+// viaje imposible entre logins consecutivos y ráfagas de logins
+type LoginRiskDetector struct {
+	cfg config.LoginRiskConfig
+}
+
+func NewLoginRiskDetector(cfg config.LoginRiskConfig) *LoginRiskDetector {
+	return &LoginRiskDetector{cfg: cfg}
+}
+
+// Assess evalúa event contra history (eventos previos del usuario, del más reciente al más antiguo)
+func (d *LoginRiskDetector) Assess(event *models.LoginEvent, history []models.LoginEvent) LoginRiskAssessment {
+	var assessment LoginRiskAssessment
+	add := func(reason string, score int) {
+		assessment.Reasons = append(assessment.Reasons, reason)
+		assessment.Score += score
+	}
+
+	var previous []models.LoginEvent
+	for _, past := range history {
+		if past.Success {
+			previous = append(previous, past)
+		}
+	}
+
+	// Sin logins exitosos previos todo es "nuevo", no tiene sentido marcarlo
+	if event.Success && len(previous) > 0 {
+		if d.isNewDevice(event, previous) {
+			add(models.RiskReasonNewDevice, d.cfg.NewDeviceScore)
+		}
+		if d.isNewCountry(event, previous) {
+			add(models.RiskReasonNewCountry, d.cfg.NewCountryScore)
+		}
+		if d.isImpossibleTravel(event, previous) {
+			add(models.RiskReasonImpossibleTravel, d.cfg.ImpossibleTravelScore)
+		}
+	}
+	if d.isBurst(event, history) {
+		add(models.RiskReasonLoginBurst, d.cfg.BurstScore)
+	}
+
+	if assessment.Score > 100 {
+		assessment.Score = 100
+	}
+	assessment.Flagged = len(assessment.Reasons) > 0 && assessment.Score >= d.cfg.FlagThreshold
+	return assessment
+}
+
+func deviceKey(event *models.LoginEvent) string {
+	if event.Device != "" {
+		return event.Device
+	}
+	return event.UserAgent
+}
+
+func (d *LoginRiskDetector) isNewDevice(event *models.LoginEvent, previous []models.LoginEvent) bool {
+	key := deviceKey(event)
+	if key == "" {
+		return false
+	}
+	for i := range previous {
+		if deviceKey(&previous[i]) == key {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *LoginRiskDetector) isNewCountry(event *models.LoginEvent, previous []models.LoginEvent) bool {
+	if event.Country == "" {
+		return false
+	}
+	known := false
+	for _, past := range previous {
+		if past.Country == "" {
+			continue
+		}
+		known = true
+		if past.Country == event.Country {
+			return false
+		}
+	}
+	return known
+}
+
+func (d *LoginRiskDetector) isImpossibleTravel(event *models.LoginEvent, previous []models.LoginEvent) bool {
+	if event.Latitude == nil || event.Longitude == nil || d.cfg.MaxTravelSpeedKmh <= 0 {
+		return false
+	}
+
+	// Comparar contra el último login exitoso con coordenadas
+	for _, past := range previous {
+		if past.Latitude == nil || past.Longitude == nil {
+			continue
+		}
+		distance := haversineKm(*past.Latitude, *past.Longitude, *event.Latitude, *event.Longitude)
+		if distance < minTravelDistanceKm {
+			return false
+		}
+		hours := event.CreatedAt.Sub(past.CreatedAt).Hours()
+		if hours <= 0 {
+			return true
+		}
+		return distance/hours > d.cfg.MaxTravelSpeedKmh
+	}
+	return false
+}
+
+func (d *LoginRiskDetector) isBurst(event *models.LoginEvent, history []models.LoginEvent) bool {
+	if d.cfg.BurstCount <= 0 || d.cfg.BurstWindowSeconds <= 0 {
+		return false
+	}
+
+	since := event.CreatedAt.Add(-time.Duration(d.cfg.BurstWindowSeconds) * time.Second)
+	count := 1 // el login actual
+	for _, past := range history {
+		if past.CreatedAt.Before(since) {
+			break
+		}
+		count++
+	}
+	return count >= d.cfg.BurstCount
+}
+
+// haversineKm calcula la distancia en km entre dos coordenadas
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
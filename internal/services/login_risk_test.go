@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"it-user-service/internal/config"
+	"it-user-service/internal/models"
+)
+
+func testRiskConfig() config.LoginRiskConfig {
+	return config.LoginRiskConfig{
+		Enabled:               true,
+		FlagThreshold:         50,
+		NewDeviceScore:        30,
+		NewCountryScore:       40,
+		ImpossibleTravelScore: 70,
+		MaxTravelSpeedKmh:     900,
+		BurstScore:            30,
+		BurstCount:            5,
+		BurstWindowSeconds:    300,
+	}
+}
+
+func coords(lat, lon float64) (*float64, *float64) {
+	return &lat, &lon
+}
+
+func TestLoginRiskDetector_FirstLoginIsNotFlagged(t *testing.T) {
+	detector := NewLoginRiskDetector(testRiskConfig())
+
+	event := &models.LoginEvent{Device: "iphone", Country: "CO", Success: true, CreatedAt: time.Now()}
+	result := detector.Assess(event, nil)
+
+	assert.False(t, result.Flagged)
+	assert.Empty(t, result.Reasons)
+}
+
+func TestLoginRiskDetector_NewDeviceAndCountry(t *testing.T) {
+	detector := NewLoginRiskDetector(testRiskConfig())
+	now := time.Now()
+
+	history := []models.LoginEvent{
+		{Device: "iphone", Country: "CO", Success: true, CreatedAt: now.Add(-48 * time.Hour)},
+	}
+	event := &models.LoginEvent{Device: "android", Country: "BR", Success: true, CreatedAt: now}
+	result := detector.Assess(event, history)
+
+	assert.True(t, result.Flagged)
+	assert.Equal(t, 70, result.Score)
+	assert.ElementsMatch(t, []string{models.RiskReasonNewDevice, models.RiskReasonNewCountry}, result.Reasons)
+}
+
+func TestLoginRiskDetector_ImpossibleTravel(t *testing.T) {
+	detector := NewLoginRiskDetector(testRiskConfig())
+	now := time.Now()
+
+	// Bogotá -> Madrid en una hora
+	bogLat, bogLon := coords(4.711, -74.072)
+	madLat, madLon := coords(40.416, -3.703)
+	history := []models.LoginEvent{
+		{Device: "iphone", Country: "CO", Latitude: bogLat, Longitude: bogLon, Success: true, CreatedAt: now.Add(-time.Hour)},
+	}
+	event := &models.LoginEvent{Device: "iphone", Country: "CO", Latitude: madLat, Longitude: madLon, Success: true, CreatedAt: now}
+	result := detector.Assess(event, history)
+
+	assert.True(t, result.Flagged)
+	assert.Contains(t, result.Reasons, models.RiskReasonImpossibleTravel)
+}
+
+func TestLoginRiskDetector_Burst(t *testing.T) {
+	cfg := testRiskConfig()
+	cfg.FlagThreshold = 30
+	detector := NewLoginRiskDetector(cfg)
+	now := time.Now()
+
+	var history []models.LoginEvent
+	for i := 1; i <= 4; i++ {
+		history = append(history, models.LoginEvent{Device: "iphone", Success: false, CreatedAt: now.Add(-time.Duration(i) * time.Second)})
+	}
+	event := &models.LoginEvent{Device: "iphone", Success: false, CreatedAt: now}
+	result := detector.Assess(event, history)
+
+	assert.True(t, result.Flagged)
+	assert.Equal(t, []string{models.RiskReasonLoginBurst}, result.Reasons)
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"it-user-service/internal/config"
+	"it-user-service/internal/events"
+	"it-user-service/internal/geoip"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+// loginHistoryWindow es la cantidad de logins previos contra los que se evalúa el riesgo
+const loginHistoryWindow = 50
+
+// LoginService registra logins evaluando su riesgo antes de persistirlos
+type LoginService struct {
+	userRepo    repositories.UserRepositoryInterface
+	loginRepo   repositories.LoginEventRepositoryInterface
+	sessionRepo repositories.SessionRepositoryInterface
+	statuses    *UserStatusService
+	locator     geoip.Locator
+	detector    *LoginRiskDetector
+	publisher   events.Publisher
+	cfg         config.LoginRiskConfig
+}
+
+func NewLoginService(userRepo repositories.UserRepositoryInterface, loginRepo repositories.LoginEventRepositoryInterface, sessionRepo repositories.SessionRepositoryInterface, statuses *UserStatusService, locator geoip.Locator, publisher events.Publisher, cfg config.LoginRiskConfig) *LoginService {
+	return &LoginService{
+		userRepo:    userRepo,
+		loginRepo:   loginRepo,
+		sessionRepo: sessionRepo,
+		statuses:    statuses,
+		locator:     locator,
+		detector:    NewLoginRiskDetector(cfg),
+		publisher:   publisher,
+		cfg:         cfg,
+	}
+}
+
+// RecordLogin geolocaliza el login, lo evalúa contra el historial del usuario, lo persiste
+// y ejecuta las acciones automáticas configuradas si resulta sospechoso. Si el login fue
+// exitoso y se recibe session, registra el dispositivo y el evento vinculado a esa sesión
+// en la misma transacción
+func (s *LoginService) RecordLogin(ctx context.Context, event *models.LoginEvent, session *models.Session) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if s.cfg.Enabled {
+		s.locate(event)
+		s.assess(ctx, event)
+	}
+
+	if event.Success && session != nil {
+		session.UserID = event.UserID
+		session.IP = event.IP
+		session.UserAgent = event.UserAgent
+		if session.DeviceName == "" {
+			session.DeviceName = event.Device
+		}
+		if session.DeviceID == "" {
+			session.DeviceID = fallbackDeviceID(event)
+		}
+		if err := s.loginRepo.RecordWithSession(ctx, event, session); err != nil {
+			return err
+		}
+	} else if err := s.loginRepo.Record(ctx, event); err != nil {
+		return err
+	}
+
+	if event.Flagged {
+		s.handleSuspiciousLogin(ctx, event)
+	}
+	if event.Success && session != nil && s.isNewDevice(ctx, session) {
+		s.publishNewDevice(ctx, event, session)
+	}
+	return nil
+}
+
+// isNewDevice indica si session se acaba de crear para un dispositivo que el usuario no había
+// usado, siempre que tenga otros dispositivos (el primer login no cuenta como dispositivo nuevo)
+func (s *LoginService) isNewDevice(ctx context.Context, session *models.Session) bool {
+	if !session.FirstSeenAt.Equal(session.LastSeenAt) {
+		return false
+	}
+	sessions, err := s.sessionRepo.GetByUserID(ctx, session.UserID, true)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("user_id", session.UserID).
+			Warn("Failed to load user sessions, skipping new device detection")
+		return false
+	}
+	for _, other := range sessions {
+		if other.DeviceID != session.DeviceID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *LoginService) publishNewDevice(ctx context.Context, event *models.LoginEvent, session *models.Session) {
+	if s.publisher == nil {
+		return
+	}
+	device := session.DeviceName
+	if device == "" {
+		device = session.UserAgent
+	}
+	payload := map[string]interface{}{
+		"login_event_id": event.ID,
+		"session_id":     session.ID,
+		"device":         device,
+		"platform":       session.Platform,
+		"ip":             event.IP,
+		"country":        event.Country,
+	}
+	if err := s.publisher.Publish(ctx, events.New(events.TypeNewDeviceLogin, event.UserID, payload)); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("user_id", event.UserID).Error("Failed to emit new device login event")
+	}
+}
+
+// fallbackDeviceID deriva un identificador estable para clientes que no envían device_id
+func fallbackDeviceID(event *models.LoginEvent) string {
+	sum := sha256.Sum256([]byte(event.Device + "|" + event.UserAgent))
+	return "derived-" + hex.EncodeToString(sum[:12])
+}
+
+func (s *LoginService) locate(event *models.LoginEvent) {
+	if event.IP == "" || s.locator == nil {
+		return
+	}
+	location, err := s.locator.Lookup(event.IP)
+	if err != nil {
+		return
+	}
+	event.Country = location.Country
+	if location.HasCoordinates {
+		lat, lon := location.Latitude, location.Longitude
+		event.Latitude = &lat
+		event.Longitude = &lon
+	}
+}
+
+func (s *LoginService) assess(ctx context.Context, event *models.LoginEvent) {
+	history, err := s.loginRepo.GetByUserID(ctx, event.UserID, loginHistoryWindow, 0)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("user_id", event.UserID).
+			Warn("Failed to load login history, skipping risk assessment")
+		return
+	}
+
+	assessment := s.detector.Assess(event, history)
+	event.RiskScore = assessment.Score
+	event.RiskReasons = assessment.Reasons
+	event.Flagged = assessment.Flagged
+}
+
+func (s *LoginService) handleSuspiciousLogin(ctx context.Context, event *models.LoginEvent) {
+	log := logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"user_id":        event.UserID,
+		"login_event_id": event.ID,
+		"risk_score":     event.RiskScore,
+		"risk_reasons":   event.RiskReasons,
+	})
+	log.Warn("Suspicious login detected")
+
+	action := ""
+	if s.cfg.AutoPendingThreshold > 0 && event.RiskScore >= s.cfg.AutoPendingThreshold {
+		if err := s.markUserPending(ctx, event); err != nil {
+			log.WithError(err).Error("Failed to set user status to pending after suspicious login")
+		} else {
+			action = "status_pending"
+		}
+	}
+
+	if s.cfg.EmitEvents && s.publisher != nil {
+		payload := map[string]interface{}{
+			"login_event_id": event.ID,
+			"risk_score":     event.RiskScore,
+			"risk_reasons":   event.RiskReasons,
+			"ip":             event.IP,
+			"country":        event.Country,
+			"device":         event.Device,
+			"action":         action,
+		}
+		if err := s.publisher.Publish(ctx, events.New(events.TypeSuspiciousLogin, event.UserID, payload)); err != nil {
+			log.WithError(err).Error("Failed to emit suspicious login event")
+		}
+	}
+}
+
+// markUserPending pasa el usuario a pending por un login sospechoso. Los usuarios que no
+// pueden pasar a pending (suspendidos, desactivados) no cambian
+func (s *LoginService) markUserPending(ctx context.Context, event *models.LoginEvent) error {
+	user, err := s.userRepo.GetByID(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	if !models.CanTransition(user.Status, models.StatusPending) {
+		return nil
+	}
+	_, err = s.statuses.Transition(ctx, user, StatusTransition{
+		Status: models.StatusPending,
+		Reason: models.StatusReasonSuspiciousActivity,
+		Note:   fmt.Sprintf("login event %d with risk score %d", event.ID, event.RiskScore),
+		Actor:  ActorSystem,
+	})
+	return err
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"it-user-service/internal/events"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/notify"
+	"it-user-service/internal/repositories"
+)
+
+const (
+	// notificationRetryBatch es la cantidad de entregas que se reintentan por ciclo
+	notificationRetryBatch = 100
+	// maxNotificationBackoff limita la espera entre reintentos
+	maxNotificationBackoff = time.Hour
+)
+
+// NotificationConfig configura los reintentos de las notificaciones
+type NotificationConfig struct {
+	// MaxAttempts es la cantidad de intentos antes de marcar la entrega como fallida
+	MaxAttempts int
+	// RetryBackoff es la espera antes del primer reintento; se duplica en cada intento
+	RetryBackoff time.Duration
+}
+
+// NotificationService notifica a los usuarios los eventos de dominio que los afectan. Cada
+// notificación se renderiza en el idioma y la zona horaria del usuario, se entrega por los
+// canales que el usuario no deshabilitó y queda registrada con sus intentos; las entregas
+// fallidas se reintentan con backoff exponencial
+type NotificationService struct {
+	userRepo     repositories.UserRepositoryInterface
+	profileRepo  repositories.ProfileRepositoryInterface
+	deliveryRepo repositories.NotificationDeliveryRepositoryInterface
+	channels     []notify.Channel
+	cfg          NotificationConfig
+	now          func() time.Time
+	retries      sync.WaitGroup
+}
+
+func NewNotificationService(userRepo repositories.UserRepositoryInterface, profileRepo repositories.ProfileRepositoryInterface, deliveryRepo repositories.NotificationDeliveryRepositoryInterface, channels []notify.Channel, cfg NotificationConfig) *NotificationService {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = time.Minute
+	}
+	return &NotificationService{
+		userRepo:     userRepo,
+		profileRepo:  profileRepo,
+		deliveryRepo: deliveryRepo,
+		channels:     channels,
+		cfg:          cfg,
+		now:          time.Now,
+	}
+}
+
+// Subscribe suscribe el servicio a los eventos que generan notificaciones
+func (s *NotificationService) Subscribe(bus *events.Bus) {
+	for _, eventType := range []string{events.TypeUserCreated, events.TypeUserRoleGranted, events.TypeUserStatusChanged, events.TypeNewDeviceLogin} {
+		bus.Subscribe(eventType, s.HandleEvent)
+	}
+}
+
+// HandleEvent renderiza la notificación de event y la entrega por cada canal habilitado
+// para el usuario. Los eventos que no generan notificaciones se ignoran
+func (s *NotificationService) HandleEvent(ctx context.Context, event events.Event) error {
+	kind, data := notificationFor(event)
+	if kind == "" {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Status == models.StatusDeleted {
+		return nil
+	}
+
+	language, location, prefs := s.userPreferences(ctx, user.ID)
+	data.Name = displayName(user)
+	data.Time = notify.FormatTime(event.OccurredAt, language, location)
+	if until, ok := event.Payload["until"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, until); err == nil {
+			data.Until = notify.FormatTime(t, language, location)
+		}
+	}
+
+	subject, body, err := notify.Render(kind, language, data)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, channel := range s.channels {
+		if !prefs.Allows(channel.Name(), kind) {
+			continue
+		}
+		recipient := ""
+		if channel.Name() == notify.ChannelEmail {
+			if user.Email == "" {
+				continue
+			}
+			recipient = user.Email
+		}
+
+		// El primer intento es inmediato; next_attempt_at reserva la entrega para que el
+		// worker de reintentos no la tome mientras tanto
+		reserved := s.now().Add(s.cfg.RetryBackoff)
+		delivery := &models.NotificationDelivery{
+			ID:            uuid.NewString(),
+			UserID:        user.ID,
+			EventID:       event.ID,
+			Kind:          kind,
+			Channel:       channel.Name(),
+			Recipient:     recipient,
+			Language:      language,
+			Subject:       subject,
+			Body:          body,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &reserved,
+		}
+		if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.attempt(ctx, channel, delivery)
+	}
+	return errors.Join(errs...)
+}
+
+// RetryDue reintenta las entregas pendientes cuyo próximo intento venció. Retorna cuántas se
+// entregaron
+func (s *NotificationService) RetryDue(ctx context.Context) (int, error) {
+	due, err := s.deliveryRepo.ClaimDue(ctx, s.now(), s.cfg.RetryBackoff, notificationRetryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range due {
+		delivery := &due[i]
+		channel := s.channel(delivery.Channel)
+		if channel == nil {
+			delivery.Status = models.DeliveryFailed
+			delivery.LastError = "channel not configured"
+			delivery.NextAttemptAt = nil
+			if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if s.attempt(ctx, channel, delivery) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// StartRetries reintenta las entregas pendientes cada interval hasta que ctx se cancele
+func (s *NotificationService) StartRetries(ctx context.Context, interval time.Duration) {
+	log := logger.GetLogger()
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	s.retries.Add(1)
+	go func() {
+		defer s.retries.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sent, err := s.RetryDue(ctx)
+				if err != nil {
+					log.WithError(err).Error("Failed to retry notification deliveries")
+				}
+				if sent > 0 {
+					log.WithField("count", sent).Info("Notification deliveries retried")
+				}
+			}
+		}
+	}()
+}
+
+// WaitRetries espera a que termine el ciclo de reintentos en curso después de cancelar el
+// contexto de StartRetries, o a que expire ctx
+func (s *NotificationService) WaitRetries(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.retries.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Deliveries retorna el registro de entregas de un usuario, de la más reciente a la más antigua
+func (s *NotificationService) Deliveries(ctx context.Context, userID string, limit, offset int) ([]models.NotificationDelivery, error) {
+	return s.deliveryRepo.GetByUserID(ctx, userID, limit, offset)
+}
+
+// attempt envía la entrega por channel y guarda el resultado. Retorna si se entregó
+func (s *NotificationService) attempt(ctx context.Context, channel notify.Channel, delivery *models.NotificationDelivery) bool {
+	log := logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"user_id":     delivery.UserID,
+		"delivery_id": delivery.ID,
+		"kind":        delivery.Kind,
+		"channel":     delivery.Channel,
+	})
+
+	err := channel.Send(ctx, notify.Notification{
+		ID:        delivery.ID,
+		Kind:      delivery.Kind,
+		UserID:    delivery.UserID,
+		EventID:   delivery.EventID,
+		Language:  delivery.Language,
+		Recipient: delivery.Recipient,
+		Subject:   delivery.Subject,
+		Body:      delivery.Body,
+		CreatedAt: delivery.CreatedAt,
+	})
+
+	now := s.now()
+	delivery.Attempts++
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySent
+		delivery.SentAt = &now
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+	case delivery.Attempts >= s.cfg.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = truncate(err.Error(), 1000)
+		log.WithError(err).WithField("attempts", delivery.Attempts).Error("Notification delivery failed, giving up")
+	default:
+		next := now.Add(s.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.LastError = truncate(err.Error(), 1000)
+		log.WithError(err).WithField("attempts", delivery.Attempts).Warn("Notification delivery failed, will retry")
+	}
+
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		log.WithError(err).Error("Failed to save notification delivery")
+	}
+	return delivery.Status == models.DeliverySent
+}
+
+// backoff retorna la espera antes del reintento siguiente a attempts intentos fallidos
+func (s *NotificationService) backoff(attempts int) time.Duration {
+	wait := s.cfg.RetryBackoff
+	for i := 1; i < attempts && wait < maxNotificationBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxNotificationBackoff {
+		return maxNotificationBackoff
+	}
+	return wait
+}
+
+func (s *NotificationService) channel(name string) notify.Channel {
+	for _, channel := range s.channels {
+		if channel.Name() == name {
+			return channel
+		}
+	}
+	return nil
+}
+
+// userPreferences retorna el idioma, la zona horaria y las preferencias de notificación del
+// usuario. Sin configuraciones, o con valores inválidos, se usan los defaults
+func (s *NotificationService) userPreferences(ctx context.Context, userID string) (string, *time.Location, notify.Preferences) {
+	log := logger.FromContext(ctx).WithField("user_id", userID)
+
+	settings, err := s.profileRepo.GetSettingsByUserID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.WithError(err).Warn("Failed to load user settings, using notification defaults")
+		}
+		return notify.DefaultLanguage, time.UTC, notify.Preferences{}
+	}
+
+	var prefs notify.Preferences
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		log.WithField("timezone", settings.Timezone).Warn("Invalid user timezone, using UTC")
+		location = time.UTC
+	}
+	raw, err := json.Marshal(settings.Notifications)
+	if err == nil {
+		prefs, err = notify.ParsePreferences(raw)
+	}
+	if err != nil {
+		log.WithError(err).Warn("Invalid notification preferences, using defaults")
+	}
+	return notify.Language(settings.Language), location, prefs
+}
+
+// notificationFor retorna el tipo de notificación de event y los datos propios del evento,
+// o "" si el evento no se notifica
+func notificationFor(event events.Event) (string, notify.TemplateData) {
+	payload := func(key string) string {
+		value, _ := event.Payload[key].(string)
+		return value
+	}
+
+	switch event.Type {
+	case events.TypeUserCreated:
+		return notify.KindWelcome, notify.TemplateData{}
+	case events.TypeUserRoleGranted:
+		return notify.KindRoleGranted, notify.TemplateData{Role: payload("role")}
+	case events.TypeUserStatusChanged:
+		if payload("to_status") != models.StatusSuspended {
+			return "", notify.TemplateData{}
+		}
+		return notify.KindAccountSuspended, notify.TemplateData{Reason: payload("reason")}
+	case events.TypeNewDeviceLogin:
+		return notify.KindNewDeviceLogin, notify.TemplateData{
+			Device:  payload("device"),
+			IP:      payload("ip"),
+			Country: payload("country"),
+		}
+	}
+	return "", notify.TemplateData{}
+}
+
+// displayName retorna el nombre con el que se saluda al usuario
+func displayName(user *models.User) string {
+	if user.FirstName != "" {
+		return user.FirstName
+	}
+	if user.Username != "" {
+		return user.Username
+	}
+	name, _, _ := strings.Cut(user.Email, "@")
+	return name
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
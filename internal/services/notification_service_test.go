@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"it-user-service/internal/events"
+	"it-user-service/internal/models"
+	"it-user-service/internal/notify"
+	"it-user-service/internal/repositories"
+)
+
+type fakeNotificationUserRepo struct {
+	repositories.UserRepositoryInterface
+	user *models.User
+}
+
+func (r *fakeNotificationUserRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return r.user, nil
+}
+
+type fakeSettingsRepo struct {
+	repositories.ProfileRepositoryInterface
+	settings *models.UserSettings
+}
+
+func (r *fakeSettingsRepo) GetSettingsByUserID(ctx context.Context, userID string) (*models.UserSettings, error) {
+	return r.settings, nil
+}
+
+type fakeDeliveryRepo struct {
+	deliveries []*models.NotificationDelivery
+}
+
+func (r *fakeDeliveryRepo) Create(ctx context.Context, delivery *models.NotificationDelivery) error {
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func (r *fakeDeliveryRepo) Update(ctx context.Context, delivery *models.NotificationDelivery) error {
+	return nil
+}
+
+func (r *fakeDeliveryRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.NotificationDelivery, error) {
+	var due []models.NotificationDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, *delivery)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeDeliveryRepo) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.NotificationDelivery, error) {
+	return nil, nil
+}
+
+type recordingChannel struct {
+	name string
+	err  error
+	sent []notify.Notification
+}
+
+func (c *recordingChannel) Name() string {
+	return c.name
+}
+
+func (c *recordingChannel) Send(ctx context.Context, n notify.Notification) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.sent = append(c.sent, n)
+	return nil
+}
+
+func TestNotificationService_RendersInUserLanguageAndRespectsPreferences(t *testing.T) {
+	email := &recordingChannel{name: notify.ChannelEmail}
+	webhook := &recordingChannel{name: notify.ChannelWebhook}
+	deliveries := &fakeDeliveryRepo{}
+	service := NewNotificationService(
+		&fakeNotificationUserRepo{user: &models.User{ID: "u1", Email: "ana@example.com", FirstName: "Ana", Status: models.StatusActive}},
+		&fakeSettingsRepo{settings: &models.UserSettings{
+			Language:      "es-AR",
+			Timezone:      "America/Argentina/Buenos_Aires",
+			Notifications: models.JSONDocument{"webhook": map[string]interface{}{"kinds": map[string]interface{}{"role_granted": false}}},
+		}},
+		deliveries, []notify.Channel{email, webhook}, NotificationConfig{MaxAttempts: 3, RetryBackoff: time.Minute},
+	)
+
+	event := events.New(events.TypeUserRoleGranted, "u1", map[string]interface{}{"role": "moderator"})
+	event.OccurredAt = time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	require.NoError(t, service.HandleEvent(context.Background(), event))
+
+	require.Len(t, email.sent, 1)
+	assert.Empty(t, webhook.sent)
+	assert.Equal(t, "ana@example.com", email.sent[0].Recipient)
+	assert.Equal(t, "Tienes un nuevo rol: moderator", email.sent[0].Subject)
+	assert.Contains(t, email.sent[0].Body, "Hola Ana")
+	assert.Contains(t, email.sent[0].Body, "01/03/2024 12:00")
+	require.Len(t, deliveries.deliveries, 1)
+	assert.Equal(t, models.DeliverySent, deliveries.deliveries[0].Status)
+}
+
+func TestNotificationService_RetriesFailedDeliveries(t *testing.T) {
+	email := &recordingChannel{name: notify.ChannelEmail, err: errors.New("smtp unavailable")}
+	deliveries := &fakeDeliveryRepo{}
+	service := NewNotificationService(
+		&fakeNotificationUserRepo{user: &models.User{ID: "u1", Email: "ana@example.com", Status: models.StatusActive}},
+		&fakeSettingsRepo{settings: &models.UserSettings{Language: "en", Timezone: "UTC"}},
+		deliveries, []notify.Channel{email}, NotificationConfig{MaxAttempts: 2, RetryBackoff: time.Minute},
+	)
+
+	suspended := events.New(events.TypeUserStatusChanged, "u1", map[string]interface{}{"to_status": models.StatusSuspended, "reason": "fraud"})
+	require.NoError(t, service.HandleEvent(context.Background(), suspended))
+	require.Len(t, deliveries.deliveries, 1)
+	delivery := deliveries.deliveries[0]
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, "smtp unavailable", delivery.LastError)
+
+	// Antes del backoff no hay nada que reintentar
+	sent, err := service.RetryDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	email.err = nil
+	service.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	sent, err = service.RetryDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, email.sent, 1)
+	assert.Equal(t, "Your account has been suspended", email.sent[0].Subject)
+
+	// Los cambios de estado que no son suspensiones no se notifican
+	reinstated := events.New(events.TypeUserStatusChanged, "u1", map[string]interface{}{"to_status": models.StatusActive})
+	require.NoError(t, service.HandleEvent(context.Background(), reinstated))
+	assert.Len(t, deliveries.deliveries, 1)
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+	"it-user-service/internal/schemas"
+	"it-user-service/internal/views"
+)
+
+// ErrProfileNotFound indica un usuario inexistente o cuyo perfil el visitante no puede ver
+var ErrProfileNotFound = errors.New("profile not found")
+
+// Relaciones del visitante con el dueño de un perfil público
+const (
+	ViewerAnonymous     = "anonymous"
+	ViewerAuthenticated = "authenticated"
+	ViewerSelf          = "self"
+	ViewerPrivileged    = "privileged"
+)
+
+// Viewer es quien pide un perfil público
+type Viewer struct {
+	// UserID es el usuario autenticado (vacío para visitantes anónimos y API keys)
+	UserID string
+	// Privileged indica un administrador o una API key, que ven todos los campos
+	Privileged bool
+	// Key identifica al visitante para contar sus vistas una sola vez, por ejemplo
+	// "user:<id>" o "ip:<ip>"
+	Key string
+}
+
+// PublicProfileService arma la proyección pública de los perfiles: cada campo se muestra
+// según su visibilidad en UserProfile.Privacy y la visibilidad general del perfil en
+// UserSettings.Privacy, y cada visitante suma una vista por ventana
+type PublicProfileService struct {
+	userRepo    repositories.UserRepositoryInterface
+	profileRepo repositories.ProfileRepositoryInterface
+	views       views.Store
+	window      time.Duration
+}
+
+func NewPublicProfileService(userRepo repositories.UserRepositoryInterface, profileRepo repositories.ProfileRepositoryInterface, viewStore views.Store, window time.Duration) *PublicProfileService {
+	return &PublicProfileService{
+		userRepo:    userRepo,
+		profileRepo: profileRepo,
+		views:       viewStore,
+		window:      window,
+	}
+}
+
+// Get retorna el perfil público del usuario ref (ID o username) con los campos que viewer
+// puede ver y cuenta la vista. Los usuarios que no están activos solo los ven ellos mismos y
+// los visitantes privilegiados
+func (s *PublicProfileService) Get(ctx context.Context, ref string, viewer Viewer) (*models.PublicProfile, error) {
+	user, err := s.lookup(ctx, ref)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	relationship := relationshipOf(user.ID, viewer)
+	if (user.Status != models.StatusActive || user.Disabled) && relationship != ViewerSelf && relationship != ViewerPrivileged {
+		return nil, ErrProfileNotFound
+	}
+
+	profile, err := s.profileRepo.GetByUserID(ctx, user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		profile, err = &models.UserProfile{UserID: user.ID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.profileRepo.GetSettingsByUserID(ctx, user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settings, err = &models.UserSettings{UserID: user.ID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if relationship != ViewerSelf {
+		s.countView(ctx, user.ID, viewer)
+	}
+	return project(user, profile, settings, relationship), nil
+}
+
+// lookup busca el usuario por ID si ref es un UUID y por username si no
+func (s *PublicProfileService) lookup(ctx context.Context, ref string) (*models.User, error) {
+	if _, err := uuid.Parse(ref); err == nil {
+		return s.userRepo.GetByID(ctx, ref)
+	}
+	return s.userRepo.GetByUsername(ctx, ref)
+}
+
+// countView suma una vista al perfil si el visitante no lo vio dentro de la ventana. Los
+// errores se registran sin fallar el request
+func (s *PublicProfileService) countView(ctx context.Context, userID string, viewer Viewer) {
+	if s.views == nil || viewer.Key == "" {
+		return
+	}
+	log := logger.FromContext(ctx).WithField("user_id", userID)
+
+	first, err := s.views.Record(ctx, userID, viewer.Key, s.window)
+	if err != nil {
+		log.WithError(err).Warn("Failed to deduplicate profile view")
+		return
+	}
+	if !first {
+		return
+	}
+	if err := s.profileRepo.IncrementProfileViews(ctx, userID); err != nil {
+		log.WithError(err).Warn("Failed to increment profile views")
+	}
+}
+
+func relationshipOf(userID string, viewer Viewer) string {
+	switch {
+	case viewer.UserID != "" && viewer.UserID == userID:
+		return ViewerSelf
+	case viewer.Privileged:
+		return ViewerPrivileged
+	case viewer.UserID != "":
+		return ViewerAuthenticated
+	}
+	return ViewerAnonymous
+}
+
+// canSee indica si un visitante con relationship puede ver un campo con visibility. Todavía
+// no hay un modelo de contactos: los campos contacts solo los ven el propio usuario y los
+// visitantes privilegiados, igual que los private
+func canSee(visibility, relationship string) bool {
+	switch relationship {
+	case ViewerSelf, ViewerPrivileged:
+		return true
+	case ViewerAuthenticated:
+		return visibility == schemas.VisibilityPublic || visibility == schemas.VisibilityAuthenticated
+	}
+	return visibility == schemas.VisibilityPublic
+}
+
+// project arma la proyección con los campos visibles. La visibilidad general del perfil
+// (profile_visibility) limita todos los campos, incluidos el username y el nombre
+func project(user *models.User, profile *models.UserProfile, settings *models.UserSettings, relationship string) *models.PublicProfile {
+	public := &models.PublicProfile{UserID: user.ID, Relationship: relationship}
+
+	profileVisibility, _ := schemas.Privacy.WithDefaults(settings.Privacy)["profile_visibility"].(string)
+	if !canSee(profileVisibility, relationship) {
+		return public
+	}
+	public.Username = user.Username
+	public.FirstName = user.FirstName
+	public.LastName = user.LastName
+
+	privacy := schemas.ProfilePrivacy.WithDefaults(profile.Privacy)
+	visible := func(field string) bool {
+		visibility, _ := privacy[field].(string)
+		return canSee(visibility, relationship)
+	}
+	if visible("avatar") {
+		public.Avatar = profile.Avatar
+	}
+	if visible("bio") {
+		public.Bio = profile.Bio
+	}
+	if visible("website") {
+		public.Website = profile.Website
+	}
+	if visible("location") {
+		public.Location = profile.Location
+	}
+	if visible("birthday") {
+		public.Birthday = profile.Birthday
+	}
+	if visible("gender") {
+		public.Gender = profile.Gender
+	}
+	if visible("phone") {
+		public.Phone = profile.Phone
+	}
+	return public
+}
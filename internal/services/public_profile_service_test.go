@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+	"it-user-service/internal/views"
+)
+
+type fakePublicUserRepo struct {
+	repositories.UserRepositoryInterface
+	user *models.User
+}
+
+func (r *fakePublicUserRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
+	if id != r.user.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.user, nil
+}
+
+func (r *fakePublicUserRepo) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	if username != r.user.Username {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.user, nil
+}
+
+type fakePublicProfileRepo struct {
+	repositories.ProfileRepositoryInterface
+	profile  *models.UserProfile
+	settings *models.UserSettings
+	views    int
+}
+
+func (r *fakePublicProfileRepo) GetByUserID(ctx context.Context, userID string) (*models.UserProfile, error) {
+	return r.profile, nil
+}
+
+func (r *fakePublicProfileRepo) GetSettingsByUserID(ctx context.Context, userID string) (*models.UserSettings, error) {
+	if r.settings == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.settings, nil
+}
+
+func (r *fakePublicProfileRepo) IncrementProfileViews(ctx context.Context, userID string) error {
+	r.views++
+	return nil
+}
+
+func TestPublicProfileService_AppliesFieldVisibility(t *testing.T) {
+	birthday := time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)
+	userID := "6f1c3a52-8a3e-4d4e-9f43-2b7f1c0d9a11"
+	profiles := &fakePublicProfileRepo{profile: &models.UserProfile{
+		UserID:   userID,
+		Bio:      "hello",
+		Location: "Lima",
+		Phone:    "+51 999",
+		Birthday: &birthday,
+		Privacy:  models.JSONDocument{"phone": "authenticated"},
+	}}
+	service := NewPublicProfileService(
+		&fakePublicUserRepo{user: &models.User{ID: userID, Username: "ana", Status: models.StatusActive}},
+		profiles, views.NewMemoryStore(), time.Hour,
+	)
+
+	anonymous, err := service.Get(context.Background(), "ana", Viewer{Key: "ip:10.0.0.1"})
+	require.NoError(t, err)
+	assert.Equal(t, ViewerAnonymous, anonymous.Relationship)
+	assert.Equal(t, "hello", anonymous.Bio)
+	assert.Empty(t, anonymous.Location)
+	assert.Empty(t, anonymous.Phone)
+
+	member, err := service.Get(context.Background(), userID, Viewer{UserID: "u2", Key: "user:u2"})
+	require.NoError(t, err)
+	assert.Equal(t, "Lima", member.Location)
+	assert.Equal(t, "+51 999", member.Phone)
+	assert.Nil(t, member.Birthday)
+
+	owner, err := service.Get(context.Background(), userID, Viewer{UserID: userID, Key: "user:" + userID})
+	require.NoError(t, err)
+	assert.Equal(t, &birthday, owner.Birthday)
+
+	// Las vistas repetidas del mismo visitante y las propias no se cuentan
+	_, err = service.Get(context.Background(), "ana", Viewer{UserID: "u2", Key: "user:u2"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, profiles.views)
+
+	// profile_visibility limita todos los campos
+	profiles.settings = &models.UserSettings{Privacy: models.JSONDocument{"profile_visibility": "private"}}
+	hidden, err := service.Get(context.Background(), "ana", Viewer{UserID: "u2", Key: "user:u2"})
+	require.NoError(t, err)
+	assert.Equal(t, &models.PublicProfile{UserID: userID, Relationship: ViewerAuthenticated}, hidden)
+
+	_, err = service.Get(context.Background(), "bob", Viewer{})
+	assert.ErrorIs(t, err, ErrProfileNotFound)
+}
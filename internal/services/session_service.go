@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"it-user-service/internal/auth"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+// SessionService gestiona las sesiones/dispositivos de los usuarios y mantiene
+// sincronizada la lista de revocación que consulta el middleware de autenticación
+type SessionService struct {
+	sessionRepo repositories.SessionRepositoryInterface
+	refreshRepo repositories.RefreshTokenRepositoryInterface
+	revocations auth.RevocationList
+	// local es la lista de esta réplica, que se sincroniza con la base de datos
+	local    *auth.MemoryRevocationList
+	tokenTTL time.Duration
+}
+
+// NewSessionService crea el servicio. revocations es la lista que consulta el middleware
+// (compartida entre réplicas o, si es nil, la lista local)
+func NewSessionService(sessionRepo repositories.SessionRepositoryInterface, refreshRepo repositories.RefreshTokenRepositoryInterface, local *auth.MemoryRevocationList, revocations auth.RevocationList, tokenTTL time.Duration) *SessionService {
+	if revocations == nil {
+		revocations = local
+	}
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		refreshRepo: refreshRepo,
+		revocations: revocations,
+		local:       local,
+		tokenTTL:    tokenTTL,
+	}
+}
+
+// ListSessions obtiene las sesiones de un usuario
+func (s *SessionService) ListSessions(ctx context.Context, userID string, includeRevoked bool) ([]models.Session, error) {
+	return s.sessionRepo.GetByUserID(ctx, userID, includeRevoked)
+}
+
+// RevokeSession revoca la sesión y sus refresh tokens, y la agrega inmediatamente a la
+// lista de revocación, de modo que los tokens emitidos para ella se rechazan desde el
+// siguiente request (en todas las réplicas si la lista es compartida)
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if err := s.sessionRepo.Revoke(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.revocations.Revoke(ctx, sessionID, time.Now().Add(s.tokenTTL))
+	return s.refreshRepo.RevokeBySession(ctx, sessionID, "session_revoked")
+}
+
+// SyncRevocations carga en la lista local las sesiones revocadas cuyos tokens aún pueden
+// estar vigentes. Sin lista compartida (o si Redis falla) permite que las revocaciones
+// hechas por otras réplicas se apliquen en esta
+func (s *SessionService) SyncRevocations(ctx context.Context) error {
+	sessions, err := s.sessionRepo.GetRevokedSince(ctx, time.Now().Add(-s.tokenTTL))
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		s.local.Revoke(ctx, session.ID, session.RevokedAt.Add(s.tokenTTL))
+	}
+	s.local.Prune()
+	return nil
+}
+
+// StartRevocationSync sincroniza la lista de revocación cada interval hasta que ctx se cancele
+func (s *SessionService) StartRevocationSync(ctx context.Context, interval time.Duration) {
+	log := logger.GetLogger()
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if err := s.SyncRevocations(ctx); err != nil {
+		log.WithError(err).Error("Failed to load revoked sessions")
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.SyncRevocations(ctx); err != nil {
+					log.WithError(err).Error("Failed to sync revoked sessions")
+				}
+			}
+		}
+	}()
+}
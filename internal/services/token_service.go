@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/events"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+var (
+	ErrInvalidIDToken       = errors.New("invalid id token")
+	ErrUserNotRegistered    = errors.New("user not registered")
+	ErrUserDisabled         = errors.New("user is disabled")
+	ErrInvalidRefreshToken  = errors.New("invalid refresh token")
+	ErrRefreshTokenReused   = errors.New("refresh token reuse detected")
+	ErrTokenIssuingDisabled = errors.New("token issuing is not configured")
+)
+
+// TokenConfig configura la emisión de tokens internos
+type TokenConfig struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// IDTokenVerifier verifica los ID tokens de Firebase que se intercambian por tokens internos
+type IDTokenVerifier interface {
+	Verify(ctx context.Context, idToken string) (*auth.FirebaseClaims, error)
+}
+
+// TokenService intercambia ID tokens de Firebase por tokens internos firmados por este
+// servicio (con roles), de modo que los demás servicios autoricen localmente sin
+// consultarnos. Emite refresh tokens rotativos con detección de reutilización
+type TokenService struct {
+	userRepo       repositories.UserRepositoryInterface
+	roleRepo       repositories.RoleRepositoryInterface
+	sessionRepo    repositories.SessionRepositoryInterface
+	refreshRepo    repositories.RefreshTokenRepositoryInterface
+	loginService   *LoginService
+	sessionService *SessionService
+	jwtManager     *auth.JWTManager
+	firebase       IDTokenVerifier
+	publisher      events.Publisher
+	cfg            TokenConfig
+}
+
+func NewTokenService(userRepo repositories.UserRepositoryInterface, roleRepo repositories.RoleRepositoryInterface, sessionRepo repositories.SessionRepositoryInterface, refreshRepo repositories.RefreshTokenRepositoryInterface, loginService *LoginService, sessionService *SessionService, jwtManager *auth.JWTManager, firebase IDTokenVerifier, publisher events.Publisher, cfg TokenConfig) *TokenService {
+	return &TokenService{
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		sessionRepo:    sessionRepo,
+		refreshRepo:    refreshRepo,
+		loginService:   loginService,
+		sessionService: sessionService,
+		jwtManager:     jwtManager,
+		firebase:       firebase,
+		publisher:      publisher,
+		cfg:            cfg,
+	}
+}
+
+// ExchangeFirebaseToken verifica el ID token de Firebase, registra el login y la sesión
+// del dispositivo y emite un access token con los roles del usuario y un refresh token
+func (s *TokenService) ExchangeFirebaseToken(ctx context.Context, idToken string, event *models.LoginEvent, session *models.Session) (*models.TokenResponse, error) {
+	if s.jwtManager == nil || s.firebase == nil {
+		return nil, ErrTokenIssuingDisabled
+	}
+
+	claims, err := s.firebase.Verify(ctx, idToken)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Warn("Firebase ID token verification failed")
+		return nil, ErrInvalidIDToken
+	}
+
+	user, err := s.userRepo.GetByFirebaseID(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotRegistered
+		}
+		return nil, err
+	}
+	if !userCanSignIn(user) {
+		return nil, ErrUserDisabled
+	}
+
+	event.UserID = user.ID
+	event.Provider = claims.Firebase.SignInProvider
+	event.Success = true
+	if err := s.loginService.RecordLogin(ctx, event, session); err != nil {
+		return nil, err
+	}
+
+	// Un login sospechoso puede haber pasado el usuario a pending: sin tokens y sin sesión
+	if event.Flagged {
+		user, err = s.userRepo.GetByID(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !userCanSignIn(user) {
+			if err := s.sessionService.RevokeSession(ctx, user.ID, session.ID); err != nil {
+				logger.FromContext(ctx).WithError(err).WithField("session_id", session.ID).Error("Failed to revoke session of suspicious login")
+			}
+			return nil, ErrUserDisabled
+		}
+	}
+
+	return s.issue(ctx, user, session.ID, uuid.NewString(), nil)
+}
+
+// Refresh rota un refresh token: lo consume y emite un nuevo par. Si el token ya había
+// sido usado se asume robo: se revoca toda la familia y la sesión
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	if s.jwtManager == nil {
+		return nil, ErrTokenIssuingDisabled
+	}
+
+	stored, err := s.refreshRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	consumed, err := s.refreshRepo.MarkUsed(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		s.handleReuse(ctx, stored)
+		return nil, ErrRefreshTokenReused
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, stored.SessionID)
+	if err != nil || !session.Active() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !userCanSignIn(user) {
+		s.refreshRepo.RevokeFamily(ctx, stored.FamilyID, "user_disabled")
+		return nil, ErrUserDisabled
+	}
+
+	return s.issue(ctx, user, stored.SessionID, stored.FamilyID, &stored.ID)
+}
+
+// Revoke revoca el refresh token (toda su familia) y la sesión asociada. Un token
+// desconocido no es un error, para no revelar qué tokens existen
+func (s *TokenService) Revoke(ctx context.Context, refreshToken string) error {
+	stored, err := s.refreshRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID, "revoked_by_client"); err != nil {
+		return err
+	}
+	if err := s.sessionService.RevokeSession(ctx, stored.UserID, stored.SessionID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s *TokenService) handleReuse(ctx context.Context, stored *models.RefreshToken) {
+	log := logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"user_id":    stored.UserID,
+		"session_id": stored.SessionID,
+		"family_id":  stored.FamilyID,
+	})
+	log.Warn("Refresh token reuse detected, revoking token family and session")
+
+	if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID, "reuse_detected"); err != nil {
+		log.WithError(err).Error("Failed to revoke refresh token family")
+	}
+	if err := s.sessionService.RevokeSession(ctx, stored.UserID, stored.SessionID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.WithError(err).Error("Failed to revoke session after refresh token reuse")
+	}
+
+	if s.publisher != nil {
+		payload := map[string]interface{}{
+			"session_id": stored.SessionID,
+			"family_id":  stored.FamilyID,
+		}
+		if err := s.publisher.Publish(ctx, events.New(events.TypeRefreshTokenReused, stored.UserID, payload)); err != nil {
+			log.WithError(err).Error("Failed to emit refresh token reuse event")
+		}
+	}
+}
+
+// issue emite un access token y un nuevo refresh token de la familia indicada
+func (s *TokenService) issue(ctx context.Context, user *models.User, sessionID, familyID string, parentID *string) (*models.TokenResponse, error) {
+	userRoles, err := s.roleRepo.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]string, 0, len(userRoles))
+	for _, userRole := range userRoles {
+		roles = append(roles, userRole.Role)
+	}
+
+	accessToken, _, err := s.jwtManager.GenerateSessionToken(user.ID, user.Email, sessionID, roles, s.cfg.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	stored := &models.RefreshToken{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		SessionID: sessionID,
+		FamilyID:  familyID,
+		ParentID:  parentID,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTTL),
+	}
+	if err := s.refreshRepo.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.cfg.AccessTTL.Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(s.cfg.RefreshTTL.Seconds()),
+		SessionID:        sessionID,
+	}, nil
+}
+
+func userCanSignIn(user *models.User) bool {
+	return !user.Disabled && user.Status == "active"
+}
+
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashRefreshToken calcula el hash con el que se guarda el refresh token
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"it-user-service/internal/auth"
+	"it-user-service/internal/config"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+type fakeIDTokenVerifier struct{}
+
+func (v fakeIDTokenVerifier) Verify(ctx context.Context, idToken string) (*auth.FirebaseClaims, error) {
+	claims := &auth.FirebaseClaims{}
+	claims.Subject = idToken
+	claims.Firebase.SignInProvider = "password"
+	return claims, nil
+}
+
+type fakeTokenUserRepo struct {
+	repositories.UserRepositoryInterface
+	user *models.User
+}
+
+func (r *fakeTokenUserRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return r.user, nil
+}
+
+func (r *fakeTokenUserRepo) GetByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error) {
+	if firebaseID != r.user.FirebaseID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.user, nil
+}
+
+type fakeTokenRoleRepo struct {
+	repositories.RoleRepositoryInterface
+}
+
+func (r *fakeTokenRoleRepo) GetUserRoles(ctx context.Context, userID string) ([]*models.UserRole, error) {
+	return []*models.UserRole{{UserID: userID, Role: "editor"}}, nil
+}
+
+type fakeLoginEventRepo struct {
+	repositories.LoginEventRepositoryInterface
+	sessions *fakeSessionRepo
+	events   []models.LoginEvent
+}
+
+func (r *fakeLoginEventRepo) Record(_ context.Context, event *models.LoginEvent) error {
+	event.ID = uint(len(r.events) + 1)
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeLoginEventRepo) RecordWithSession(ctx context.Context, event *models.LoginEvent, session *models.Session) error {
+	if err := r.sessions.Touch(ctx, session); err != nil {
+		return err
+	}
+	event.SessionID = session.ID
+	return r.Record(ctx, event)
+}
+
+func (r *fakeLoginEventRepo) GetByUserID(_ context.Context, userID string, limit, offset int) ([]models.LoginEvent, error) {
+	return r.events, nil
+}
+
+type fakeSessionRepo struct {
+	sessions map[string]*models.Session
+}
+
+func (r *fakeSessionRepo) Touch(_ context.Context, session *models.Session) error {
+	if session.ID == "" {
+		session.ID = uuid.NewString()
+		session.FirstSeenAt = time.Now()
+		session.LastSeenAt = session.FirstSeenAt
+	}
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *fakeSessionRepo) GetByID(_ context.Context, id string) (*models.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return session, nil
+}
+
+func (r *fakeSessionRepo) GetByUserID(_ context.Context, userID string, includeRevoked bool) ([]models.Session, error) {
+	var sessions []models.Session
+	for _, session := range r.sessions {
+		sessions = append(sessions, *session)
+	}
+	return sessions, nil
+}
+
+func (r *fakeSessionRepo) Revoke(_ context.Context, userID, sessionID string) error {
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	return nil
+}
+
+func (r *fakeSessionRepo) GetRevokedSince(_ context.Context, since time.Time) ([]models.Session, error) {
+	return nil, nil
+}
+
+type fakeRefreshTokenRepo struct {
+	tokens map[string]*models.RefreshToken
+}
+
+func (r *fakeRefreshTokenRepo) Create(_ context.Context, token *models.RefreshToken) error {
+	r.tokens[token.ID] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByHash(_ context.Context, tokenHash string) (*models.RefreshToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRefreshTokenRepo) MarkUsed(_ context.Context, id string) (bool, error) {
+	token := r.tokens[id]
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return true, nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeFamily(_ context.Context, familyID, reason string) error {
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			now := time.Now()
+			token.RevokedAt, token.RevokedReason = &now, reason
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeBySession(_ context.Context, sessionID, reason string) error {
+	for _, token := range r.tokens {
+		if token.SessionID == sessionID && token.RevokedAt == nil {
+			now := time.Now()
+			token.RevokedAt, token.RevokedReason = &now, reason
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+type tokenServiceFixture struct {
+	service     *TokenService
+	users       repositories.UserRepositoryInterface
+	jwtManager  *auth.JWTManager
+	user        *models.User
+	logins      *fakeLoginEventRepo
+	sessions    *fakeSessionRepo
+	refresh     *fakeRefreshTokenRepo
+	revocations *auth.MemoryRevocationList
+}
+
+func newTokenServiceFixture(riskCfg config.LoginRiskConfig) *tokenServiceFixture {
+	f := &tokenServiceFixture{
+		jwtManager:  auth.NewJWTManager("secret", "it-user-service"),
+		user:        &models.User{ID: "u1", FirebaseID: "fb-u1", Email: "u1@example.com", Status: models.StatusActive},
+		sessions:    &fakeSessionRepo{sessions: map[string]*models.Session{}},
+		refresh:     &fakeRefreshTokenRepo{tokens: map[string]*models.RefreshToken{}},
+		revocations: auth.NewMemoryRevocationList(),
+	}
+	f.logins = &fakeLoginEventRepo{sessions: f.sessions}
+	userRepo := &fakeTokenUserRepo{user: f.user}
+	statuses := NewUserStatusService(&fakeStatusRepo{}, nil)
+	loginService := NewLoginService(userRepo, f.logins, f.sessions, statuses, nil, nil, riskCfg)
+	sessionService := NewSessionService(f.sessions, f.refresh, f.revocations, nil, time.Minute)
+	f.users = repositories.WithLoginRecorder(userRepo, loginService)
+	f.service = NewTokenService(userRepo, &fakeTokenRoleRepo{}, f.sessions, f.refresh, loginService, sessionService,
+		f.jwtManager, fakeIDTokenVerifier{}, nil, TokenConfig{AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	return f
+}
+
+func (f *tokenServiceFixture) exchange(t *testing.T, device string) (*models.TokenResponse, error) {
+	t.Helper()
+	return f.service.ExchangeFirebaseToken(context.Background(), f.user.FirebaseID,
+		&models.LoginEvent{IP: "10.0.0.1", Device: device}, &models.Session{DeviceID: device})
+}
+
+func TestTokenService_ExchangeIssuesTokensWithRoles(t *testing.T) {
+	f := newTokenServiceFixture(config.LoginRiskConfig{})
+
+	tokens, err := f.exchange(t, "laptop")
+	require.NoError(t, err)
+
+	claims, err := f.jwtManager.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "u1", claims.UserID)
+	assert.Equal(t, []string{"editor"}, claims.Roles)
+	assert.Equal(t, tokens.SessionID, claims.SessionID)
+	require.Len(t, f.logins.events, 1)
+	assert.Equal(t, tokens.SessionID, f.logins.events[0].SessionID)
+	assert.Len(t, f.refresh.tokens, 1)
+}
+
+func TestTokenService_ExchangeRejectsLoginThatMovesUserToPending(t *testing.T) {
+	f := newTokenServiceFixture(config.LoginRiskConfig{
+		Enabled:              true,
+		FlagThreshold:        30,
+		AutoPendingThreshold: 30,
+		NewDeviceScore:       30,
+	})
+
+	_, err := f.exchange(t, "laptop")
+	require.NoError(t, err)
+
+	// Un dispositivo nuevo supera el umbral y el usuario pasa a pending
+	_, err = f.exchange(t, "phone")
+	assert.ErrorIs(t, err, ErrUserDisabled)
+	assert.Equal(t, models.StatusPending, f.user.Status)
+	assert.Len(t, f.refresh.tokens, 1)
+
+	require.Len(t, f.logins.events, 2)
+	session := f.sessions.sessions[f.logins.events[1].SessionID]
+	require.NotNil(t, session)
+	assert.False(t, session.Active())
+	assert.True(t, f.revocations.IsRevoked(context.Background(), session.ID))
+}
+
+func TestTokenService_RefreshRotatesAndDetectsReuse(t *testing.T) {
+	f := newTokenServiceFixture(config.LoginRiskConfig{})
+	ctx := context.Background()
+
+	first, err := f.exchange(t, "laptop")
+	require.NoError(t, err)
+
+	second, err := f.service.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, first.SessionID, second.SessionID)
+
+	// Reusar el token ya rotado revoca la familia y la sesión
+	_, err = f.service.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = f.service.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.False(t, f.sessions.sessions[first.SessionID].Active())
+	assert.True(t, f.revocations.IsRevoked(ctx, first.SessionID))
+}
+
+func TestTokenService_RevokeEndsSession(t *testing.T) {
+	f := newTokenServiceFixture(config.LoginRiskConfig{})
+	ctx := context.Background()
+
+	tokens, err := f.exchange(t, "laptop")
+	require.NoError(t, err)
+
+	require.NoError(t, f.service.Revoke(ctx, tokens.RefreshToken))
+	assert.NoError(t, f.service.Revoke(ctx, "unknown"))
+
+	_, err = f.service.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.False(t, f.sessions.sessions[tokens.SessionID].Active())
+	assert.True(t, f.revocations.IsRevoked(ctx, tokens.SessionID))
+}
+
+func TestLoginRecorder_UpdateLoginInfoAssessesRisk(t *testing.T) {
+	f := newTokenServiceFixture(config.LoginRiskConfig{
+		Enabled:              true,
+		FlagThreshold:        30,
+		AutoPendingThreshold: 30,
+		NewDeviceScore:       30,
+	})
+	ctx := context.Background()
+
+	require.NoError(t, f.users.UpdateLoginInfo(ctx, f.user.ID, "10.0.0.1", "laptop"))
+	require.NoError(t, f.users.UpdateLoginInfo(ctx, f.user.ID, "10.0.0.1", "phone"))
+
+	require.Len(t, f.logins.events, 2)
+	assert.False(t, f.logins.events[0].Flagged)
+	assert.True(t, f.logins.events[1].Flagged)
+	assert.Equal(t, models.StatusPending, f.user.Status)
+}
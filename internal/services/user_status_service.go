@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"it-user-service/internal/events"
+	"it-user-service/internal/logger"
+	"it-user-service/internal/models"
+	"it-user-service/internal/repositories"
+)
+
+var (
+	ErrStatusTransitionNotAllowed = errors.New("status transition not allowed")
+	ErrInvalidSuspensionUntil     = errors.New("until is only allowed for suspended and must be in the future")
+)
+
+// ActorSystem identifica los cambios de estado automáticos en el historial
+const ActorSystem = "system"
+
+// expiredSuspensionsBatch es la cantidad de suspensiones vencidas procesadas por ciclo
+const expiredSuspensionsBatch = 100
+
+// StatusTransition es un cambio de estado solicitado para un usuario
+type StatusTransition struct {
+	Status string
+	Reason string
+	Note   string
+	// Until es el fin de una suspensión temporal (nil = suspensión indefinida)
+	Until *time.Time
+	// Actor es el subject de quien pide el cambio
+	Actor string
+}
+
+// UserStatusService aplica la máquina de estados de los usuarios: valida las transiciones,
+// las registra en el historial y emite un evento por cada cambio
+type UserStatusService struct {
+	statusRepo repositories.UserStatusRepositoryInterface
+	publisher  events.Publisher
+	now        func() time.Time
+}
+
+func NewUserStatusService(statusRepo repositories.UserStatusRepositoryInterface, publisher events.Publisher) *UserStatusService {
+	return &UserStatusService{
+		statusRepo: statusRepo,
+		publisher:  publisher,
+		now:        time.Now,
+	}
+}
+
+// Transition cambia el estado de user. Retorna ErrStatusTransitionNotAllowed si la máquina de
+// estados no permite el cambio y repositories.ErrVersionConflict si el usuario cambió desde
+// que se leyó. Al salir de suspended se descarta la fecha de fin de la suspensión
+func (s *UserStatusService) Transition(ctx context.Context, user *models.User, t StatusTransition) (*models.UserStatusChange, error) {
+	if !models.CanTransition(user.Status, t.Status) {
+		return nil, ErrStatusTransitionNotAllowed
+	}
+	if t.Until != nil && (t.Status != models.StatusSuspended || !t.Until.After(s.now())) {
+		return nil, ErrInvalidSuspensionUntil
+	}
+
+	change := &models.UserStatusChange{
+		UserID:     user.ID,
+		FromStatus: user.Status,
+		ToStatus:   t.Status,
+		Reason:     t.Reason,
+		Note:       t.Note,
+		Until:      t.Until,
+		Actor:      t.Actor,
+	}
+	previous := *user
+	user.Status = t.Status
+	user.SuspendedUntil = t.Until
+	if err := s.statusRepo.Transition(ctx, user, change); err != nil {
+		*user = previous
+		return nil, err
+	}
+
+	s.publish(ctx, change)
+	return change, nil
+}
+
+// History retorna los cambios de estado de un usuario, del más reciente al más antiguo
+func (s *UserStatusService) History(ctx context.Context, userID string, limit, offset int) ([]models.UserStatusChange, error) {
+	return s.statusRepo.GetHistory(ctx, userID, limit, offset)
+}
+
+// ReinstateExpiredSuspensions reactiva los usuarios cuya suspensión venció y retorna cuántos
+// reactivó. Con varias réplicas, la réplica que pierde la carrera recibe un conflicto de
+// versión y omite al usuario
+func (s *UserStatusService) ReinstateExpiredSuspensions(ctx context.Context) (int, error) {
+	users, err := s.statusRepo.GetExpiredSuspensions(ctx, s.now(), expiredSuspensionsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	reinstated := 0
+	for i := range users {
+		_, err := s.Transition(ctx, &users[i], StatusTransition{
+			Status: models.StatusActive,
+			Reason: models.StatusReasonSuspensionExpired,
+			Actor:  ActorSystem,
+		})
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			continue
+		}
+		if err != nil {
+			return reinstated, err
+		}
+		reinstated++
+	}
+	return reinstated, nil
+}
+
+// StartSuspensionExpiry reactiva las suspensiones vencidas cada interval hasta que ctx se cancele
+func (s *UserStatusService) StartSuspensionExpiry(ctx context.Context, interval time.Duration) {
+	log := logger.GetLogger()
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reinstated, err := s.ReinstateExpiredSuspensions(ctx)
+				if err != nil {
+					log.WithError(err).Error("Failed to reinstate expired suspensions")
+				}
+				if reinstated > 0 {
+					log.WithField("count", reinstated).Info("Expired suspensions reinstated")
+				}
+			}
+		}
+	}()
+}
+
+func (s *UserStatusService) publish(ctx context.Context, change *models.UserStatusChange) {
+	if s.publisher == nil {
+		return
+	}
+	payload := map[string]interface{}{
+		"status_change_id": change.ID,
+		"from_status":      change.FromStatus,
+		"to_status":        change.ToStatus,
+		"reason":           change.Reason,
+		"actor":            change.Actor,
+	}
+	if change.Until != nil {
+		payload["until"] = change.Until.UTC().Format(time.RFC3339)
+	}
+	if err := s.publisher.Publish(ctx, events.New(events.TypeUserStatusChanged, change.UserID, payload)); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("user_id", change.UserID).Error("Failed to emit user status changed event")
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"it-user-service/internal/events"
+	"it-user-service/internal/models"
+)
+
+type fakeStatusRepo struct {
+	changes []models.UserStatusChange
+	expired []models.User
+}
+
+func (r *fakeStatusRepo) Transition(ctx context.Context, user *models.User, change *models.UserStatusChange) error {
+	user.Version++
+	r.changes = append(r.changes, *change)
+	return nil
+}
+
+func (r *fakeStatusRepo) GetHistory(ctx context.Context, userID string, limit, offset int) ([]models.UserStatusChange, error) {
+	return r.changes, nil
+}
+
+func (r *fakeStatusRepo) GetExpiredSuspensions(ctx context.Context, now time.Time, limit int) ([]models.User, error) {
+	return r.expired, nil
+}
+
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestUserStatusService_TransitionRecordsHistoryAndEvent(t *testing.T) {
+	repo := &fakeStatusRepo{}
+	publisher := &recordingPublisher{}
+	service := NewUserStatusService(repo, publisher)
+	until := time.Now().Add(time.Hour)
+	user := &models.User{ID: "u1", Status: models.StatusActive, Version: 1}
+
+	change, err := service.Transition(context.Background(), user, StatusTransition{
+		Status: models.StatusSuspended,
+		Reason: models.StatusReasonPolicyViolation,
+		Until:  &until,
+		Actor:  "user:admin",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.StatusSuspended, user.Status)
+	assert.Equal(t, &until, user.SuspendedUntil)
+	assert.Equal(t, models.StatusActive, change.FromStatus)
+	assert.Len(t, repo.changes, 1)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, events.TypeUserStatusChanged, publisher.events[0].Type)
+	assert.Equal(t, models.StatusSuspended, publisher.events[0].Payload["to_status"])
+}
+
+func TestUserStatusService_RejectsInvalidTransitions(t *testing.T) {
+	service := NewUserStatusService(&fakeStatusRepo{}, nil)
+	past := time.Now().Add(-time.Hour)
+
+	deleted := &models.User{Status: models.StatusDeleted}
+	_, err := service.Transition(context.Background(), deleted, StatusTransition{Status: models.StatusActive, Reason: models.StatusReasonAdminAction})
+	assert.ErrorIs(t, err, ErrStatusTransitionNotAllowed)
+
+	pending := &models.User{Status: models.StatusPending}
+	_, err = service.Transition(context.Background(), pending, StatusTransition{Status: models.StatusSuspended, Reason: models.StatusReasonFraud})
+	assert.ErrorIs(t, err, ErrStatusTransitionNotAllowed)
+
+	active := &models.User{Status: models.StatusActive}
+	_, err = service.Transition(context.Background(), active, StatusTransition{Status: models.StatusSuspended, Reason: models.StatusReasonFraud, Until: &past})
+	assert.ErrorIs(t, err, ErrInvalidSuspensionUntil)
+	_, err = service.Transition(context.Background(), active, StatusTransition{Status: models.StatusDeactivated, Reason: models.StatusReasonInactivity, Until: &past})
+	assert.ErrorIs(t, err, ErrInvalidSuspensionUntil)
+	assert.Equal(t, models.StatusActive, active.Status)
+}
+
+func TestUserStatusService_ReinstatesExpiredSuspensions(t *testing.T) {
+	until := time.Now().Add(-time.Minute)
+	repo := &fakeStatusRepo{expired: []models.User{{ID: "u1", Status: models.StatusSuspended, SuspendedUntil: &until}}}
+	service := NewUserStatusService(repo, nil)
+
+	reinstated, err := service.ReinstateExpiredSuspensions(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, reinstated)
+	require.Len(t, repo.changes, 1)
+	assert.Equal(t, models.StatusActive, repo.changes[0].ToStatus)
+	assert.Equal(t, models.StatusReasonSuspensionExpired, repo.changes[0].Reason)
+	assert.Equal(t, ActorSystem, repo.changes[0].Actor)
+	assert.Nil(t, repo.expired[0].SuspendedUntil)
+}
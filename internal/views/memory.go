@@ -0,0 +1,63 @@
+package views
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore guarda las vistas en memoria. Las vistas no se comparten entre réplicas
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Record registra la vista si viewer no vio el perfil dentro de window
+func (s *MemoryStore) Record(ctx context.Context, userID, viewer string, window time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	k := key(userID, viewer)
+	if expiresAt, ok := s.entries[k]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	s.entries[k] = now.Add(window)
+	return true, nil
+}
+
+// StartCleanup descarta periódicamente las vistas expiradas hasta que ctx se cancele
+func (s *MemoryStore) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.cleanup()
+			}
+		}
+	}()
+}
+
+func (s *MemoryStore) cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, expiresAt := range s.entries {
+		if !now.Before(expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package views
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore comparte las vistas entre réplicas usando Redis. Cada vista es un SET NX que
+// expira al terminar la ventana
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Record registra la vista si viewer no vio el perfil dentro de window
+func (s *RedisStore) Record(ctx context.Context, userID, viewer string, window time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.prefix+key(userID, viewer), 1, window).Result()
+}
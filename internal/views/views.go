@@ -0,0 +1,19 @@
+package views
+
+import (
+	"context"
+	"time"
+)
+
+// Store recuerda qué visitantes ya vieron cada perfil para contar una sola vista por
+// visitante dentro de una ventana. MemoryStore sirve para una réplica; RedisStore comparte
+// las vistas entre réplicas
+type Store interface {
+	// Record registra la vista de viewer sobre el perfil de userID y retorna si es la primera
+	// dentro de window
+	Record(ctx context.Context, userID, viewer string, window time.Duration) (first bool, err error)
+}
+
+func key(userID, viewer string) string {
+	return userID + ":" + viewer
+}
@@ -0,0 +1,29 @@
+package views
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_DeduplicatesWithinWindow(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	first, err := store.Record(context.Background(), "u1", "user:u2", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, first)
+
+	first, _ = store.Record(context.Background(), "u1", "user:u2", time.Hour)
+	assert.False(t, first)
+	first, _ = store.Record(context.Background(), "u1", "ip:10.0.0.1", time.Hour)
+	assert.True(t, first)
+
+	now = now.Add(time.Hour)
+	first, _ = store.Record(context.Background(), "u1", "user:u2", time.Hour)
+	assert.True(t, first)
+}
@@ -26,6 +26,8 @@ go build -o bin/it-user-service ./cmd
 
 if [ $? -eq 0 ]; then
     echo "✅ Compilación exitosa"
+    echo "🗄️  Aplicando migraciones..."
+    ./bin/it-user-service migrate || exit 1
     echo "🌐 Iniciando servidor en http://localhost:$PORT"
     echo "📋 Health check: http://localhost:$PORT/api/v1/health"
     echo ""